@@ -1,17 +1,24 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/artifacts"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/automation"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/browserpool"
 	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/handlers"
-	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/models"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/history"
 	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/queue"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/webhook"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/worker"
 	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/pkg/logger"
 	"github.com/rs/cors"
 )
@@ -23,8 +30,91 @@ func main() {
 	logger.Info("🚀 Iniciando RPA Service - Caixa Automation")
 
 
-	// Cria o handler
-	handler := handlers.NewHandler(false)
+	// Backend da fila: "redis" (padrão) ou "memory" (dev/testes, sem Redis)
+	queueConfig := queue.DefaultQueueConfig()
+	queueConfig.ResultTTL = getEnvDuration("RESULT_CACHE_TTL", queueConfig.ResultTTL)
+	if limit, err := strconv.Atoi(getEnv("LOGIN_FAILURE_LIMIT", "")); err == nil {
+		queueConfig.LoginFailureLimit = limit
+	}
+
+	q, err := newQueue(getEnv("QUEUE_BACKEND", "redis"), queueConfig)
+	if err != nil {
+		logger.Error(fmt.Sprintf("❌ Fila: %v", err))
+		os.Exit(1)
+	}
+
+	// Webhooks (callback_url): exigem o segredo de assinatura
+	webhooks := newWebhooks(getEnv("QUEUE_BACKEND", "redis"))
+
+	// Histórico durável das consultas (SQLite)
+	historyPath := getEnv(history.DBPathEnv, history.DefaultDBPath)
+	historyRepo, err := history.NewSQLiteRepository(historyPath)
+	if err != nil {
+		logger.Error(fmt.Sprintf("❌ Histórico: %v", err))
+		os.Exit(1)
+	}
+	logger.Info(fmt.Sprintf("🗄️ Histórico em %s", historyPath))
+
+	// Evidências das falhas (screenshot, HTML, erros do console) por job
+	artifactsDir := getEnv(artifacts.DirEnv, artifacts.DefaultDir)
+	artifactStore, err := artifacts.NewStore(artifactsDir)
+	if err != nil {
+		logger.Error(fmt.Sprintf("❌ Evidências: %v", err))
+		os.Exit(1)
+	}
+	logger.Info(fmt.Sprintf("🧾 Evidências de falha em %s", artifactsDir))
+
+	// Modo dos Chromes das execuções automáticas: worker embutido, consultas síncronas e pool
+	headless := getEnv("WORKER_HEADLESS", "true") == "true"
+
+	// Pool de Chromes abertos, compartilhado pelas consultas síncronas e pelo worker embutido
+	pool := newBrowserPool(headless)
+
+	// Worker embutido: obrigatório com a fila em memória (não há worker externo)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Entregas dos callbacks saem do log (inclusive as pendentes de antes de reiniciar)
+	if webhooks != nil {
+		go webhooks.Run(ctx)
+	}
+	if _, inMemory := q.(*queue.MemoryQueue); inMemory || getEnv("EMBEDDED_WORKER", "false") == "true" {
+		workerID := getEnv("WORKER_ID", "embedded-worker")
+		embedded := worker.New(workerID, q, headless)
+		embedded.SetWebhooks(webhooks)
+		embedded.SetHistory(historyRepo)
+		embedded.SetBrowserPool(pool)
+		embedded.SetArtifacts(artifactStore)
+		go embedded.Run(ctx)
+		logger.Info(fmt.Sprintf("👷 Worker embutido %s iniciado", workerID))
+	}
+
+	// Sessões persistentes: um Chrome logado por sessão, encerrado quando fica ocioso
+	sessionConfig := automation.DefaultSessionConfig()
+	sessionConfig.IdleTimeout = getEnvDuration("SESSION_IDLE_TIMEOUT", sessionConfig.IdleTimeout)
+	if maxSessions, err := strconv.Atoi(getEnv("SESSION_MAX", "")); err == nil {
+		sessionConfig.MaxSessions = maxSessions
+	}
+	sessionHeadless := getEnv("SESSION_HEADLESS", "true") == "true"
+	sessions := automation.NewSessionManagerWithConfig(sessionHeadless, sessionConfig)
+	if pool != nil && sessionHeadless == headless {
+		sessions.SetBrowserPool(pool)
+	} else if pool != nil {
+		logger.Info("ℹ️ SESSION_HEADLESS difere de WORKER_HEADLESS: sessões abrem Chrome próprio (fora do pool)")
+	}
+	go sessions.Run(ctx)
+
+	// Cria os handlers
+	handler := handlers.NewHandler(headless, q)
+	handler.SetHistory(historyRepo)
+	handler.SetSessions(sessions)
+	handler.SetLoginGuard(q)
+	handler.SetBrowserPool(pool)
+	jobHandler := handlers.NewJobHandler(q, webhooks)
+	jobHandler.SetHistory(historyRepo)
+	jobHandler.SetArtifacts(artifactStore)
+	historyHandler := handlers.NewHistoryHandler(historyRepo)
+	loginBlockHandler := handlers.NewLoginBlockHandler(q)
 
 	// Configura as rotas
 	router := mux.NewRouter()
@@ -35,11 +125,45 @@ func main() {
 	// Rota principal - Login + Busca
 	router.HandleFunc("/api/login-and-search", handler.LoginAndSearch).Methods("POST")
 
+	// Sessões - login uma vez, várias buscas no mesmo navegador
+	router.HandleFunc("/api/login", handler.Login).Methods("POST")
+	router.HandleFunc("/api/search", handler.Search).Methods("POST")
+	router.HandleFunc("/api/logout", handler.Logout).Methods("POST")
+
+	// API assíncrona - fila de jobs
+	router.HandleFunc("/api/jobs", jobHandler.AddJob).Methods("POST")
+	router.HandleFunc("/api/jobs", jobHandler.ListJobs).Methods("GET")
+	router.HandleFunc("/api/jobs/{id}", jobHandler.GetJob).Methods("GET")
+	router.HandleFunc("/api/jobs/{id}", jobHandler.CancelJob).Methods("DELETE")
+	router.HandleFunc("/api/jobs/{id}/events", jobHandler.StreamJobEvents).Methods("GET")
+	router.HandleFunc("/api/jobs/{id}/artifacts", jobHandler.GetJobArtifacts).Methods("GET")
+
+	// Webhooks - log de entregas e reenvio
+	if webhooks != nil {
+		webhookHandler := handlers.NewWebhookHandler(webhooks)
+		router.HandleFunc("/api/jobs/{id}/deliveries", webhookHandler.ListJobDeliveries).Methods("GET")
+		router.HandleFunc("/api/webhooks/deliveries/{id}", webhookHandler.GetDelivery).Methods("GET")
+		router.HandleFunc("/api/webhooks/deliveries/{id}/replay", webhookHandler.ReplayDelivery).Methods("POST")
+	}
+
+	// Histórico durável (página /historico)
+	router.HandleFunc("/api/history", historyHandler.SearchHistory).Methods("GET")
+	router.HandleFunc("/api/history/{id}", historyHandler.GetHistoryRecord).Methods("GET")
+
+	// Lotes de CPFs
+	router.HandleFunc("/api/batches", jobHandler.CreateBatch).Methods("POST")
+	router.HandleFunc("/api/batches/{id}", jobHandler.GetBatch).Methods("GET")
+
+	// Usuários bloqueados por senha errada (proteção da conta no portal)
+	router.HandleFunc("/api/login-blocks", loginBlockHandler.ListLoginBlocks).Methods("GET")
+	router.HandleFunc("/api/login-blocks/{username}", loginBlockHandler.ClearLoginBlock).Methods("DELETE")
+
 	// Configura CORS (permite requisições do backend)
 	corsHandler := cors.New(cors.Options{
 		AllowedOrigins:   []string{"*"}, // Em produção, coloque apenas o domínio do backend
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Content-Type", "Authorization"},
+		ExposedHeaders:   []string{"Location"},
 		AllowCredentials: true,
 	})
 
@@ -56,6 +180,24 @@ func main() {
 		logger.Info("📋 Endpoints disponíveis:")
 		logger.Info("   GET  /health                - Health check")
 		logger.Info("   POST /api/login-and-search  - Login + Busca CPF (COMPLETO)")
+		logger.Info("   POST /api/login             - Abre sessão (Chrome logado) e retorna session_token")
+		logger.Info("   POST /api/search            - Busca CPF na sessão aberta")
+		logger.Info("   POST /api/logout            - Encerra a sessão")
+		logger.Info("   POST /api/jobs              - Enfileira Login + Busca CPF (assíncrono)")
+		logger.Info("   GET  /api/jobs              - Lista jobs (filtros + paginação por cursor)")
+		logger.Info("   GET  /api/jobs/{id}         - Status/resultado do job")
+		logger.Info("   DELETE /api/jobs/{id}       - Cancela o job")
+		logger.Info("   GET  /api/jobs/{id}/events  - Progresso do job em tempo real (SSE)")
+		logger.Info("   GET  /api/jobs/{id}/artifacts - Evidências da falha do job (.zip)")
+		logger.Info("   GET  /api/jobs/{id}/deliveries            - Entregas de callback do job")
+		logger.Info("   GET  /api/webhooks/deliveries/{id}        - Detalhes da entrega")
+		logger.Info("   POST /api/webhooks/deliveries/{id}/replay - Reenvia a entrega")
+		logger.Info("   POST /api/batches           - Enfileira lote de CPFs (JSON ou CSV)")
+		logger.Info("   GET  /api/batches/{id}      - Progresso/resultados do lote")
+		logger.Info("   GET  /api/history           - Histórico de consultas (cpf, contract, status, from, to)")
+		logger.Info("   GET  /api/history/{id}      - Consulta do histórico com os dados extraídos")
+		logger.Info("   GET  /api/login-blocks      - Usuários bloqueados por senha errada")
+		logger.Info("   DELETE /api/login-blocks/{username} - Libera o usuário bloqueado")
 
 		if err := http.ListenAndServe(addr, httpHandler); err != nil {
 			logger.Error(fmt.Sprintf("Erro ao iniciar servidor: %v", err))
@@ -69,6 +211,13 @@ func main() {
 	<-quit
 
 	logger.Info("🛑 Encerrando servidor...")
+	cancel()
+	sessions.Shutdown()
+	if pool != nil {
+		pool.Close()
+	}
+	q.Close()
+	historyRepo.Close()
 	logger.Info("✅ Servidor encerrado com sucesso")
 }
 
@@ -81,38 +230,77 @@ func getEnv(key, defaultValue string) string {
 	return value
 }
 
-func HandleAddJobToQueue(w http.ResponseWriter, r *http.Request) {
-	var req models.LoginAndSearchRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
+// getEnvDuration - lê duração (ex: "30m") de variável de ambiente
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return defaultValue
 	}
+	return value
+}
 
-	q := queue.NewRedisQueue("localhost:6379")
-	
-	jobID, err := q.AddJob(req.Username, req.Password, req.CPF)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+// newQueue - cria o backend de fila configurado
+func newQueue(backend string, config queue.QueueConfig) (queue.Queue, error) {
+	switch backend {
+	case "memory":
+		logger.Info("🧠 Fila em memória (jobs são perdidos ao reiniciar)")
+		return queue.NewMemoryQueue(config), nil
+
+	case "redis":
+		// Chaves para cifrar as senhas dos jobs (nunca ficam em texto puro no Redis)
+		credentialCipher, err := queue.NewCredentialCipherFromEnv()
+		if err != nil {
+			return nil, fmt.Errorf("credenciais: %w", err)
+		}
+
+		redisAddr := getEnv("REDIS_ADDR", "localhost:6379")
+		q := queue.NewRedisQueueWithConfig(redisAddr, credentialCipher, config)
+		if err := q.Ping(); err != nil {
+			logger.Error(fmt.Sprintf("⚠️ Redis indisponível em %s: %v", redisAddr, err))
+		} else {
+			logger.Info(fmt.Sprintf("✅ Conectado ao Redis: %s", redisAddr))
+		}
+		return q, nil
+
+	default:
+		return nil, fmt.Errorf("QUEUE_BACKEND inválido: %q (use redis ou memory)", backend)
 	}
+}
 
-	json.NewEncoder(w).Encode(map[string]string{
-		"job_id": jobID,
-		"status": "pending",
-		"message": "Job adicionado na fila",
-	})
+// newBrowserPool - pool de Chromes (nil com BROWSER_POOL_SIZE=0: um Chrome por execução)
+// headless é o mesmo modo de quem usa o pool (WORKER_HEADLESS)
+func newBrowserPool(headless bool) *browserpool.Pool {
+	poolConfig := browserpool.DefaultConfig(headless)
+	if size, err := strconv.Atoi(getEnv("BROWSER_POOL_SIZE", "")); err == nil {
+		poolConfig.Size = size
+	}
+	if maxUses, err := strconv.Atoi(getEnv("BROWSER_POOL_MAX_USES", "")); err == nil {
+		poolConfig.MaxUses = maxUses
+	}
+
+	if poolConfig.Size <= 0 {
+		logger.Info("ℹ️ Pool de navegadores desabilitado (um Chrome por execução)")
+		return nil
+	}
+	return browserpool.New(poolConfig)
 }
 
-func HandleGetJobStatus(w http.ResponseWriter, r *http.Request) {
-	jobID := r.URL.Query().Get("job_id")
-	
-	q := queue.NewRedisQueue("localhost:6379")
-	
-	job, err := q.GetJobStatus(jobID)
-	if err != nil {
-		http.Error(w, "Job não encontrado", http.StatusNotFound)
-		return
+// newWebhooks - dispatcher de callbacks (nil se WEBHOOK_SECRET não estiver definido)
+func newWebhooks(backend string) *webhook.Dispatcher {
+	secret := os.Getenv(webhook.SecretEnv)
+	if secret == "" {
+		logger.Info(fmt.Sprintf("ℹ️ Webhooks desabilitados (defina %s para aceitar callback_url)", webhook.SecretEnv))
+		return nil
+	}
+
+	var store webhook.Store = webhook.NewMemoryStore()
+	if backend == "redis" {
+		store = webhook.NewRedisStore(getEnv("REDIS_ADDR", "localhost:6379"))
 	}
 
-	json.NewEncoder(w).Encode(job)
-}
\ No newline at end of file
+	webhookConfig := webhook.DefaultConfig()
+	webhookConfig.AllowPrivate = getEnv("WEBHOOK_ALLOW_PRIVATE", "false") == "true"
+
+	logger.Info("📨 Webhooks habilitados (callbacks assinados com HMAC-SHA256)")
+	return webhook.NewDispatcherWithConfig(secret, store, webhookConfig)
+}
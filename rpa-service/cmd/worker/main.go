@@ -1,16 +1,20 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
-	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/automation"
-	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/models"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/artifacts"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/browserpool"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/history"
 	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/queue"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/webhook"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/worker"
 	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/pkg/logger"
 )
 
@@ -32,66 +36,112 @@ func main() {
 		redisAddr = "localhost:6379"
 	}
 
-	q := queue.NewRedisQueue(redisAddr)
+	queueConfig := queue.DefaultQueueConfig()
+	queueConfig.VisibilityTimeout = getEnvDuration("JOB_VISIBILITY_TIMEOUT", queueConfig.VisibilityTimeout)
+	queueConfig.HeartbeatInterval = getEnvDuration("JOB_HEARTBEAT_INTERVAL", queueConfig.HeartbeatInterval)
+	queueConfig.ReapInterval = getEnvDuration("JOB_REAP_INTERVAL", queueConfig.ReapInterval)
+	queueConfig.PromoteInterval = getEnvDuration("JOB_PROMOTE_INTERVAL", queueConfig.PromoteInterval)
+	queueConfig.Retry.MaxAttempts = getEnvInt("JOB_MAX_ATTEMPTS", queueConfig.Retry.MaxAttempts)
+	queueConfig.Retry.BaseDelay = getEnvDuration("JOB_RETRY_BASE_DELAY", queueConfig.Retry.BaseDelay)
+	queueConfig.Retry.MaxDelay = getEnvDuration("JOB_RETRY_MAX_DELAY", queueConfig.Retry.MaxDelay)
+	queueConfig.ResultTTL = getEnvDuration("RESULT_CACHE_TTL", queueConfig.ResultTTL)
+	queueConfig.LoginFailureLimit = getEnvInt("LOGIN_FAILURE_LIMIT", queueConfig.LoginFailureLimit)
+
+	// Chaves para decifrar as senhas dos jobs
+	credentialCipher, err := queue.NewCredentialCipherFromEnv()
+	if err != nil {
+		logger.Error(fmt.Sprintf("[%s] ❌ Credenciais: %v", workerID, err))
+		os.Exit(1)
+	}
+
+	q := queue.NewRedisQueueWithConfig(redisAddr, credentialCipher, queueConfig)
 	logger.Info(fmt.Sprintf("✅ Conectado ao Redis: %s", redisAddr))
 
+	// Recifra com a chave ativa jobs pendentes cifrados com chaves antigas
+	if rotated, err := q.RotateCredentials(); err != nil {
+		logger.Error(fmt.Sprintf("[%s] ⚠️ Erro ao rotacionar credenciais: %v", workerID, err))
+	} else if rotated > 0 {
+		logger.Info(fmt.Sprintf("[%s] 🔑 %d job(s) recifrados com a chave ativa", workerID, rotated))
+	}
+
 	// Graceful shutdown
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
 
-	// Loop principal do worker
-	go func() {
-		for {
-			logger.Info(fmt.Sprintf("[%s] 🔍 Buscando próximo job...", workerID))
-
-			// Pega próximo job da fila
-			job, err := q.GetNextJob()
-			if err != nil {
-				logger.Error(fmt.Sprintf("[%s] ❌ Erro ao buscar job: %v", workerID, err))
-				time.Sleep(5 * time.Second)
-				continue
-			}
-
-			if job == nil {
-				// Fila vazia, aguarda
-				time.Sleep(2 * time.Second)
-				continue
-			}
-
-			logger.Info(fmt.Sprintf("[%s] 📋 Processando job %s (CPF: %s)", workerID, job.ID, job.CPF))
-
-			// Atualiza status para processing
-			job.Status = "processing"
-			q.UpdateJob(job)
-
-			// Executa automação
-			bot := automation.NewCaixaBot(true)
-			
-			req := models.LoginAndSearchRequest{
-				Username: job.Username,
-				Password: job.Password,
-				CPF:      job.CPF,
-			}
-
-			response, err := bot.LoginAndSearch(req.Username, req.Password, req.CPF)
-			
-			if err != nil {
-				logger.Error(fmt.Sprintf("[%s] ❌ Erro no job %s: %v", workerID, job.ID, err))
-				q.FailJob(job.ID, err.Error())
-				continue
-			}
-
-			// Serializa resultado
-			resultJSON, _ := json.Marshal(response.Data)
-
-			// Marca como completo
-			q.CompleteJob(job.ID, string(resultJSON))
-
-			logger.Info(fmt.Sprintf("[%s] ✅ Job %s completado!", workerID, job.ID))
-		}
-	}()
+	ctx, cancel := context.WithCancel(context.Background())
+	w := worker.New(workerID, q, true)
+
+	// Webhooks (callback_url dos jobs): exigem o segredo de assinatura
+	if secret := os.Getenv(webhook.SecretEnv); secret != "" {
+		webhookConfig := webhook.DefaultConfig()
+		webhookConfig.AllowPrivate = os.Getenv("WEBHOOK_ALLOW_PRIVATE") == "true"
+		dispatcher := webhook.NewDispatcherWithConfig(secret, webhook.NewRedisStore(redisAddr), webhookConfig)
+		go dispatcher.Run(ctx)
+		w.SetWebhooks(dispatcher)
+		logger.Info(fmt.Sprintf("[%s] 📨 Webhooks habilitados", workerID))
+	} else {
+		logger.Info(fmt.Sprintf("[%s] ℹ️ Webhooks desabilitados (%s não definido)", workerID, webhook.SecretEnv))
+	}
+
+	// Histórico durável: mesmo arquivo SQLite do servidor (volume compartilhado)
+	historyPath := os.Getenv(history.DBPathEnv)
+	if historyPath == "" {
+		historyPath = history.DefaultDBPath
+	}
+	historyRepo, err := history.NewSQLiteRepository(historyPath)
+	if err != nil {
+		logger.Error(fmt.Sprintf("[%s] ❌ Histórico: %v", workerID, err))
+		os.Exit(1)
+	}
+	defer historyRepo.Close()
+	w.SetHistory(historyRepo)
+
+	// Evidências das falhas: mesma pasta do servidor (volume compartilhado), que as serve na API
+	artifactsDir := os.Getenv(artifacts.DirEnv)
+	if artifactsDir == "" {
+		artifactsDir = artifacts.DefaultDir
+	}
+	artifactStore, err := artifacts.NewStore(artifactsDir)
+	if err != nil {
+		logger.Error(fmt.Sprintf("[%s] ❌ Evidências: %v", workerID, err))
+		os.Exit(1)
+	}
+	w.SetArtifacts(artifactStore)
+
+	// Pool de Chromes abertos (BROWSER_POOL_SIZE=0 volta a abrir um Chrome por job)
+	poolConfig := browserpool.DefaultConfig(true)
+	poolConfig.Size = getEnvInt("BROWSER_POOL_SIZE", poolConfig.Size)
+	poolConfig.MaxUses = getEnvInt("BROWSER_POOL_MAX_USES", poolConfig.MaxUses)
+	if poolConfig.Size > 0 {
+		pool := browserpool.New(poolConfig)
+		defer pool.Close()
+		w.SetBrowserPool(pool)
+	} else {
+		logger.Info(fmt.Sprintf("[%s] ℹ️ Pool de navegadores desabilitado", workerID))
+	}
+
+	go w.Run(ctx)
 
 	// Aguarda sinal de stop
 	<-stop
+	cancel()
 	logger.Info(fmt.Sprintf("[%s] 🛑 Worker parando...", workerID))
-}
\ No newline at end of file
+}
+
+// getEnvDuration - lê duração (ex: "2m") de variável de ambiente
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvInt - lê inteiro de variável de ambiente
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
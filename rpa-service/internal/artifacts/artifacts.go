@@ -0,0 +1,243 @@
+package artifacts
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+const (
+	DirEnv     = "ARTIFACTS_DIR" // Pasta dos pacotes de evidências
+	DefaultDir = "data/artifacts"
+	Retention  = 24 * time.Hour // Mesmo TTL dos jobs: pacotes mais velhos são apagados
+)
+
+// Arquivos de um pacote
+const (
+	infoFile       = "info.json"
+	screenshotFile = "screenshot.png"
+	pageFile       = "page.html"
+	iframeFile     = "iframe.html"
+)
+
+// ErrNotFound - o job não tem evidências de falha
+var ErrNotFound = errors.New("nenhuma evidência de falha para o job")
+
+// jobIDPattern - IDs aceitos como nome de pasta (nada de "../")
+var jobIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Bundle - evidências do navegador no momento em que a execução falhou
+type Bundle struct {
+	JobID         string         `json:"job_id"`
+	Attempt       int            `json:"attempt,omitempty"`
+	Stage         string         `json:"stage,omitempty"`
+	Error         string         `json:"error"`
+	ErrorCode     string         `json:"error_code,omitempty"`
+	URL           string         `json:"url"`
+	CapturedAt    time.Time      `json:"captured_at"`
+	ConsoleErrors []ConsoleEntry `json:"console_errors"`
+	NetworkErrors []NetworkError `json:"network_errors"`
+	CaptureErrors []string       `json:"capture_errors,omitempty"` // Partes que não puderam ser capturadas
+
+	Screenshot []byte `json:"-"` // PNG da página inteira
+	PageHTML   string `json:"-"` // outerHTML do documento de cima
+	IframeHTML string `json:"-"` // outerHTML do iframe blank.jsp
+}
+
+// ConsoleEntry - erro do console do navegador (console.error, exceção ou log do Chrome)
+type ConsoleEntry struct {
+	Time    time.Time `json:"time"`
+	Source  string    `json:"source"`
+	Message string    `json:"message"`
+	URL     string    `json:"url,omitempty"`
+}
+
+// NetworkError - requisição que falhou ou respondeu com erro HTTP
+type NetworkError struct {
+	Time   time.Time `json:"time"`
+	URL    string    `json:"url"`
+	Status int       `json:"status,omitempty"` // 0 = falhou sem resposta
+	Error  string    `json:"error,omitempty"`
+}
+
+// Store - pacotes de evidências em disco, uma pasta por job
+// A tentativa mais recente que falhou substitui a anterior; o job concluído apaga o pacote
+type Store struct {
+	dir       string
+	retention time.Duration
+}
+
+// NewStore - cria a pasta (se preciso) e o store, já sem os pacotes vencidos
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("erro ao criar pasta de evidências: %w", err)
+	}
+
+	// Pacotes que venceram com o serviço parado
+	store := &Store{dir: dir, retention: Retention}
+	if _, err := store.Prune(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Save - grava o pacote do job (e apaga os pacotes vencidos)
+func (s *Store) Save(bundle *Bundle) error {
+	dir, err := s.jobDir(bundle.JobID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.Prune(); err != nil {
+		return err
+	}
+
+	// Grava numa pasta temporária e troca de uma vez (download nunca vê pacote pela metade)
+	tmp, err := os.MkdirTemp(s.dir, ".tmp-"+bundle.JobID+"-")
+	if err != nil {
+		return fmt.Errorf("erro ao criar pasta temporária: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	info, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return fmt.Errorf("erro ao serializar evidências: %w", err)
+	}
+
+	files := map[string][]byte{infoFile: info}
+	if len(bundle.Screenshot) > 0 {
+		files[screenshotFile] = bundle.Screenshot
+	}
+	if bundle.PageHTML != "" {
+		files[pageFile] = []byte(bundle.PageHTML)
+	}
+	if bundle.IframeHTML != "" {
+		files[iframeFile] = []byte(bundle.IframeHTML)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmp, name), content, 0o644); err != nil {
+			return fmt.Errorf("erro ao gravar %s: %w", name, err)
+		}
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("erro ao remover evidências anteriores: %w", err)
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		return fmt.Errorf("erro ao gravar evidências: %w", err)
+	}
+	return nil
+}
+
+// WriteZip - escreve o pacote do job como .zip
+func (s *Store) WriteZip(jobID string, w io.Writer) error {
+	dir, err := s.jobDir(jobID)
+	if err != nil {
+		return err
+	}
+
+	if s.expired(dir) {
+		return ErrNotFound
+	}
+
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao ler evidências: %w", err)
+	}
+
+	archive := zip.NewWriter(w)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if err := addFile(archive, filepath.Join(dir, entry.Name()), entry.Name()); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// Exists - o job tem pacote de evidências
+func (s *Store) Exists(jobID string) bool {
+	dir, err := s.jobDir(jobID)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(filepath.Join(dir, infoFile))
+	return err == nil && !s.expired(dir)
+}
+
+// Delete - apaga o pacote do job (ex: uma tentativa seguinte deu certo)
+func (s *Store) Delete(jobID string) error {
+	dir, err := s.jobDir(jobID)
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("erro ao apagar evidências: %w", err)
+	}
+	return nil
+}
+
+// Prune - apaga os pacotes (e pastas temporárias esquecidas) mais velhos que a retenção
+func (s *Store) Prune() (int, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao ler pasta de evidências: %w", err)
+	}
+
+	removed := 0
+	for _, entry := range entries {
+		dir := filepath.Join(s.dir, entry.Name())
+		if !entry.IsDir() || !s.expired(dir) {
+			continue
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			return removed, fmt.Errorf("erro ao apagar evidências vencidas: %w", err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// expired - a pasta passou da retenção (a troca no Save renova a data da pasta)
+func (s *Store) expired(dir string) bool {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return false
+	}
+	return time.Since(info.ModTime()) > s.retention
+}
+
+// jobDir - pasta do job (recusa IDs que não sejam um nome de pasta simples)
+func (s *Store) jobDir(jobID string) (string, error) {
+	if !jobIDPattern.MatchString(jobID) {
+		return "", ErrNotFound
+	}
+	return filepath.Join(s.dir, jobID), nil
+}
+
+// addFile - copia um arquivo do pacote para o zip
+func addFile(archive *zip.Writer, path, name string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("erro ao abrir %s: %w", name, err)
+	}
+	defer file.Close()
+
+	entry, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(entry, file)
+	return err
+}
@@ -2,10 +2,17 @@ package automation
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/chromedp/chromedp"
 	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/automation/config"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/automation/navigation"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/automation/waits"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/browserpool"
 	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/models"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/pkg/documents"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/pkg/logger"
 )
 
 // CaixaBot - Robô de automação da Caixa
@@ -13,6 +20,9 @@ type CaixaBot struct {
 	browserConfig config.BrowserConfig
 	timeouts      config.Timeouts
 	maxRetries    config.MaxRetries
+	progress      ProgressReporter
+	failure       FailureReporter
+	pool          *browserpool.Pool // Opcional: Chromes já abertos em vez de um por execução
 }
 
 // NewCaixaBot - cria uma nova instância do bot
@@ -34,19 +44,29 @@ func NewCaixaBotWithConfig(browserConfig config.BrowserConfig, timeouts config.T
 }
 
 //LoginAndSearch - executa login e busca (método principal)
-func (bot *CaixaBot) LoginAndSearch(username, password, cpf string) (*models.SearchResponse, error) {
-	// Cria contexto base
-	ctx := context.Background()
-	
-	// IMPORTANTE: Cria contexto do Chrome
-	browserCtx, cancel := bot.createBrowserContext(ctx)
+// Cancelar o ctx encerra o Chrome e interrompe a automação; a execução toda
+// é limitada a Timeouts.RunBudget (com ProposalAll, cada proposta tem o seu prazo)
+func (bot *CaixaBot) LoginAndSearch(ctx context.Context, username, password string, query SearchQuery, selection models.ProposalSelection) (*models.SearchResponse, error) {
+	// IMPORTANTE: Cria contexto do Chrome (do pool, se houver)
+	browserCtx, cancel, err := bot.openBrowser(ctx)
+	if err != nil {
+		return &models.SearchResponse{Success: false, Message: err.Error()}, err
+	}
 	defer cancel()
 	
+	// Execução inteira limitada ao tempo total (cada etapa tem o seu prazo dentro dele)
+	runCtx, stop := bot.withSearchDeadline(browserCtx, selection)
+	defer stop()
+	
+	// Mede quanto tempo a execução passou esperando a página
+	runCtx, stats := waits.WithStats(runCtx)
+	defer func() { logger.Info(stats.Summary()) }()
+	
 	// Cria orquestrador
 	orchestrator := NewOrchestrator(bot)
 	
 	// Executa fluxo completo com o contexto do Chrome
-	clientData, err := orchestrator.Execute(browserCtx, username, password, cpf)
+	results, err := orchestrator.Execute(runCtx, username, password, query, selection)
 	
 	if err != nil {
 		return &models.SearchResponse{
@@ -55,11 +75,70 @@ func (bot *CaixaBot) LoginAndSearch(username, password, cpf string) (*models.Sea
 		}, err
 	}
 	
-	return &models.SearchResponse{
+	return searchResponse(results, selection), nil
+}
+
+// searchResponse - resposta de sucesso com os dados extraídos
+// Data é sempre a primeira proposta; com ProposalAll, Results traz todas
+func searchResponse(results []*models.ClientData, selection models.ProposalSelection) *models.SearchResponse {
+	response := &models.SearchResponse{
 		Success: true,
 		Message: "Dados extraídos com sucesso",
-		Data:    clientData,
-	}, nil
+	}
+	if len(results) > 0 {
+		response.Data = results[0]
+	}
+	if selection == models.ProposalAll {
+		response.Results = results
+		response.Message = fmt.Sprintf("Dados extraídos com sucesso (%d proposta(s))", len(results))
+	}
+	return response
+}
+
+// SearchQuery - o que buscar no portal (tipo + valor normalizado)
+type SearchQuery = navigation.SearchQuery
+
+// CPFQuery - busca por CPF (valor como veio; o portal aceita com ou sem formatação)
+func CPFQuery(cpf string) SearchQuery {
+	return SearchQuery{Type: models.SearchTypeCPF, Value: cpf}
+}
+
+// ParseSearchQuery - valida o search_type e normaliza o valor (vazio = cpf)
+func ParseSearchQuery(searchType, value string) (SearchQuery, error) {
+	query := SearchQuery{Type: models.SearchType(strings.ToLower(strings.TrimSpace(searchType)))}
+	if query.Type == "" {
+		query.Type = models.SearchTypeCPF
+	}
+	
+	var err error
+	switch query.Type {
+	case models.SearchTypeCPF:
+		query.Value, err = documents.NormalizeCPF(value)
+	case models.SearchTypeCNPJ:
+		query.Value, err = documents.NormalizeCNPJ(value)
+	case models.SearchTypeProposal:
+		query.Value, err = documents.NormalizeProposalNumber(value)
+	case models.SearchTypeContract:
+		query.Value, err = documents.NormalizeContractNumber(value)
+	default:
+		return SearchQuery{}, fmt.Errorf("search_type inválido: %q (use cpf, cnpj, proposal ou contract)", searchType)
+	}
+	if err != nil {
+		return SearchQuery{}, err
+	}
+	return query, nil
+}
+
+// ParseProposalSelection - valida o proposal_selection da requisição (vazio = first)
+func ParseProposalSelection(value string) (models.ProposalSelection, error) {
+	switch selection := models.ProposalSelection(strings.ToLower(strings.TrimSpace(value))); selection {
+	case "":
+		return models.ProposalFirst, nil
+	case models.ProposalFirst, models.ProposalMostRecentActive, models.ProposalAll:
+		return selection, nil
+	default:
+		return "", fmt.Errorf("proposal_selection inválido: %q (use first, most_recent_active ou all)", value)
+	}
 }
 // createBrowserContext - cria contexto do navegador
 func (bot *CaixaBot) createBrowserContext(ctx context.Context) (context.Context, context.CancelFunc) {
@@ -75,6 +154,52 @@ func (bot *CaixaBot) createBrowserContext(ctx context.Context) (context.Context,
 	return browserCtx, cancelFunc
 }
 
+// openBrowser - contexto do Chrome pronto para uso
+// Com pool: empresta um Chrome já aberto (contexto incognito próprio), devolvido no cancel.
+// Sem pool: abre um Chrome só para esta execução. Cancelar o ctx encerra a execução.
+func (bot *CaixaBot) openBrowser(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	if bot.pool == nil {
+		browserCtx, cancel := bot.createBrowserContext(ctx)
+		if err := startBrowser(browserCtx); err != nil {
+			cancel()
+			return nil, nil, err
+		}
+		return browserCtx, cancel, nil
+	}
+	
+	lease, err := bot.pool.Acquire(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("erro ao obter navegador do pool: %w", err)
+	}
+	if err := startBrowser(lease.Ctx); err != nil {
+		lease.Release()
+		return nil, nil, err
+	}
+	
+	// O Chrome do pool não morre com o ctx: fecha a aba quando ele acabar
+	stop := context.AfterFunc(ctx, lease.Release)
+	cancel := func() {
+		stop()
+		lease.Release()
+	}
+	return lease.Ctx, cancel, nil
+}
+
+// SetBrowserPool - passa a usar os Chromes do pool (nil = um Chrome por execução)
+func (bot *CaixaBot) SetBrowserPool(pool *browserpool.Pool) {
+	bot.pool = pool
+}
+
+// SetProgressReporter - define quem recebe as transições de etapa
+func (bot *CaixaBot) SetProgressReporter(reporter ProgressReporter) {
+	bot.progress = reporter
+}
+
+// SetFailureReporter - define quem recebe as evidências (screenshot, HTML, erros do console) quando a execução falha
+func (bot *CaixaBot) SetFailureReporter(reporter FailureReporter) {
+	bot.failure = reporter
+}
+
 // GetTimeouts - retorna configurações de timeout
 func (bot *CaixaBot) GetTimeouts() config.Timeouts {
 	return bot.timeouts
@@ -12,12 +12,19 @@ type Timeouts struct {
 	ScrollWait       time.Duration
 	AfterClick       time.Duration
 	BetweenRetries   time.Duration
+
+	// Tempo máximo de cada etapa do orquestrador (dentro do BrowserContext)
+	LoginStage        time.Duration
+	SearchStage       time.Duration
+	FinancialStage    time.Duration
+	ParticipantsStage time.Duration
+	PropertyStage     time.Duration
 }
 
 // DefaultTimeouts - timeouts padrão do sistema
 func DefaultTimeouts() Timeouts {
 	return Timeouts{
-		BrowserContext:  5 * time.Minute,  // Tempo total máximo
+		BrowserContext:  7 * time.Minute,  // Tempo total máximo (cabe o login + as etapas de uma proposta)
 		PageLoad:        60 * time.Second, // Tempo para página carregar
 		IframeWait:      30 * time.Second, // Tempo para iframe aparecer
 		ElementWait:     10 * time.Second, // Tempo para elemento aparecer
@@ -25,7 +32,31 @@ func DefaultTimeouts() Timeouts {
 		ScrollWait:      2 * time.Second,  // Tempo após scroll
 		AfterClick:      3 * time.Second,  // Tempo após clique
 		BetweenRetries:  5 * time.Second,  // Tempo entre tentativas
+
+		LoginStage:        90 * time.Second,
+		SearchStage:       60 * time.Second, // Busca + abrir a proposta
+		FinancialStage:    60 * time.Second,
+		ParticipantsStage: 2 * time.Minute, // Um detalhamento por participante
+		PropertyStage:     60 * time.Second,
+	}
+}
+
+// ProposalBudget - soma dos prazos das etapas de uma proposta (busca, financeiro, participantes e imóvel)
+func (t Timeouts) ProposalBudget() time.Duration {
+	return t.SearchStage + t.FinancialStage + t.ParticipantsStage + t.PropertyStage
+}
+
+// RunBudget - tempo total de uma execução com uma proposta
+// Nunca menor que o login + as etapas da proposta: senão o tempo total venceria antes
+// do prazo de qualquer etapa e o erro apontaria o total em vez da etapa
+func (t Timeouts) RunBudget() time.Duration {
+	if t.BrowserContext <= 0 {
+		return 0
+	}
+	if minimum := t.LoginStage + t.ProposalBudget(); t.BrowserContext < minimum {
+		return minimum
 	}
+	return t.BrowserContext
 }
 
 // MaxRetries - configurações de tentativas
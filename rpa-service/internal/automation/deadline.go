@@ -0,0 +1,129 @@
+package automation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/chromedp/chromedp"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/models"
+)
+
+// StageTimeoutError - uma etapa passou do seu tempo máximo (ou o tempo total acabou durante ela)
+type StageTimeoutError struct {
+	Stage   Stage
+	Timeout time.Duration
+	Overall bool  // O que expirou foi o tempo total (ou o da proposta, com ProposalAll), não o da etapa
+	Err     error // Erro devolvido pela etapa ao ser interrompida
+}
+
+func (e *StageTimeoutError) Error() string {
+	if e.Overall {
+		return fmt.Sprintf("tempo total de %s esgotado durante a etapa %s", e.Timeout, e.Stage)
+	}
+	return fmt.Sprintf("etapa %s excedeu o tempo limite de %s", e.Stage, e.Timeout)
+}
+
+// Unwrap - errors.Is(err, context.DeadlineExceeded) continua valendo
+func (e *StageTimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// ErrorCode - código estável para a API
+func (e *StageTimeoutError) ErrorCode() string {
+	return "stage_timeout"
+}
+
+// IsStageTimeout - a automação foi interrompida por tempo
+func IsStageTimeout(err error) bool {
+	var timeoutErr *StageTimeoutError
+	return errors.As(err, &timeoutErr)
+}
+
+// stageTimeout - tempo máximo configurado para a etapa (0 = só o tempo total)
+func (o *Orchestrator) stageTimeout(stage Stage) time.Duration {
+	timeouts := o.bot.GetTimeouts()
+	switch stage {
+	case StageLogin:
+		return timeouts.LoginStage
+	case StageSearch:
+		return timeouts.SearchStage
+	case StageFinancial:
+		return timeouts.FinancialStage
+	case StageParticipants:
+		return timeouts.ParticipantsStage
+	case StageProperty:
+		return timeouts.PropertyStage
+	default:
+		return 0
+	}
+}
+
+// runStage - executa a etapa com o seu próprio prazo
+// Se a etapa falha porque o prazo (dela ou o total) venceu, o erro vira StageTimeoutError
+func (o *Orchestrator) runStage(ctx context.Context, stage Stage, run func(ctx context.Context) error) error {
+	o.stage = stage
+	timeout := o.stageTimeout(stage)
+	stageCtx, cancel := ctx, context.CancelFunc(func() {})
+	if timeout > 0 {
+		stageCtx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	defer cancel()
+
+	err := run(stageCtx)
+	if err == nil || !errors.Is(stageCtx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return &StageTimeoutError{Stage: stage, Timeout: o.budget, Overall: true, Err: err}
+	}
+	return &StageTimeoutError{Stage: stage, Timeout: timeout, Err: err}
+}
+
+// startBrowser - sobe o Chrome no contexto do navegador
+// O primeiro chromedp.Run prende o Chrome ao ctx recebido: se fosse o ctx de uma
+// etapa, o Chrome morreria quando o prazo dela fosse liberado.
+func startBrowser(browserCtx context.Context) error {
+	if err := chromedp.Run(browserCtx); err != nil {
+		return fmt.Errorf("erro ao iniciar o navegador: %w", err)
+	}
+	return nil
+}
+
+// withRunDeadline - limita uma execução completa ao tempo total (0 = sem limite)
+func (bot *CaixaBot) withRunDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	budget := bot.timeouts.RunBudget()
+	if budget <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, budget)
+}
+
+// withSearchDeadline - como withRunDeadline, mas com ProposalAll não há um total fixo:
+// o número de propostas só é conhecido depois da busca, e cada uma recebe o seu
+// próprio prazo (withProposalDeadline)
+func (bot *CaixaBot) withSearchDeadline(ctx context.Context, selection models.ProposalSelection) (context.Context, context.CancelFunc) {
+	if selection == models.ProposalAll {
+		return context.WithCancel(ctx)
+	}
+	return bot.withRunDeadline(ctx)
+}
+
+// withProposalDeadline - prazo de uma proposta dentro da execução (ProposalAll)
+// Enquanto o ctx devolvido estiver em uso, o tempo esgotado é reportado como o da proposta
+func (o *Orchestrator) withProposalDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	budget := o.bot.GetTimeouts().ProposalBudget()
+	if o.bot.GetTimeouts().BrowserContext <= 0 || budget <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	previous := o.budget
+	o.budget = budget
+	proposalCtx, cancel := context.WithTimeout(ctx, budget)
+	return proposalCtx, func() {
+		cancel()
+		o.budget = previous
+	}
+}
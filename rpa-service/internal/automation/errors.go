@@ -0,0 +1,63 @@
+package automation
+
+import (
+	"errors"
+
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/automation/navigation"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/queue"
+)
+
+// Falhas de login classificadas pelo VerifyLoginSuccess (use errors.Is)
+var (
+	ErrInvalidCredentials = navigation.ErrInvalidCredentials
+	ErrPasswordExpired    = navigation.ErrPasswordExpired
+	ErrAccountLocked      = navigation.ErrAccountLocked
+	ErrPortalUnavailable  = navigation.ErrPortalUnavailable
+	ErrUnexpectedPage     = navigation.ErrUnexpectedPage
+)
+
+// IsSessionExpired - o portal derrubou a sessão (precisa de novo login)
+func IsSessionExpired(err error) bool {
+	return errors.Is(err, navigation.ErrSessionExpired)
+}
+
+// IsCredentialError - falha que só se resolve mexendo na conta (senha errada, expirada ou bloqueada)
+// Tentar de novo não adianta e ainda pode bloquear o usuário no portal
+func IsCredentialError(err error) bool {
+	return errors.Is(err, ErrInvalidCredentials) ||
+		errors.Is(err, ErrPasswordExpired) ||
+		errors.Is(err, ErrAccountLocked)
+}
+
+// ErrorCode - código estável do erro para a API (vazio se não for uma falha classificada)
+func ErrorCode(err error) string {
+	var loginErr *navigation.LoginError
+	if errors.As(err, &loginErr) {
+		return loginErr.ErrorCode()
+	}
+	var noActiveErr *navigation.NoActiveProposalError
+	if errors.As(err, &noActiveErr) {
+		return noActiveErr.ErrorCode()
+	}
+	var timeoutErr *StageTimeoutError
+	if errors.As(err, &timeoutErr) {
+		return timeoutErr.ErrorCode()
+	}
+	return ""
+}
+
+// RecordLoginResult - alimenta o protetor de contas com o resultado de uma execução
+// Senha errada conta uma falha; conta bloqueada no portal bloqueia na hora; sucesso zera.
+// Devolve o bloqueio criado agora (nil se não bloqueou).
+func RecordLoginResult(guard queue.LoginGuard, username string, err error) (*queue.LoginBlock, error) {
+	switch {
+	case err == nil:
+		return nil, guard.RecordLoginSuccess(username)
+	case errors.Is(err, ErrInvalidCredentials):
+		return guard.RecordLoginFailure(username, err.Error())
+	case errors.Is(err, ErrAccountLocked):
+		return guard.BlockLogin(username, err.Error())
+	default:
+		return nil, nil
+	}
+}
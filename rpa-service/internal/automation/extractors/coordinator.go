@@ -6,6 +6,8 @@ import (
 
 	"github.com/chromedp/cdproto/cdp"
 	"github.com/chromedp/chromedp"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/automation/config"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/automation/waits"
 	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/models"
 	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/pkg/logger"
 )
@@ -18,17 +20,22 @@ type DataCoordinator struct {
 	bankingExtractor   *CaixaBankingExtractor
 	propertyExtractor  *CaixaPropertyExtractor
 	financialExtractor *CaixaFinancialExtractor
+	summaryExtractor   *CaixaProposalSummaryExtractor
+	waits              *waits.Waits
 }
 
 // NewDataCoordinator - cria novo coordenador de extração
-func NewDataCoordinator() *DataCoordinator {
+func NewDataCoordinator(timeouts config.Timeouts) *DataCoordinator {
+	w := waits.New(timeouts)
 	return &DataCoordinator{
 		personalExtractor:  NewPersonalExtractor(),
 		contactExtractor:   NewContactExtractor(),
 		addressExtractor:   NewAddressExtractor(),
 		bankingExtractor:   NewBankingExtractor(),
-		propertyExtractor:  NewPropertyExtractor(),
-		financialExtractor: NewFinancialExtractor(),
+		propertyExtractor:  NewPropertyExtractor(w),
+		financialExtractor: NewFinancialExtractor(w),
+		summaryExtractor:   NewProposalSummaryExtractor(),
+		waits:              w,
 	}
 }
 
@@ -38,9 +45,14 @@ func (c *DataCoordinator) ExtractAllParticipantData(ctx context.Context, iframeN
 	
 	clientData := &models.ClientData{}
 	
+	step := waits.Step{Name: "dados do participante", Replaces: 2 * time.Second}
+	if err := c.waits.ElementInFrame(ctx, step, `//label[contains(., 'CPF:')]`); err != nil {
+		return nil, err
+	}
+	waits.Dropped(ctx, waits.Step{Name: "antes dos dados bancários", Replaces: 1 * time.Second})
+	
 	err := chromedp.Run(ctx,
-		chromedp.Sleep(2*time.Second),
-		
+
 		// 1. Dados Pessoais
 		chromedp.ActionFunc(func(ctx context.Context) error {
 			return c.personalExtractor.ExtractPersonalData(ctx, iframeNode, clientData)
@@ -56,8 +68,6 @@ func (c *DataCoordinator) ExtractAllParticipantData(ctx context.Context, iframeN
 			return c.addressExtractor.ExtractAddressData(ctx, iframeNode, clientData)
 		}),
 		
-		chromedp.Sleep(1*time.Second),
-		
 		// 4. Dados Bancários
 		chromedp.ActionFunc(func(ctx context.Context) error {
 			return c.bankingExtractor.ExtractBankingData(ctx, iframeNode, clientData)
@@ -78,7 +88,47 @@ func (c *DataCoordinator) ExtractPropertyData(ctx context.Context, iframeNode *c
 	return c.propertyExtractor.ExtractPropertyData(ctx, iframeNode, clientData)
 }
 
+// ExtractProposalSummary - extrai o resumo da página "Proposta Selecionada"
+func (c *DataCoordinator) ExtractProposalSummary(ctx context.Context) (*models.ProposalSummary, error) {
+	return c.summaryExtractor.ExtractProposalSummary(ctx)
+}
+
 // ExtractFinancialData - extrai dados financeiros
 func (c *DataCoordinator) ExtractFinancialData(ctx context.Context, iframeNode *cdp.Node, clientData *models.ClientData) error {
 	return c.financialExtractor.ExtractFinancialData(ctx, iframeNode, clientData)
-}
\ No newline at end of file
+}
+
+// ExtractParticipant - extrai os dados do participante aberto em detalharParticipante
+// Roda os mesmos extratores do proponente (pessoais, contato, endereço e bancários)
+func (c *DataCoordinator) ExtractParticipant(ctx context.Context, iframeNode *cdp.Node, role string) (*models.Participant, *models.ClientData, error) {
+	clientData, err := c.ExtractAllParticipantData(ctx, iframeNode)
+	if err != nil {
+		return nil, nil, err
+	}
+	return NewParticipant(role, clientData), clientData, nil
+}
+
+// NewParticipant - copia os dados de participante extraídos para um Participant
+func NewParticipant(role string, data *models.ClientData) *models.Participant {
+	return &models.Participant{
+		Papel:               role,
+		CPF:                 data.CPF,
+		Nome:                data.Nome,
+		Ocupacao:            data.Ocupacao,
+		Nacionalidade:       data.Nacionalidade,
+		TipoIdentificacao:   data.TipoIdentificacao,
+		RG:                  data.RG,
+		TelefoneCelular:     data.TelefoneCelular,
+		CEP:                 data.CEP,
+		TipoLogradouro:      data.TipoLogradouro,
+		Logradouro:          data.Logradouro,
+		Numero:              data.Numero,
+		Bairro:              data.Bairro,
+		Municipio:           data.Municipio,
+		UF:                  data.UF,
+		Complemento:         data.Complemento,
+		ContaDebitoCompleta: data.ContaDebitoCompleta,
+		Agencia:             data.Agencia,
+		ContaCorrente:       data.ContaCorrente,
+	}
+}
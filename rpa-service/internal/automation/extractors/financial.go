@@ -8,27 +8,31 @@ import (
 
 	"github.com/chromedp/cdproto/cdp"
 	"github.com/chromedp/chromedp"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/automation/waits"
 	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/models"
 	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/pkg/logger"
 )
 
 // CaixaFinancialExtractor - implementação para extração de valores
-type CaixaFinancialExtractor struct{}
+type CaixaFinancialExtractor struct {
+	waits *waits.Waits
+}
 
 // NewFinancialExtractor - cria novo extrator financeiro
-func NewFinancialExtractor() *CaixaFinancialExtractor {
-	return &CaixaFinancialExtractor{}
+func NewFinancialExtractor(w *waits.Waits) *CaixaFinancialExtractor {
+	return &CaixaFinancialExtractor{waits: w}
 }
 
 // ExtractFinancialData - extrai dados financeiros (valor compra e venda)
 func (e *CaixaFinancialExtractor) ExtractFinancialData(ctx context.Context, iframeNode *cdp.Node, clientData *models.ClientData) error {
 	logger.Info("💰 Extraindo Valor de Compra e Venda...")
 	
-	time.Sleep(2 * time.Second)
+	step := waits.Step{Name: "valores da operação", Replaces: 4 * time.Second}
+	if err := e.waits.ElementInFrame(ctx, step, `//label[contains(., 'Valor Compra e Venda')]`); err != nil {
+		return err
+	}
 	
 	return chromedp.Run(ctx,
-		chromedp.Sleep(2*time.Second),
-		
 		chromedp.ActionFunc(func(ctx context.Context) error {
 			return e.extractValorCompraVenda(ctx, iframeNode, clientData)
 		}),
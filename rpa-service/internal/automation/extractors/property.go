@@ -9,27 +9,31 @@ import (
 
 	"github.com/chromedp/cdproto/cdp"
 	"github.com/chromedp/chromedp"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/automation/waits"
 	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/models"
 	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/pkg/logger"
 )
 
 // CaixaPropertyExtractor - implementação para extração de imóvel
-type CaixaPropertyExtractor struct{}
+type CaixaPropertyExtractor struct {
+	waits *waits.Waits
+}
 
 // NewPropertyExtractor - cria novo extrator de imóvel
-func NewPropertyExtractor() *CaixaPropertyExtractor {
-	return &CaixaPropertyExtractor{}
+func NewPropertyExtractor(w *waits.Waits) *CaixaPropertyExtractor {
+	return &CaixaPropertyExtractor{waits: w}
 }
 
 // ExtractPropertyData - extrai dados do imóvel
 func (e *CaixaPropertyExtractor) ExtractPropertyData(ctx context.Context, iframeNode *cdp.Node, clientData *models.ClientData) error {
 	logger.Info("🏠 Extraindo dados do Imóvel...")
 	
-	time.Sleep(3 * time.Second)
+	step := waits.Step{Name: "dados do imóvel", Replaces: 5 * time.Second}
+	if err := e.waits.ElementInFrame(ctx, step, `//label[contains(., 'Endereço da Unidade Habitacional')]`); err != nil {
+		return err
+	}
 	
 	return chromedp.Run(ctx,
-		chromedp.Sleep(2*time.Second),
-		
 		chromedp.ActionFunc(func(ctx context.Context) error {
 			return e.extractEnderecoImovel(ctx, iframeNode, clientData)
 		}),
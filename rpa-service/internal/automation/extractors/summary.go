@@ -0,0 +1,133 @@
+package extractors
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/chromedp/chromedp"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/models"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/pkg/logger"
+)
+
+// CaixaProposalSummaryExtractor - extração do resumo da página "Proposta Selecionada"
+type CaixaProposalSummaryExtractor struct{}
+
+// NewProposalSummaryExtractor - cria novo extrator do resumo da proposta
+func NewProposalSummaryExtractor() *CaixaProposalSummaryExtractor {
+	return &CaixaProposalSummaryExtractor{}
+}
+
+// Rótulos aceitos para cada campo do resumo (o primeiro presente na página vence)
+var (
+	agendamentoLabels    = []string{"Agendamento da Assinatura", "Data de Agendamento da Assinatura", "Agendamento"}
+	situacaoLabels       = []string{"Situação da Proposta", "Situação", "Status"}
+	faseLabels           = []string{"Fase", "Etapa", "Fase da Proposta"}
+	agenciaLabels        = []string{"Agência", "Agência da Proposta", "Agência de Relacionamento", "Agência Concessora"}
+	modalidadeLabels     = []string{"Modalidade", "Modalidade de Financiamento"}
+	produtoLabels        = []string{"Produto", "Linha de Financiamento", "Linha"}
+	correspondenteLabels = []string{"Correspondente", "Correspondente Responsável", "Correspondente Caixa Aqui"}
+)
+
+// summaryFieldsScript - lê todos os pares rótulo/valor do iframe de uma vez
+// Ler campo a campo com chromedp.Text trava quando o rótulo não existe na página
+const summaryFieldsScript = `(() => {
+	const frame = document.querySelector('iframe[src="blank.jsp"]');
+	const doc = frame ? frame.contentDocument : document;
+	const fields = {};
+	if (!doc) return fields;
+	const text = (el) => (el.innerText || el.textContent || '').replace(/\s+/g, ' ').trim();
+	for (const tr of doc.querySelectorAll('tr')) {
+		const label = tr.querySelector('label');
+		const value = tr.querySelector('td.alinha_esquerda');
+		if (!label || !value) continue;
+		const key = text(label).replace(/:\s*$/, '');
+		if (key && !(key in fields)) fields[key] = text(value);
+	}
+	return fields;
+})()`
+
+// ExtractProposalSummary - agendamento da assinatura, situação/fase, agência, modalidade/produto e correspondente
+func (e *CaixaProposalSummaryExtractor) ExtractProposalSummary(ctx context.Context) (*models.ProposalSummary, error) {
+	logger.Info("📑 Extraindo resumo da proposta...")
+
+	var fields map[string]string
+	if err := chromedp.Run(ctx, chromedp.Evaluate(summaryFieldsScript, &fields)); err != nil {
+		logger.Error(fmt.Sprintf("❌ Erro ao ler resumo da proposta: %v", err))
+		return nil, err
+	}
+
+	summary := &models.ProposalSummary{
+		AgendamentoAssinaturaTexto: summaryField(fields, agendamentoLabels, "Agendamento da Assinatura"),
+		Situacao:                   summaryField(fields, situacaoLabels, "Situação"),
+		Fase:                       summaryField(fields, faseLabels, "Fase"),
+		Agencia:                    summaryField(fields, agenciaLabels, "Agência"),
+		Modalidade:                 summaryField(fields, modalidadeLabels, "Modalidade"),
+		Produto:                    summaryField(fields, produtoLabels, "Produto"),
+		Correspondente:             summaryField(fields, correspondenteLabels, "Correspondente"),
+	}
+
+	if agendamento, ok := ParseAgendamento(summary.AgendamentoAssinaturaTexto); ok {
+		summary.AgendamentoAssinatura = &agendamento
+		logger.Info(fmt.Sprintf("✓ Agendamento interpretado: %s", agendamento.Format(time.RFC3339)))
+	} else if summary.AgendamentoAssinaturaTexto != "" {
+		logger.Info(fmt.Sprintf("⚠️ Agendamento em formato desconhecido: %s", summary.AgendamentoAssinaturaTexto))
+	}
+
+	logger.Info("✅ Resumo da proposta extraído!")
+	return summary, nil
+}
+
+// summaryField - valor do primeiro rótulo encontrado (comparação sem caixa)
+func summaryField(fields map[string]string, labels []string, fieldName string) string {
+	for _, label := range labels {
+		for key, value := range fields {
+			if strings.EqualFold(strings.TrimSpace(key), label) && value != "" {
+				logger.Info(fmt.Sprintf("✓ %s: %s", fieldName, value))
+				return value
+			}
+		}
+	}
+
+	logger.Info(fmt.Sprintf("⚠️ %s não encontrado", fieldName))
+	return ""
+}
+
+// agendamentoPattern - data com hora opcional ("15/03/2025 14:30", "15/03/2025 às 14h30")
+var agendamentoPattern = regexp.MustCompile(`(\d{2}/\d{2}/\d{4})(?:\s*(?:às|as|-)?\s*(\d{1,2})[:h](\d{2})(?::(\d{2}))?)?`)
+
+// portalLocation - horário de Brasília (fixo -03:00 se o sistema não tiver tzdata)
+var portalLocation = func() *time.Location {
+	if location, err := time.LoadLocation("America/Sao_Paulo"); err == nil {
+		return location
+	}
+	return time.FixedZone("BRT", -3*60*60)
+}()
+
+// ParseAgendamento - interpreta a data/hora do agendamento exibida pelo portal
+// Sem hora, retorna meia-noite do dia (horário de Brasília)
+func ParseAgendamento(text string) (time.Time, bool) {
+	matches := agendamentoPattern.FindStringSubmatch(text)
+	if matches == nil {
+		return time.Time{}, false
+	}
+
+	value := matches[1]
+	layout := "02/01/2006"
+	if matches[2] != "" {
+		seconds := matches[4]
+		if seconds == "" {
+			seconds = "00"
+		}
+		value += fmt.Sprintf(" %02s:%s:%s", matches[2], matches[3], seconds)
+		layout += " 15:04:05"
+	}
+
+	parsed, err := time.ParseInLocation(layout, value, portalLocation)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return parsed, true
+}
@@ -0,0 +1,53 @@
+package extractors
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseAgendamento(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		want   time.Time
+		wantOK bool
+	}{
+		{name: "só data", text: "15/03/2025", want: time.Date(2025, 3, 15, 0, 0, 0, 0, portalLocation), wantOK: true},
+		{name: "data e hora", text: "15/03/2025 14:30", want: time.Date(2025, 3, 15, 14, 30, 0, 0, portalLocation), wantOK: true},
+		{name: "com segundos", text: "15/03/2025 14:30:45", want: time.Date(2025, 3, 15, 14, 30, 45, 0, portalLocation), wantOK: true},
+		{name: "às com h", text: "15/03/2025 às 14h30", want: time.Date(2025, 3, 15, 14, 30, 0, 0, portalLocation), wantOK: true},
+		{name: "as sem acento", text: "15/03/2025 as 14:30", want: time.Date(2025, 3, 15, 14, 30, 0, 0, portalLocation), wantOK: true},
+		{name: "com hífen", text: "15/03/2025 - 14:30", want: time.Date(2025, 3, 15, 14, 30, 0, 0, portalLocation), wantOK: true},
+		{name: "hora com um dígito", text: "15/03/2025 às 9h05", want: time.Date(2025, 3, 15, 9, 5, 0, 0, portalLocation), wantOK: true},
+		{name: "texto em volta", text: "Assinatura agendada para 02/04/2025 às 10h00 na agência", want: time.Date(2025, 4, 2, 10, 0, 0, 0, portalLocation), wantOK: true},
+
+		{name: "vazio", text: "", wantOK: false},
+		{name: "sem data", text: "Não agendado", wantOK: false},
+		{name: "lixo", text: "##/##/#### 99:99", wantOK: false},
+		{name: "data inexistente", text: "31/02/2025", wantOK: false},
+		{name: "hora inválida", text: "15/03/2025 25:00", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseAgendamento(tt.text)
+			if ok != tt.wantOK {
+				t.Fatalf("ParseAgendamento(%q) ok = %v, esperado %v (%s)", tt.text, ok, tt.wantOK, got)
+			}
+			if !got.Equal(tt.want) {
+				t.Fatalf("ParseAgendamento(%q) = %s, esperado %s", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAgendamentoBrasilia(t *testing.T) {
+	// O horário exibido é o de Brasília (UTC-3 desde o fim do horário de verão)
+	got, ok := ParseAgendamento("15/03/2025 14:30")
+	if !ok {
+		t.Fatal("ParseAgendamento falhou")
+	}
+	if want := time.Date(2025, 3, 15, 17, 30, 0, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("ParseAgendamento = %s, esperado %s", got.UTC(), want)
+	}
+}
@@ -0,0 +1,182 @@
+package automation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	cdplog "github.com/chromedp/cdproto/log"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/artifacts"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/pkg/logger"
+)
+
+// FailureReporter - recebe as evidências do navegador quando uma execução falha
+type FailureReporter func(bundle *artifacts.Bundle)
+
+// Limites da captura
+const (
+	forensicsTimeout = 20 * time.Second // Tempo máximo para capturar tudo (o prazo da execução já pode ter vencido)
+	maxBrowserErrors = 100              // Erros de console/rede guardados (os mais recentes)
+)
+
+// pageHTMLScript - outerHTML do documento de cima
+const pageHTMLScript = `document.documentElement ? document.documentElement.outerHTML : ''`
+
+// iframeHTMLScript - outerHTML do iframe blank.jsp (vazio se não houver)
+const iframeHTMLScript = `(() => {
+	const frame = document.querySelector('iframe[src="blank.jsp"]');
+	try {
+		const doc = frame ? frame.contentDocument : null;
+		return doc && doc.documentElement ? doc.documentElement.outerHTML : '';
+	} catch (e) { return ''; }
+})()`
+
+// browserErrors - erros de console e de rede vistos durante a execução
+type browserErrors struct {
+	mu       sync.Mutex
+	console  []artifacts.ConsoleEntry
+	network  []artifacts.NetworkError
+	requests map[network.RequestID]string // URL das requisições em andamento
+}
+
+// recordBrowserErrors - começa a guardar os erros da aba (nil sem FailureReporter)
+// Os eventos deixam de ser ouvidos quando o ctx termina
+func (o *Orchestrator) recordBrowserErrors(ctx context.Context) *browserErrors {
+	if o.failure == nil {
+		return nil
+	}
+	recorder := &browserErrors{requests: make(map[network.RequestID]string)}
+	chromedp.ListenTarget(ctx, recorder.handle)
+	return recorder
+}
+
+// handle - trata um evento do CDP
+func (r *browserErrors) handle(ev interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch ev := ev.(type) {
+	case *runtime.EventConsoleAPICalled:
+		if ev.Type != runtime.APITypeError && ev.Type != runtime.APITypeAssert {
+			return
+		}
+		entry := artifacts.ConsoleEntry{Time: time.Now(), Source: "console." + string(ev.Type), Message: consoleMessage(ev.Args)}
+		if ev.StackTrace != nil && len(ev.StackTrace.CallFrames) > 0 {
+			entry.URL = ev.StackTrace.CallFrames[0].URL
+		}
+		r.addConsole(entry)
+
+	case *runtime.EventExceptionThrown:
+		details := ev.ExceptionDetails
+		message := details.Text
+		if details.Exception != nil && details.Exception.Description != "" {
+			message = details.Exception.Description
+		}
+		r.addConsole(artifacts.ConsoleEntry{Time: time.Now(), Source: "exception", Message: message, URL: details.URL})
+
+	case *cdplog.EventEntryAdded:
+		if ev.Entry.Level != cdplog.LevelError {
+			return
+		}
+		r.addConsole(artifacts.ConsoleEntry{Time: time.Now(), Source: string(ev.Entry.Source), Message: ev.Entry.Text, URL: ev.Entry.URL})
+
+	case *network.EventRequestWillBeSent:
+		r.requests[ev.RequestID] = ev.Request.URL
+
+	case *network.EventResponseReceived:
+		if ev.Response.Status >= 400 {
+			r.addNetwork(artifacts.NetworkError{Time: time.Now(), URL: ev.Response.URL, Status: int(ev.Response.Status), Error: ev.Response.StatusText})
+		}
+
+	case *network.EventLoadingFinished:
+		delete(r.requests, ev.RequestID)
+
+	case *network.EventLoadingFailed:
+		url := r.requests[ev.RequestID]
+		delete(r.requests, ev.RequestID)
+		if !ev.Canceled {
+			r.addNetwork(artifacts.NetworkError{Time: time.Now(), URL: url, Error: ev.ErrorText})
+		}
+	}
+}
+
+// addConsole - guarda o erro de console (descarta o mais antigo acima do limite)
+func (r *browserErrors) addConsole(entry artifacts.ConsoleEntry) {
+	r.console = append(r.console, entry)
+	if len(r.console) > maxBrowserErrors {
+		r.console = r.console[1:]
+	}
+}
+
+// addNetwork - guarda o erro de rede (descarta o mais antigo acima do limite)
+func (r *browserErrors) addNetwork(entry artifacts.NetworkError) {
+	r.network = append(r.network, entry)
+	if len(r.network) > maxBrowserErrors {
+		r.network = r.network[1:]
+	}
+}
+
+// snapshot - cópia dos erros guardados até agora
+func (r *browserErrors) snapshot() ([]artifacts.ConsoleEntry, []artifacts.NetworkError) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]artifacts.ConsoleEntry{}, r.console...), append([]artifacts.NetworkError{}, r.network...)
+}
+
+// consoleMessage - argumentos do console.error como texto
+func consoleMessage(args []*runtime.RemoteObject) string {
+	parts := make([]string, 0, len(args))
+	for _, arg := range args {
+		var text string
+		if arg.Type == runtime.TypeString && json.Unmarshal([]byte(arg.Value), &text) == nil {
+			parts = append(parts, text)
+			continue
+		}
+		if len(arg.Value) > 0 {
+			parts = append(parts, string(arg.Value))
+			continue
+		}
+		parts = append(parts, arg.Description)
+	}
+	return strings.Join(parts, " ")
+}
+
+// captureFailure - junta as evidências da falha e entrega ao FailureReporter
+// Execuções canceladas não são falhas: nada é capturado
+func (o *Orchestrator) captureFailure(ctx context.Context, recorder *browserErrors, err error) {
+	if err == nil || recorder == nil || errors.Is(err, context.Canceled) {
+		return
+	}
+
+	// O ctx da execução pode ter vencido justamente por causa da falha
+	captureCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), forensicsTimeout)
+	defer cancel()
+
+	bundle := &artifacts.Bundle{
+		Stage:      string(o.stage),
+		Error:      err.Error(),
+		ErrorCode:  ErrorCode(err),
+		CapturedAt: time.Now(),
+	}
+	capture := func(what string, action chromedp.Action) {
+		if err := chromedp.Run(captureCtx, action); err != nil {
+			bundle.CaptureErrors = append(bundle.CaptureErrors, fmt.Sprintf("%s: %v", what, err))
+		}
+	}
+	capture("url", chromedp.Location(&bundle.URL))
+	capture("screenshot", chromedp.FullScreenshot(&bundle.Screenshot, 100))
+	capture("page_html", chromedp.Evaluate(pageHTMLScript, &bundle.PageHTML))
+	capture("iframe_html", chromedp.Evaluate(iframeHTMLScript, &bundle.IframeHTML))
+	bundle.ConsoleErrors, bundle.NetworkErrors = recorder.snapshot()
+
+	logger.Info(fmt.Sprintf("🧾 Evidências da falha capturadas (etapa %s, %d erro(s) de console, %d de rede)",
+		bundle.Stage, len(bundle.ConsoleErrors), len(bundle.NetworkErrors)))
+	o.failure(bundle)
+}
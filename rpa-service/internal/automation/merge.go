@@ -0,0 +1,114 @@
+package automation
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/models"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/pkg/logger"
+)
+
+// ResultMerger - monta o ClientData final a partir dos resultados parciais de cada etapa
+// Cada campo fica com o valor da primeira etapa que o preencheu; valores diferentes
+// vindos de etapas seguintes viram conflitos em vez de sobrescrever.
+type ResultMerger struct {
+	result    *models.ClientData
+	sources   map[string]string
+	conflicts []models.FieldConflict
+}
+
+// NewResultMerger - cria merger vazio
+func NewResultMerger() *ResultMerger {
+	return &ResultMerger{
+		result:  &models.ClientData{},
+		sources: make(map[string]string),
+	}
+}
+
+// Campos de proveniência: preenchidos pelo próprio merger
+var mergeSkipFields = map[string]bool{"Fontes": true, "Conflitos": true}
+
+// Add - incorpora o resultado parcial de uma etapa (nil é ignorado)
+func (m *ResultMerger) Add(stage Stage, partial *models.ClientData) {
+	if partial == nil {
+		return
+	}
+
+	dst := reflect.ValueOf(m.result).Elem()
+	src := reflect.ValueOf(partial).Elem()
+	fields := dst.Type()
+
+	for i := 0; i < fields.NumField(); i++ {
+		field := fields.Field(i)
+		if mergeSkipFields[field.Name] {
+			continue
+		}
+
+		value := src.Field(i)
+		if value.IsZero() {
+			continue
+		}
+
+		name := jsonFieldName(field)
+		current := dst.Field(i)
+		if current.IsZero() {
+			current.Set(value)
+			m.sources[name] = string(stage)
+			continue
+		}
+
+		if reflect.DeepEqual(current.Interface(), value.Interface()) {
+			continue
+		}
+
+		conflict := models.FieldConflict{
+			Campo:           name,
+			Etapa:           m.sources[name],
+			Valor:           conflictValue(current),
+			EtapaDescartada: string(stage),
+			ValorDescartado: conflictValue(value),
+		}
+		m.conflicts = append(m.conflicts, conflict)
+		logger.Info(fmt.Sprintf("⚠️ Conflito em %s: %q (%s) x %q (%s) - mantido o primeiro",
+			conflict.Campo, conflict.Valor, conflict.Etapa, conflict.ValorDescartado, conflict.EtapaDescartada))
+	}
+}
+
+// Result - ClientData combinado com a proveniência dos campos e os conflitos
+func (m *ResultMerger) Result() *models.ClientData {
+	result := *m.result
+	result.Fontes = make(map[string]string, len(m.sources))
+	for name, stage := range m.sources {
+		result.Fontes[name] = stage
+	}
+	result.Conflitos = append([]models.FieldConflict(nil), m.conflicts...)
+	return &result
+}
+
+// Conflicts - conflitos registrados até agora
+func (m *ResultMerger) Conflicts() []models.FieldConflict {
+	return m.conflicts
+}
+
+// jsonFieldName - nome do campo como aparece na API
+func jsonFieldName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
+
+// conflictValue - valor legível para o relatório de conflitos (JSON fora strings)
+func conflictValue(value reflect.Value) string {
+	if value.Kind() == reflect.String {
+		return value.String()
+	}
+	encoded, err := json.Marshal(value.Interface())
+	if err != nil {
+		return fmt.Sprint(value.Interface())
+	}
+	return string(encoded)
+}
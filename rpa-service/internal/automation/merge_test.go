@@ -0,0 +1,91 @@
+package automation
+
+import (
+	"testing"
+
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/models"
+)
+
+func TestResultMergerProvenanceAndConflicts(t *testing.T) {
+	merger := NewResultMerger()
+	merger.Add(StageFinancial, &models.ClientData{Agencia: "1234", ValorCompraVenda: "250.000,00"})
+	merger.Add(StageParticipants, &models.ClientData{CPF: "52998224725", Agencia: "9999", ContaCorrente: "00012345-6"})
+	merger.Add(StageProperty, &models.ClientData{Agencia: "1234"}) // Mesmo valor: não é conflito
+	merger.Add(StageProperty, nil)
+
+	result := merger.Result()
+
+	// O primeiro valor fica
+	if result.Agencia != "1234" {
+		t.Fatalf("agencia %q, esperado o valor da primeira etapa", result.Agencia)
+	}
+	if result.CPF != "52998224725" || result.ContaCorrente != "00012345-6" || result.ValorCompraVenda != "250.000,00" {
+		t.Fatalf("campos sem conflito não foram copiados: %+v", result)
+	}
+
+	wantSources := map[string]Stage{
+		"agencia":            StageFinancial,
+		"valor_compra_venda": StageFinancial,
+		"cpf":                StageParticipants,
+		"conta_corrente":     StageParticipants,
+	}
+	if len(result.Fontes) != len(wantSources) {
+		t.Fatalf("fontes = %v, esperado %d campos", result.Fontes, len(wantSources))
+	}
+	for field, stage := range wantSources {
+		if result.Fontes[field] != string(stage) {
+			t.Fatalf("fonte de %s = %q, esperado %q", field, result.Fontes[field], stage)
+		}
+	}
+
+	want := models.FieldConflict{
+		Campo:           "agencia",
+		Etapa:           string(StageFinancial),
+		Valor:           "1234",
+		EtapaDescartada: string(StageParticipants),
+		ValorDescartado: "9999",
+	}
+	if len(result.Conflitos) != 1 || result.Conflitos[0] != want {
+		t.Fatalf("conflitos = %+v, esperado [%+v]", result.Conflitos, want)
+	}
+	if conflicts := merger.Conflicts(); len(conflicts) != 1 || conflicts[0] != want {
+		t.Fatalf("Conflicts() = %+v, esperado [%+v]", conflicts, want)
+	}
+}
+
+func TestResultMergerNonStringConflict(t *testing.T) {
+	merger := NewResultMerger()
+	merger.Add(StageSearch, &models.ClientData{Proposta: &models.Proposal{Numero: "8555"}})
+	merger.Add(StageParticipants, &models.ClientData{Proposta: &models.Proposal{Numero: "8554"}})
+
+	result := merger.Result()
+	if result.Proposta.Numero != "8555" || result.Fontes["proposta"] != string(StageSearch) {
+		t.Fatalf("proposta %+v (fonte %q), esperado a da busca", result.Proposta, result.Fontes["proposta"])
+	}
+
+	// Campos que não são string vão para o relatório em JSON
+	if len(result.Conflitos) != 1 {
+		t.Fatalf("conflitos = %+v, esperado 1", result.Conflitos)
+	}
+	conflict := result.Conflitos[0]
+	if conflict.Campo != "proposta" || conflict.Valor != `{"numero":"8555"}` || conflict.ValorDescartado != `{"numero":"8554"}` {
+		t.Fatalf("conflito inesperado: %+v", conflict)
+	}
+}
+
+func TestResultMergerResultIsACopy(t *testing.T) {
+	merger := NewResultMerger()
+	merger.Add(StageFinancial, &models.ClientData{Agencia: "1234"})
+
+	first := merger.Result()
+	first.Fontes["agencia"] = "alterado"
+	merger.Add(StageParticipants, &models.ClientData{Agencia: "9999"})
+
+	second := merger.Result()
+	if second.Fontes["agencia"] != string(StageFinancial) {
+		t.Fatalf("Result() compartilha o mapa de fontes: %v", second.Fontes)
+	}
+	if len(first.Conflitos) != 0 || len(second.Conflitos) != 1 {
+		t.Fatalf("Result() compartilha os conflitos: %d / %d", len(first.Conflitos), len(second.Conflitos))
+	}
+}
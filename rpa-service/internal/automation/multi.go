@@ -0,0 +1,81 @@
+package automation
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/models"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/pkg/logger"
+)
+
+// CPFResult - resultado de um CPF dentro de SearchMany
+type CPFResult struct {
+	Index    int // Posição do CPF na lista recebida
+	CPF      string
+	Response *models.SearchResponse
+	Err      error
+}
+
+// SearchMany - faz login uma vez e busca cada CPF no mesmo navegador
+// Os resultados chegam no canal à medida que cada CPF termina (na ordem da lista).
+// O canal é fechado ao final e deve ser lido até lá; cancelar o ctx interrompe a
+// busca em andamento e os CPFs restantes chegam com o erro do ctx.
+func (bot *CaixaBot) SearchMany(ctx context.Context, username, password string, cpfs []string, selection models.ProposalSelection) <-chan CPFResult {
+	results := make(chan CPFResult)
+
+	go func() {
+		defer close(results)
+
+		session, err := bot.OpenSession(ctx, username, password)
+		if err != nil {
+			// Sem login não há o que buscar: todos os CPFs falham com o mesmo erro
+			for i, cpf := range cpfs {
+				results <- failedResult(i, cpf, err)
+			}
+			return
+		}
+		defer session.Close()
+
+		for result := range session.SearchMany(ctx, password, cpfs, selection) {
+			results <- result
+		}
+	}()
+
+	return results
+}
+
+// SearchMany - busca cada CPF na sessão já logada, na ordem da lista
+// Se o portal derrubar o login no meio da lista, loga de novo e repete o CPF (SearchRelogin).
+// A sessão continua aberta no final: quem abriu é quem fecha.
+func (s *Session) SearchMany(ctx context.Context, password string, cpfs []string, selection models.ProposalSelection) <-chan CPFResult {
+	results := make(chan CPFResult)
+
+	go func() {
+		defer close(results)
+
+		for i, cpf := range cpfs {
+			if ctx.Err() != nil {
+				results <- failedResult(i, cpf, ctx.Err())
+				continue
+			}
+
+			if len(cpfs) > 1 {
+				logger.Info(fmt.Sprintf("📋 CPF %d/%d: %s", i+1, len(cpfs), cpf))
+			}
+			response, err := s.SearchRelogin(ctx, password, CPFQuery(cpf), selection)
+			results <- CPFResult{Index: i, CPF: cpf, Response: response, Err: err}
+		}
+	}()
+
+	return results
+}
+
+// failedResult - resultado de erro para um CPF
+func failedResult(index int, cpf string, err error) CPFResult {
+	return CPFResult{
+		Index:    index,
+		CPF:      cpf,
+		Response: &models.SearchResponse{Success: false, Message: err.Error()},
+		Err:      err,
+	}
+}
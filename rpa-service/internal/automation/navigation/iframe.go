@@ -8,6 +8,7 @@ import (
 	"github.com/chromedp/cdproto/cdp"
 	"github.com/chromedp/chromedp"
 	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/automation/config"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/automation/waits"
 	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/pkg/logger"
 )
 
@@ -19,68 +20,60 @@ type IframeWaiter interface {
 // DefaultIframeWaiter - implementação padrão
 type DefaultIframeWaiter struct {
 	maxRetries int
-	waitTime   time.Duration
+	waits      *waits.Waits
 }
 
 // NewIframeWaiter - cria novo waiter com configurações
 func NewIframeWaiter(maxRetries config.MaxRetries, timeouts config.Timeouts) *DefaultIframeWaiter {
 	return &DefaultIframeWaiter{
 		maxRetries: maxRetries.IframeSearch,
-		waitTime:   timeouts.IframeWait / time.Duration(maxRetries.IframeSearch),
+		waits:      waits.New(timeouts),
 	}
 }
 
-// WaitForIframe - aguarda o iframe aparecer e retorna o node
+// WaitForIframe - aguarda o iframe aparecer (e terminar de carregar) e retorna o node
 func (w *DefaultIframeWaiter) WaitForIframe(ctx context.Context, pageName string) (*cdp.Node, error) {
 	logger.Info(fmt.Sprintf("⏳ [%s] Procurando iframe...", pageName))
 	
-	// Aguarda inicial
-	time.Sleep(3 * time.Second)
-	
-	for tentativa := 1; tentativa <= w.maxRetries; tentativa++ {
-		var nodes []*cdp.Node
-		
-		// SELETOR CORRETO: busca por src="blank.jsp"
-		err := chromedp.Run(ctx,
-			chromedp.Nodes(`iframe[src="blank.jsp"]`, &nodes, chromedp.BySearch, chromedp.AtLeast(0)),
-		)
-		
-		if err == nil && len(nodes) > 0 {
-			logger.Info(fmt.Sprintf("✅ [%s] Iframe encontrado na tentativa %d!", pageName, tentativa))
-			time.Sleep(2 * time.Second)
-			return nodes[0], nil
-		}
-		
-		if tentativa%3 == 0 {
-			logger.Info(fmt.Sprintf("⏳ [%s] Tentativa %d/%d...", pageName, tentativa, w.maxRetries))
-		}
-		
-		time.Sleep(w.waitTime)
+	step := waits.Step{Name: "iframe " + pageName, Replaces: 5 * time.Second}
+	if err := w.waits.FrameReady(ctx, step); err != nil {
+		logger.Error(fmt.Sprintf("❌ [%s] Iframe não encontrado!", pageName))
+		return nil, fmt.Errorf("iframe não encontrado: %w", err)
 	}
 	
-	logger.Error(fmt.Sprintf("❌ [%s] Iframe não encontrado após %d tentativas!", pageName, w.maxRetries))
-	return nil, fmt.Errorf("iframe não encontrado após %d tentativas", w.maxRetries)
+	return w.iframeNode(ctx, pageName, `iframe[src="blank.jsp"]`)
 }
 
 // WaitForIframeWithSelector - aguarda iframe com seletor customizado
 func (w *DefaultIframeWaiter) WaitForIframeWithSelector(ctx context.Context, pageName string, selector string) (*cdp.Node, error) {
 	logger.Info(fmt.Sprintf("🎯 [%s] Aguardando iframe com seletor: %s", pageName, selector))
 	
-	for tentativa := 1; tentativa <= w.maxRetries; tentativa++ {
+	step := waits.Step{Name: "iframe " + pageName, Replaces: 2 * time.Second}
+	err := w.waits.Until(ctx, step, w.waits.Timeouts().IframeWait, func(ctx context.Context) (bool, error) {
 		var nodes []*cdp.Node
-		
-		err := chromedp.Run(ctx,
-			chromedp.Nodes(selector, &nodes, chromedp.BySearch, chromedp.AtLeast(0)),
-		)
-		
-		if err == nil && len(nodes) > 0 {
-			logger.Info(fmt.Sprintf("✓ [%s] Iframe encontrado!", pageName))
-			time.Sleep(2 * time.Second)
-			return nodes[0], nil
-		}
-		
-		time.Sleep(w.waitTime)
+		err := chromedp.Run(ctx, chromedp.Nodes(selector, &nodes, chromedp.BySearch, chromedp.AtLeast(0)))
+		return err == nil && len(nodes) > 0, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("iframe não encontrado: %w", err)
+	}
+	
+	return w.iframeNode(ctx, pageName, selector)
+}
+
+// iframeNode - node do iframe (já carregado) para usar com chromedp.FromNode
+func (w *DefaultIframeWaiter) iframeNode(ctx context.Context, pageName, selector string) (*cdp.Node, error) {
+	var nodes []*cdp.Node
+	err := chromedp.Run(ctx,
+		chromedp.Nodes(selector, &nodes, chromedp.BySearch, chromedp.AtLeast(0)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("iframe não encontrado")
 	}
 	
-	return nil, fmt.Errorf("iframe não encontrado")
-}
\ No newline at end of file
+	logger.Info(fmt.Sprintf("✅ [%s] Iframe encontrado!", pageName))
+	return nodes[0], nil
+}
@@ -2,11 +2,13 @@ package navigation
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/chromedp/chromedp"
 	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/automation/config"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/automation/waits"
 	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/pkg/logger"
 )
 
@@ -21,8 +23,12 @@ type CaixaLoginNavigator struct {
 	url        string
 	timeouts   config.Timeouts
 	maxRetries config.MaxRetries
+	waits      *waits.Waits
 }
 
+// loginFeedbackScript - o portal mostrou erro sem sair da página de login
+const loginFeedbackScript = `document.querySelector('#input-error, #kc-error-message, .kc-feedback-text, .alert-error, .alert-danger') !== null`
+
 
 // Login - realiza o login no portal
 func (nav *CaixaLoginNavigator) Login(ctx context.Context, username, password string) error {
@@ -32,7 +38,9 @@ func (nav *CaixaLoginNavigator) Login(ctx context.Context, username, password st
 	err := chromedp.Run(ctx,
 		// Navega para a página
 		chromedp.Navigate(nav.url),
-		chromedp.Sleep(5*time.Second),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			return nav.waits.NetworkIdle(ctx, waits.Step{Name: "página de login", Replaces: 5 * time.Second})
+		}),
 		
 		// Debug: Verifica se página carregou
 		chromedp.ActionFunc(func(ctx context.Context) error {
@@ -61,6 +69,19 @@ func (nav *CaixaLoginNavigator) Login(ctx context.Context, username, password st
 			return nil
 		}),
 		
+		// Sem formulário de login: portal fora do ar/em manutenção não espera o WaitVisible
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			var page LoginPage
+			if err := chromedp.Evaluate(loginPageScript, &page).Do(ctx); err != nil || page.LoginForm {
+				return nil
+			}
+			if err := ClassifyLoginPage(page, nav.url); errors.Is(err, ErrPortalUnavailable) {
+				logger.Error(fmt.Sprintf("❌ Portal indisponível: %v", err))
+				return err
+			}
+			return nil
+		}),
+		
 		// Aguarda campo de usuário
 		chromedp.WaitVisible(`#username`, chromedp.ByID),
 		
@@ -92,21 +113,21 @@ func (nav *CaixaLoginNavigator) Login(ctx context.Context, username, password st
 			return nil
 		}),
 		
-		chromedp.Sleep(1*time.Second),
-		
-// Clica no botão
+		// Clica no botão e aguarda navegação COMPLETA (ou mensagem de erro na própria página)
 		chromedp.ActionFunc(func(ctx context.Context) error {
+			waits.Dropped(ctx, waits.Step{Name: "antes do clique em Entrar", Replaces: 1 * time.Second})
 			logger.Info("🎯 Clicando no botão de login...")
-			script := `document.querySelector('#btn_login').click();`
-			return chromedp.Evaluate(script, nil).Do(ctx)
-		}),
-		
-		// Aguarda navegação COMPLETA
-		chromedp.ActionFunc(func(ctx context.Context) error {
 			logger.Info("⏳ Aguardando redirecionamento pós-login...")
-			return nil
+			click := chromedp.Evaluate(`document.querySelector('#btn_login').click();`, nil)
+			step := waits.Step{Name: "redirecionamento pós-login", Replaces: 8 * time.Second}
+			err := nav.waits.NavigationUntil(ctx, step, click, loginFeedbackScript)
+			if errors.Is(err, waits.ErrTimeout) {
+				// VerifyLoginSuccess classifica o que ficou na tela
+				logger.Error(fmt.Sprintf("⚠️ %v", err))
+				return nil
+			}
+			return err
 		}),
-		chromedp.Sleep(8*time.Second),
 		
 		chromedp.WaitReady("body", chromedp.ByQuery),
 		
@@ -127,37 +148,36 @@ func NewCaixaLoginNavigator(timeouts config.Timeouts, maxRetries config.MaxRetri
 		url:        "https://habitacao.caixa.gov.br/siopiweb-web/",
 		timeouts:   timeouts,
 		maxRetries: maxRetries,
+		waits:      waits.New(timeouts),
 	}
 }
 
 // VerifyLoginSuccess - verifica se o login foi bem-sucedido
+// Lê URL e mensagens do portal e devolve um *LoginError (ErrInvalidCredentials,
+// ErrPasswordExpired, ErrAccountLocked, ErrPortalUnavailable ou ErrUnexpectedPage)
 func (nav *CaixaLoginNavigator) VerifyLoginSuccess(ctx context.Context) error {
 	logger.Info("✓ Verificando sucesso do login...")
 	
-	// Aguarda um pouco mais para garantir
-	time.Sleep(2 * time.Second)
-	
-	// Tenta pegar o título, mas não falha se der erro
-	var pageTitle string
-	err := chromedp.Title(&pageTitle).Do(ctx)
-	
+	var page LoginPage
+	err := chromedp.Run(ctx,
+		chromedp.WaitReady("body", chromedp.ByQuery),
+		chromedp.Evaluate(loginPageScript, &page),
+	)
 	if err != nil {
-		logger.Info("⚠️ Não foi possível verificar título (página ainda carregando)")
-		// Não retorna erro, só avisa
-		return nil
+		return fmt.Errorf("erro ao ler página pós-login: %w", err)
 	}
 	
-	logger.Info(fmt.Sprintf("📄 Título da página: %s", pageTitle))
-	
-	// Verifica se a URL mudou (sinal de sucesso)
-	var currentURL string
-	chromedp.Evaluate(`window.location.href`, &currentURL).Do(ctx)
+	logger.Info(fmt.Sprintf("📄 Título da página: %s", page.Title))
+	logger.Info(fmt.Sprintf("📍 URL pós-login: %s", page.URL))
+	for _, message := range page.Messages {
+		logger.Info(fmt.Sprintf("💬 Mensagem do portal: %s", message))
+	}
 	
-	if currentURL != "" && currentURL != nav.url {
-		logger.Info("✅ Login realizado com sucesso! (URL mudou)")
-		return nil
+	if err := ClassifyLoginPage(page, nav.url); err != nil {
+		logger.Error(fmt.Sprintf("❌ Login falhou: %v", err))
+		return err
 	}
 	
-	logger.Info("✅ Login aparentemente bem-sucedido!")
+	logger.Info("✅ Login realizado com sucesso!")
 	return nil
-}
\ No newline at end of file
+}
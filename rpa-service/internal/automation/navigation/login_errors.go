@@ -0,0 +1,179 @@
+package navigation
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Resultados de login reconhecidos (use errors.Is)
+var (
+	ErrInvalidCredentials = errors.New("usuário ou senha inválidos")
+	ErrPasswordExpired    = errors.New("senha expirada")
+	ErrAccountLocked      = errors.New("usuário bloqueado")
+	ErrPortalUnavailable  = errors.New("portal indisponível")
+	ErrUnexpectedPage     = errors.New("página inesperada após o login")
+)
+
+// Códigos estáveis expostos pela API (error_code)
+var loginErrorCodes = map[error]string{
+	ErrInvalidCredentials: "invalid_credentials",
+	ErrPasswordExpired:    "password_expired",
+	ErrAccountLocked:      "account_locked",
+	ErrPortalUnavailable:  "portal_unavailable",
+	ErrUnexpectedPage:     "unexpected_page",
+}
+
+// LoginError - falha de login classificada, com o que o portal mostrou
+type LoginError struct {
+	Kind          error  // Um dos Err* acima
+	PortalMessage string // Mensagem de erro exibida pelo portal (se houver)
+	URL           string // URL da página pós-login
+}
+
+func (e *LoginError) Error() string {
+	if e.PortalMessage != "" {
+		return fmt.Sprintf("%s: %s", e.Kind, e.PortalMessage)
+	}
+	return fmt.Sprintf("%s (%s)", e.Kind, e.URL)
+}
+
+func (e *LoginError) Unwrap() error { return e.Kind }
+
+// ErrorCode - código estável do erro (ex: "invalid_credentials")
+func (e *LoginError) ErrorCode() string {
+	return loginErrorCodes[e.Kind]
+}
+
+// LoginPage - o que foi lido da página depois de clicar em "Entrar"
+type LoginPage struct {
+	URL          string   `json:"url"`
+	Title        string   `json:"title"`
+	LoginForm    bool     `json:"loginForm"`    // Campo de senha ainda na tela
+	Messages     []string `json:"messages"`     // Textos das caixas de erro/alerta do portal
+	BodyText     string   `json:"bodyText"`     // Início do texto da página (páginas de manutenção não têm caixa de erro)
+	PasswordForm bool     `json:"passwordForm"` // Formulário de troca de senha (nova senha + confirmação)
+}
+
+// loginPageScript - coleta a LoginPage no navegador
+const loginPageScript = `(() => {
+	const texts = [];
+	const selectors = [
+		'#input-error', '#kc-error-message', '.kc-feedback-text', '.alert-error', '.alert-danger',
+		'.alert-warning', '.mensagem-erro', '.msgErro', '.erro', '.error', '[role="alert"]'
+	];
+	for (const el of document.querySelectorAll(selectors.join(','))) {
+		const text = (el.innerText || '').trim();
+		if (text && !texts.includes(text)) texts.push(text);
+	}
+	const body = document.body ? (document.body.innerText || '') : '';
+	return {
+		url: window.location.href,
+		title: document.title,
+		loginForm: document.querySelector('#password') !== null,
+		messages: texts,
+		bodyText: body.substring(0, 2000),
+		passwordForm: document.querySelector('#password-new, #password-confirm, input[name="novaSenha"]') !== null
+	};
+})()`
+
+// Trechos (sem acento, minúsculos) que identificam cada situação
+var (
+	unavailablePatterns = []string{
+		"manutencao", "indisponivel", "temporariamente fora", "service unavailable",
+		"bad gateway", "gateway timeout", "erro interno", "internal server error", "tente novamente mais tarde",
+	}
+	// Só o bloqueio já feito: avisos como "será bloqueado" vêm junto da senha inválida
+	lockedPatterns = []string{
+		"esta bloqueado", "esta bloqueada", "foi bloqueado", "foi bloqueada", "usuario bloqueado", "conta bloqueada",
+		"conta desativada", "temporariamente desativada", "account is disabled", "temporarily disabled",
+		"esta suspenso", "esta suspensa", "foi suspenso", "foi suspensa",
+	}
+	expiredPatterns = []string{
+		"senha expirada", "senha expirou", "alterar sua senha", "alterar a senha",
+		"atualizar senha", "troca de senha", "update password", "password expired",
+	}
+	invalidPatterns = []string{
+		"usuario ou senha invalid", "senha invalida", "usuario invalido", "credenciais invalidas",
+		"invalid username or password", "invalid credentials", "nao confere",
+	}
+)
+
+// ClassifyLoginPage - decide se o login deu certo (nil) ou qual foi a falha
+// A ordem importa: manutenção e bloqueio costumam vir com o formulário de login na tela,
+// e senha inválida vem antes do bloqueio (o aviso de tentativas restantes cita o bloqueio)
+func ClassifyLoginPage(page LoginPage, loginURL string) error {
+	messages := foldText(strings.Join(page.Messages, " | "))
+
+	// Texto solto da página só conta fora do portal (a home do SIOPI pode citar "indisponível")
+	body := foldText(page.Title)
+	if !isPortalURL(page.URL, loginURL) {
+		body += " " + foldText(page.BodyText)
+	}
+	failure := func(kind error) error {
+		return &LoginError{Kind: kind, PortalMessage: strings.Join(page.Messages, " | "), URL: page.URL}
+	}
+
+	switch {
+	case containsAny(messages, unavailablePatterns) || (len(page.Messages) == 0 && containsAny(body, unavailablePatterns)):
+		return failure(ErrPortalUnavailable)
+	case containsAny(messages, invalidPatterns):
+		return failure(ErrInvalidCredentials)
+	case containsAny(messages, lockedPatterns):
+		return failure(ErrAccountLocked)
+	case page.PasswordForm || containsAny(messages, expiredPatterns) || isPasswordUpdateURL(page.URL):
+		return failure(ErrPasswordExpired)
+	case page.LoginForm:
+		// Continua na tela de login sem mensagem reconhecida
+		return failure(ErrUnexpectedPage)
+	case isPortalURL(page.URL, loginURL):
+		return nil
+	default:
+		return failure(ErrUnexpectedPage)
+	}
+}
+
+// isPortalURL - a página pós-login é do próprio SIOPI (mesmo host e aplicação da URL de login)
+func isPortalURL(current, loginURL string) bool {
+	currentURL, err := url.Parse(current)
+	if err != nil {
+		return false
+	}
+	portalURL, err := url.Parse(loginURL)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(currentURL.Host, portalURL.Host) && strings.HasPrefix(currentURL.Path, portalURL.Path)
+}
+
+// isPasswordUpdateURL - SSO redirecionou para a troca obrigatória de senha
+func isPasswordUpdateURL(current string) bool {
+	lower := strings.ToLower(current)
+	return strings.Contains(lower, "update_password") || strings.Contains(lower, "required-action")
+}
+
+// containsAny - texto contém algum dos trechos
+func containsAny(text string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if strings.Contains(text, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// accentFolder - remove acentos para comparar mensagens do portal
+var accentFolder = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a",
+	"é", "e", "ê", "e",
+	"í", "i",
+	"ó", "o", "ô", "o", "õ", "o",
+	"ú", "u", "ü", "u",
+	"ç", "c",
+)
+
+// foldText - minúsculas e sem acento
+func foldText(text string) string {
+	return accentFolder.Replace(strings.ToLower(text))
+}
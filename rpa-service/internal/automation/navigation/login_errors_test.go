@@ -0,0 +1,130 @@
+package navigation
+
+import (
+	"errors"
+	"testing"
+)
+
+const testLoginURL = "https://habitacao.caixa.gov.br/siopiweb-web/"
+
+func TestClassifyLoginPage(t *testing.T) {
+	tests := []struct {
+		name string
+		page LoginPage
+		want error // nil = login com sucesso
+	}{
+		{
+			name: "login com sucesso",
+			page: LoginPage{URL: "https://habitacao.caixa.gov.br/siopiweb-web/principal.do", Title: "SIOPI"},
+			want: nil,
+		},
+		{
+			name: "senha inválida",
+			page: LoginPage{URL: testLoginURL, LoginForm: true, Messages: []string{"Usuário ou senha inválidos."}},
+			want: ErrInvalidCredentials,
+		},
+		{
+			name: "senha inválida do SSO",
+			page: LoginPage{URL: "https://login.caixa.gov.br/auth/realms/internet/login-actions/authenticate", LoginForm: true,
+				Messages: []string{"Nome de usuário ou senha inválida."}},
+			want: ErrInvalidCredentials,
+		},
+		{
+			// O aviso de tentativas restantes cita o bloqueio no futuro: ainda é senha errada
+			name: "senha inválida com aviso de bloqueio",
+			page: LoginPage{URL: testLoginURL, LoginForm: true,
+				Messages: []string{"Usuário ou senha inválidos. Após mais 1 tentativa(s) inválida(s) o usuário será bloqueado."}},
+			want: ErrInvalidCredentials,
+		},
+		{
+			name: "senha não confere com aviso de bloqueio",
+			page: LoginPage{URL: testLoginURL, LoginForm: true,
+				Messages: []string{"Senha não confere. Atenção: na próxima tentativa incorreta sua conta será bloqueada."}},
+			want: ErrInvalidCredentials,
+		},
+		{
+			name: "usuário bloqueado",
+			page: LoginPage{URL: testLoginURL, LoginForm: true, Messages: []string{"Usuário bloqueado. Procure o administrador do sistema."}},
+			want: ErrAccountLocked,
+		},
+		{
+			name: "conta bloqueada por tentativas",
+			page: LoginPage{URL: testLoginURL, LoginForm: true, Messages: []string{"Sua conta foi bloqueada por excesso de tentativas inválidas."}},
+			want: ErrAccountLocked,
+		},
+		{
+			name: "conta desativada no SSO",
+			page: LoginPage{URL: testLoginURL, LoginForm: true, Messages: []string{"Conta desativada, contate o administrador."}},
+			want: ErrAccountLocked,
+		},
+		{
+			name: "senha expirada",
+			page: LoginPage{URL: testLoginURL, Messages: []string{"Sua senha expirou. É necessário cadastrar uma nova senha."}},
+			want: ErrPasswordExpired,
+		},
+		{
+			name: "troca obrigatória de senha",
+			page: LoginPage{URL: "https://login.caixa.gov.br/auth/realms/internet/login-actions/required-action?execution=UPDATE_PASSWORD",
+				PasswordForm: true, Messages: []string{"Você precisa alterar sua senha para ativar sua conta."}},
+			want: ErrPasswordExpired,
+		},
+		{
+			name: "portal em manutenção",
+			page: LoginPage{URL: "https://habitacao.caixa.gov.br/manutencao.html", Title: "Aviso",
+				BodyText: "O sistema está em manutenção. Tente novamente mais tarde."},
+			want: ErrPortalUnavailable,
+		},
+		{
+			name: "erro interno com formulário na tela",
+			page: LoginPage{URL: testLoginURL, LoginForm: true, Messages: []string{"Erro interno. Tente novamente mais tarde."}},
+			want: ErrPortalUnavailable,
+		},
+		{
+			// Dentro do portal o texto solto não conta: a home pode citar "indisponível"
+			name: "home do portal citando indisponível",
+			page: LoginPage{URL: "https://habitacao.caixa.gov.br/siopiweb-web/principal.do", Title: "SIOPI",
+				BodyText: "Serviço de simulação indisponível aos domingos"},
+			want: nil,
+		},
+		{
+			name: "formulário de login sem mensagem",
+			page: LoginPage{URL: testLoginURL, LoginForm: true},
+			want: ErrUnexpectedPage,
+		},
+		{
+			name: "outro site",
+			page: LoginPage{URL: "https://www.caixa.gov.br/", Title: "CAIXA"},
+			want: ErrUnexpectedPage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ClassifyLoginPage(tt.page, testLoginURL)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("ClassifyLoginPage = %v, esperado sucesso", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("ClassifyLoginPage = %v, esperado %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoginErrorCodeAndMessage(t *testing.T) {
+	page := LoginPage{URL: testLoginURL, LoginForm: true, Messages: []string{"Usuário bloqueado."}}
+
+	var loginErr *LoginError
+	if !errors.As(ClassifyLoginPage(page, testLoginURL), &loginErr) {
+		t.Fatal("ClassifyLoginPage não devolveu *LoginError")
+	}
+	if loginErr.ErrorCode() != "account_locked" {
+		t.Fatalf("código %q, esperado account_locked", loginErr.ErrorCode())
+	}
+	if loginErr.PortalMessage != "Usuário bloqueado." {
+		t.Fatalf("mensagem do portal %q", loginErr.PortalMessage)
+	}
+}
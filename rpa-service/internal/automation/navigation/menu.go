@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/chromedp/cdproto/cdp"
 	"github.com/chromedp/chromedp"
 	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/automation/config"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/automation/waits"
 	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/pkg/logger"
 )
 
@@ -22,6 +24,7 @@ type MenuNavigator interface {
 type CaixaMenuNavigator struct {
 	timeouts   config.Timeouts
 	maxRetries config.MaxRetries
+	waits      *waits.Waits
 }
 
 // NewCaixaMenuNavigator - cria novo navegador de menu
@@ -29,6 +32,7 @@ func NewCaixaMenuNavigator(timeouts config.Timeouts, maxRetries config.MaxRetrie
 	return &CaixaMenuNavigator{
 		timeouts:   timeouts,
 		maxRetries: maxRetries,
+		waits:      waits.New(timeouts),
 	}
 }
 
@@ -44,10 +48,13 @@ func (nav *CaixaMenuNavigator) ClickIrPara(ctx context.Context, iframeWaiter Ifr
 	// Procura botão "Ir para"
 	xpath := `//img[@onclick="jQuery('#divFluxogramaProposta').dialog('open');outrasOP();"]`
 	
+	// O menu abre num dialog do jQuery (sem trocar de página): espera só a rede
 	return chromedp.Run(ctx,
 		chromedp.WaitVisible(xpath, chromedp.BySearch, chromedp.FromNode(iframeNode)),
 		chromedp.Click(xpath, chromedp.BySearch, chromedp.FromNode(iframeNode)),
-		chromedp.Sleep(nav.timeouts.AfterClick),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			return nav.waits.NetworkIdle(ctx, waits.Step{Name: "menu Ir Para", Replaces: nav.timeouts.AfterClick})
+		}),
 	)
 }
 
@@ -76,14 +83,10 @@ func (nav *CaixaMenuNavigator) ClickMenuOption(ctx context.Context, iframeWaiter
 	for _, selector := range selectors {
 		logger.Info(fmt.Sprintf("🔍 Tentando seletor: %s", selector))
 		
-		err := chromedp.Run(ctx,
-			chromedp.Sleep(1*time.Second),
-			chromedp.Click(selector, chromedp.ByID, chromedp.FromNode(iframeNode)),
-		)
+		err := nav.clickAndWait(ctx, iframeNode, menuName, selector)
 		
 		if err == nil {
 			logger.Info(fmt.Sprintf("✅ Menu '%s' clicado: %s", menuName, selector))
-			time.Sleep(nav.timeouts.AfterClick)
 			return nil
 		}
 		
@@ -119,14 +122,10 @@ func (nav *CaixaMenuNavigator) ClickMenuOptionDirect(ctx context.Context, iframe
 	for _, selector := range selectors {
 		logger.Info(fmt.Sprintf("🔍 Tentando seletor: %s", selector))
 		
-		err := chromedp.Run(ctx,
-			chromedp.Sleep(1*time.Second),
-			chromedp.Click(selector, chromedp.ByID, chromedp.FromNode(iframeNode)),
-		)
+		err := nav.clickAndWait(ctx, iframeNode, menuName, selector)
 		
 		if err == nil {
 			logger.Info(fmt.Sprintf("✅ Menu '%s' clicado: %s", menuName, selector))
-			time.Sleep(nav.timeouts.AfterClick)
 			return nil
 		}
 		
@@ -135,4 +134,19 @@ func (nav *CaixaMenuNavigator) ClickMenuOptionDirect(ctx context.Context, iframe
 	
 	logger.Error(fmt.Sprintf("❌ Menu '%s' não encontrado!", menuName))
 	return fmt.Errorf("menu '%s' não encontrado", menuName)
-}
\ No newline at end of file
+}
+
+// clickAndWait - clica na opção (se existir) e espera a página dela carregar no iframe
+func (nav *CaixaMenuNavigator) clickAndWait(ctx context.Context, iframeNode *cdp.Node, menuName, selector string) error {
+	var nodes []*cdp.Node
+	err := chromedp.Run(ctx, chromedp.Nodes(selector, &nodes, chromedp.ByID, chromedp.FromNode(iframeNode), chromedp.AtLeast(0)))
+	if err != nil {
+		return err
+	}
+	if len(nodes) == 0 {
+		return fmt.Errorf("seletor %s não encontrado", selector)
+	}
+	
+	step := waits.Step{Name: "menu " + menuName, Replaces: time.Second + nav.timeouts.AfterClick}
+	return nav.waits.Navigation(ctx, step, chromedp.Click(selector, chromedp.ByID, chromedp.FromNode(iframeNode)))
+}
@@ -9,20 +9,23 @@ import (
 	"github.com/chromedp/cdproto/cdp"
 	"github.com/chromedp/chromedp"
 	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/automation/config"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/automation/waits"
 	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/pkg/logger"
 )
 
 // ParticipantsNavigator - interface para navegação de participantes
 type ParticipantsNavigator interface {
 	ClickParticipantes(ctx context.Context, iframeWaiter IframeWaiter) error
-	ExtractCoobrigado(ctx context.Context, iframeWaiter IframeWaiter) (cpf, nome string, err error)
+	ListParticipants(ctx context.Context, iframeWaiter IframeWaiter) ([]ParticipantRow, error)
 	ClickProponenteCPF(ctx context.Context, iframeWaiter IframeWaiter) error
+	ClickParticipant(ctx context.Context, iframeWaiter IframeWaiter, row ParticipantRow) error
 }
 
 // CaixaParticipantsNavigator - implementação para participantes
 type CaixaParticipantsNavigator struct {
 	timeouts   config.Timeouts
 	maxRetries config.MaxRetries
+	waits      *waits.Waits
 }
 
 // NewCaixaParticipantsNavigator - cria novo navegador de participantes
@@ -30,6 +33,7 @@ func NewCaixaParticipantsNavigator(timeouts config.Timeouts, maxRetries config.M
 	return &CaixaParticipantsNavigator{
 		timeouts:   timeouts,
 		maxRetries: maxRetries,
+		waits:      waits.New(timeouts),
 	}
 }
 
@@ -58,16 +62,20 @@ func (nav *CaixaParticipantsNavigator) ClickParticipantes(ctx context.Context, i
 	for _, selector := range selectors {
 		logger.Info(fmt.Sprintf("🔍 Tentando seletor: %s", selector))
 		
-		// Tenta clicar direto
-		err := chromedp.Run(ctx,
-			chromedp.Sleep(1*time.Second),
-			chromedp.Click(selector, chromedp.ByID, chromedp.FromNode(iframeNode)),
-		)
+		// Tenta clicar direto (só se o botão existir) e espera a página carregar
+		var nodes []*cdp.Node
+		err := chromedp.Run(ctx, chromedp.Nodes(selector, &nodes, chromedp.ByID, chromedp.FromNode(iframeNode), chromedp.AtLeast(0)))
+		if err == nil && len(nodes) == 0 {
+			err = fmt.Errorf("seletor %s não encontrado", selector)
+		}
+		if err == nil {
+			step := waits.Step{Name: "menu Participantes", Replaces: time.Second + nav.timeouts.AfterClick}
+			err = nav.waits.Navigation(ctx, step, chromedp.Click(selector, chromedp.ByID, chromedp.FromNode(iframeNode)))
+		}
 		
 		// Se conseguiu clicar, sucesso!
 		if err == nil {
 			logger.Info(fmt.Sprintf("✅ Botão Participantes clicado: %s", selector))
-			time.Sleep(nav.timeouts.AfterClick)
 			return nil
 		}
 		
@@ -80,87 +88,132 @@ func (nav *CaixaParticipantsNavigator) ClickParticipantes(ctx context.Context, i
 }
 
 
-// ExtractCoobrigado - extrai CPF e nome do coobrigado
-func (nav *CaixaParticipantsNavigator) ExtractCoobrigado(ctx context.Context, iframeWaiter IframeWaiter) (string, string, error) {
-	logger.Info("👥 Extraindo dados do Coobrigado...")
-	
-	// Context com timeout específico (não crítico)
-	coobrigadoCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
-	defer cancel()
-	
-	// Busca iframe
-	iframeNode, err := iframeWaiter.WaitForIframe(coobrigadoCtx, "Coobrigado")
-	if err != nil {
-		logger.Error("❌ Iframe não encontrado!")
-		return "", "", err
-	}
-	
-	logger.Info("✅ Iframe encontrado! Procurando coobrigado...")
-	
-	// Extrai CPF e Nome do coobrigado
-	cpf, nome, err := nav.extractCoobrigadoFromTable(coobrigadoCtx, iframeNode)
-	if err != nil {
-		logger.Info("⚠️ Coobrigado não encontrado ou não existe")
-		return "", "", nil
+// ParticipantRow - linha da página Participantes (tr#ItemN com link detalharParticipante)
+type ParticipantRow struct {
+	RowID string // ID da linha (Item1, Item2, ...)
+	CPF   string // Texto do link
+	Nome  string
+	Papel string // Proponente, Cônjuge, Coobrigado...
+}
+
+// participantsScript - lê as linhas de participantes dentro do iframe blank.jsp
+const participantsScript = `(() => {
+	const frame = document.querySelector('iframe[src="blank.jsp"]');
+	const doc = frame ? frame.contentDocument : document;
+	if (!doc) return [];
+	const text = (el) => el ? (el.innerText || el.textContent || '').replace(/\s+/g, ' ').trim() : '';
+	const rows = [];
+	for (const tr of doc.querySelectorAll('tr[id^="Item"]')) {
+		const link = tr.querySelector('a[onclick*="detalharParticipante"]');
+		if (!link) continue;
+		let headers = [];
+		const table = tr.closest('table');
+		const headerRow = table ? table.querySelector('tr:has(th)') : null;
+		if (headerRow) headers = Array.from(headerRow.querySelectorAll('th')).map(text);
+		rows.push({ id: tr.id, link: text(link), cells: Array.from(tr.querySelectorAll('td')).map(text), headers: headers });
 	}
-	
-	logger.Info(fmt.Sprintf("✅ Coobrigado encontrado: %s (%s)", nome, cpf))
-	return cpf, nome, nil
+	return rows;
+})()`
+
+// participantScriptRow - o que participantsScript devolve por linha
+type participantScriptRow struct {
+	ID      string   `json:"id"`
+	Link    string   `json:"link"`
+	Cells   []string `json:"cells"`
+	Headers []string `json:"headers"`
 }
 
-// extractCoobrigadoFromTable - extrai coobrigado da tabela
-func (nav *CaixaParticipantsNavigator) extractCoobrigadoFromTable(ctx context.Context, iframeNode *cdp.Node) (string, string, error) {
-	logger.Info("🔍 Procurando linha do Coobrigado (Item2)...")
+// ListParticipants - todos os participantes da página Participantes
+func (nav *CaixaParticipantsNavigator) ListParticipants(ctx context.Context, iframeWaiter IframeWaiter) ([]ParticipantRow, error) {
+	logger.Info("👥 Listando participantes...")
 	
-	// XPath CORRETO para o link com CPF do coobrigado
-	xpathCPF := `//tr[@id='Item2']//a[contains(@onclick, 'detalharParticipante')]`
+	iframeNode, err := iframeWaiter.WaitForIframe(ctx, "Participantes")
+	if err != nil {
+		logger.Error("❌ Iframe não encontrado!")
+		return nil, err
+	}
 	
-	var cpf string
-	err := chromedp.Run(ctx,
-		chromedp.WaitVisible(xpathCPF, chromedp.BySearch, chromedp.FromNode(iframeNode)),
-		chromedp.Text(xpathCPF, &cpf, chromedp.BySearch, chromedp.FromNode(iframeNode)),
+	// Proponente (Item1) sempre existe: espera a tabela antes de ler
+	err = chromedp.Run(ctx,
+		chromedp.WaitVisible(`//tr[@id='Item1']//a[contains(@onclick, 'detalharParticipante')]`, chromedp.BySearch, chromedp.FromNode(iframeNode)),
 	)
-	
 	if err != nil {
-		return "", "", fmt.Errorf("CPF do coobrigado não encontrado: %w", err)
+		logger.Error("❌ Tabela de participantes não encontrada!")
+		return nil, fmt.Errorf("tabela de participantes não encontrada: %w", err)
 	}
 	
-	cpf = strings.TrimSpace(cpf)
-	logger.Info(fmt.Sprintf("✅ CPF Coobrigado: %s", cpf))
+	var scriptRows []participantScriptRow
+	if err := chromedp.Run(ctx, chromedp.Evaluate(participantsScript, &scriptRows)); err != nil {
+		return nil, fmt.Errorf("erro ao ler participantes: %w", err)
+	}
 	
-	// XPath CORRETO para nome do coobrigado (coluna 3)
-	xpathNome := `//tr[@id='Item2']//td[3]`
+	rows := make([]ParticipantRow, 0, len(scriptRows))
+	for _, scriptRow := range scriptRows {
+		row := parseParticipantRow(scriptRow)
+		logger.Info(fmt.Sprintf("   👤 %s: %s (%s)", row.Papel, row.Nome, row.CPF))
+		rows = append(rows, row)
+	}
 	
-	var nome string
-	err = chromedp.Run(ctx,
-		chromedp.Text(xpathNome, &nome, chromedp.BySearch, chromedp.FromNode(iframeNode)),
-	)
+	logger.Info(fmt.Sprintf("✅ %d participante(s) encontrado(s)", len(rows)))
+	return rows, nil
+}
+
+// parseParticipantRow - identifica CPF, nome e papel da linha
+// Sem coluna de papel reconhecida: Item1 é o proponente e Item2 o coobrigado (layout usual do portal)
+func parseParticipantRow(scriptRow participantScriptRow) ParticipantRow {
+	row := ParticipantRow{RowID: scriptRow.ID, CPF: strings.TrimSpace(scriptRow.Link)}
 	
-	if err != nil {
-		return cpf, "", fmt.Errorf("nome do coobrigado não encontrado: %w", err)
+	nameColumn, roleColumn := 2, -1
+	for i, header := range scriptRow.Headers {
+		folded := foldText(header)
+		switch {
+		case containsAny(folded, []string{"nome"}):
+			nameColumn = i
+		case containsAny(folded, []string{"tipo", "particip", "qualifica", "papel", "vinculo"}):
+			roleColumn = i
+		}
 	}
 	
-	nome = strings.TrimSpace(nome)
-	logger.Info(fmt.Sprintf("✅ Nome Coobrigado: %s", nome))
-	
-	return cpf, nome, nil
+	row.Nome = cellAt(scriptRow.Cells, nameColumn)
+	row.Papel = cellAt(scriptRow.Cells, roleColumn)
+	if row.Papel == "" {
+		row.Papel = defaultParticipantRole(row.RowID)
+	}
+	return row
+}
+
+// defaultParticipantRole - papel pela posição da linha
+func defaultParticipantRole(rowID string) string {
+	switch rowID {
+	case "Item1":
+		return "Proponente"
+	case "Item2":
+		return "Coobrigado"
+	default:
+		return "Participante"
+	}
 }
 
 // ClickProponenteCPF - clica no CPF do proponente
 func (nav *CaixaParticipantsNavigator) ClickProponenteCPF(ctx context.Context, iframeWaiter IframeWaiter) error {
-	logger.Info("👤 Clicando no CPF do PROPONENTE...")
+	return nav.ClickParticipant(ctx, iframeWaiter, ParticipantRow{RowID: "Item1", Papel: "Proponente"})
+}
+
+// ClickParticipant - clica no CPF do participante e espera o "Detalhe do Participante"
+func (nav *CaixaParticipantsNavigator) ClickParticipant(ctx context.Context, iframeWaiter IframeWaiter, row ParticipantRow) error {
+	logger.Info(fmt.Sprintf("👤 Clicando no CPF do participante %s (%s)...", row.RowID, row.Papel))
 	
 	// Busca iframe UMA VEZ SÓ
-	iframeNode, err := iframeWaiter.WaitForIframe(ctx, "Proponente")
+	iframeNode, err := iframeWaiter.WaitForIframe(ctx, "Participante "+row.RowID)
 	if err != nil {
 		logger.Error("❌ Iframe não encontrado!")
 		return err
 	}
 	
-	logger.Info("✅ Iframe encontrado! Procurando CPF do proponente...")
+	logger.Info("✅ Iframe encontrado! Procurando CPF do participante...")
 	
-	// XPath para o link com CPF do proponente
-	xpath := `//tr[@id='Item1']//a[contains(@onclick, 'detalharParticipante')]`
+	// XPath para o link com CPF do participante
+	xpath := fmt.Sprintf(`//tr[@id='%s']//a[contains(@onclick, 'detalharParticipante')]`, row.RowID)
 	
 	// Tenta clicar com retries
 	for tentativa := 1; tentativa <= nav.maxRetries.ElementClick; tentativa++ {
@@ -168,9 +221,11 @@ func (nav *CaixaParticipantsNavigator) ClickProponenteCPF(ctx context.Context, i
 		
 		err := chromedp.Run(ctx,
 			chromedp.WaitVisible(xpath, chromedp.BySearch, chromedp.FromNode(iframeNode)),
-			chromedp.Click(xpath, chromedp.BySearch, chromedp.FromNode(iframeNode)),
-			chromedp.Sleep(3*time.Second),
 		)
+		if err == nil {
+			step := waits.Step{Name: "detalhe do participante " + row.RowID, Replaces: 3 * time.Second}
+			err = nav.waits.Navigation(ctx, step, chromedp.Click(xpath, chromedp.BySearch, chromedp.FromNode(iframeNode)))
+		}
 		
 		if err == nil {
 			logger.Info("✅ Clique realizado! Verificando se página carregou...")
@@ -187,8 +242,11 @@ func (nav *CaixaParticipantsNavigator) ClickProponenteCPF(ctx context.Context, i
 		}
 		
 		if tentativa < nav.maxRetries.ElementClick {
-			logger.Info("⏳ Aguardando antes de tentar novamente...")
-			time.Sleep(nav.timeouts.BetweenRetries)
+			logger.Info("⏳ Aguardando a página estabilizar antes de tentar novamente...")
+			step := waits.Step{Name: "nova tentativa no participante", Replaces: nav.timeouts.BetweenRetries}
+			if err := nav.waits.NetworkIdle(ctx, step); err != nil {
+				return err
+			}
 		}
 	}
 	
@@ -200,8 +258,8 @@ func (nav *CaixaParticipantsNavigator) ClickProponenteCPF(ctx context.Context, i
 func (nav *CaixaParticipantsNavigator) verifyDetailPageLoaded(ctx context.Context, iframeWaiter IframeWaiter) bool {
 	logger.Info("🔍 Verificando se página de detalhes carregou...")
 	
-	// Aguarda um pouco para a página carregar
-	time.Sleep(2 * time.Second)
+	// O clique já esperou a navegação; o WaitForIframe confirma que o iframe carregou
+	waits.Dropped(ctx, waits.Step{Name: "detalhe do participante", Replaces: 2 * time.Second})
 	
 	iframeNode, err := iframeWaiter.WaitForIframe(ctx, "Detalhe Participante")
 	if err != nil {
@@ -214,7 +272,7 @@ func (nav *CaixaParticipantsNavigator) verifyDetailPageLoaded(ctx context.Contex
 	
 	var nodes []*cdp.Node
 	err = chromedp.Run(ctx,
-		chromedp.Nodes(xpath, &nodes, chromedp.BySearch, chromedp.FromNode(iframeNode)),
+		chromedp.Nodes(xpath, &nodes, chromedp.BySearch, chromedp.FromNode(iframeNode), chromedp.AtLeast(0)),
 	)
 	
 	if err == nil && len(nodes) > 0 {
@@ -0,0 +1,211 @@
+package navigation
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/models"
+)
+
+// ErrNoProposals - a busca não retornou nenhuma proposta
+var ErrNoProposals = errors.New("nenhuma proposta encontrada na busca")
+
+// NoActiveProposalError - most_recent_active sem nenhuma proposta ativa na busca
+// Devolver a mais recente das canceladas traria o contrato errado: quem chamou decide
+type NoActiveProposalError struct {
+	Proposals []Proposal // Todas as propostas encontradas (nenhuma ativa)
+}
+
+func (e *NoActiveProposalError) Error() string {
+	return fmt.Sprintf("nenhuma proposta ativa entre as %d encontradas", len(e.Proposals))
+}
+
+// ErrorCode - código estável para a API
+func (e *NoActiveProposalError) ErrorCode() string { return "no_active_proposal" }
+
+// Proposal - proposta listada na tabela de resultados
+type Proposal struct {
+	Index   int    // Posição do link localizarProposta.do na tabela (0 = primeiro)
+	Number  string // Número da proposta (texto do link)
+	Status  string // Situação exibida pelo portal
+	Date    string // Data como exibida (dd/mm/aaaa)
+	Product string
+}
+
+// Model - proposta no formato da API
+func (p Proposal) Model() *models.Proposal {
+	return &models.Proposal{
+		Numero:   p.Number,
+		Situacao: p.Status,
+		Data:     p.Date,
+		Produto:  p.Product,
+	}
+}
+
+// Active - a proposta não está cancelada, encerrada ou reprovada
+// Sem situação na tabela não há como saber: conta como ativa
+func (p Proposal) Active() bool {
+	return !containsAny(foldText(p.Status), inactivePatterns)
+}
+
+// ParsedDate - data da proposta (zero se não reconhecida)
+func (p Proposal) ParsedDate() time.Time {
+	for _, layout := range proposalDateLayouts {
+		if date, err := time.Parse(layout, p.Date); err == nil {
+			return date
+		}
+	}
+	return time.Time{}
+}
+
+// Situações (sem acento, minúsculas) de propostas que não seguem mais
+var inactivePatterns = []string{
+	"cancelad", "encerrad", "inativ", "reprovad", "rejeitad", "arquivad",
+	"desist", "excluid", "expirad", "suspens", "indeferid",
+}
+
+// Formatos de data usados na tabela de resultados
+var proposalDateLayouts = []string{"02/01/2006 15:04:05", "02/01/2006 15:04", "02/01/2006"}
+
+// proposalDatePattern - data dd/mm/aaaa (com hora opcional) dentro de uma célula
+var proposalDatePattern = regexp.MustCompile(`\d{2}/\d{2}/\d{4}(?: \d{2}:\d{2}(?::\d{2})?)?`)
+
+// proposalTableScript - lê table.tb_lista dentro do iframe blank.jsp
+const proposalTableScript = `(() => {
+	const frame = document.querySelector('iframe[src="blank.jsp"]');
+	const doc = frame ? frame.contentDocument : document;
+	const table = doc ? doc.querySelector('table.tb_lista') : null;
+	if (!table) return null;
+	const text = (el) => (el.innerText || el.textContent || '').replace(/\s+/g, ' ').trim();
+	const headers = [];
+	const rows = [];
+	for (const tr of table.querySelectorAll('tr')) {
+		const link = tr.querySelector('a[onclick*="localizarProposta.do"]');
+		const cells = Array.from(tr.querySelectorAll('th, td')).map(text);
+		if (!link) {
+			if (headers.length === 0 && cells.some((c) => c !== '')) headers.push(...cells);
+			continue;
+		}
+		rows.push({ link: text(link), cells: cells });
+	}
+	return { headers: headers, rows: rows };
+})()`
+
+// proposalTable - o que proposalTableScript devolve
+type proposalTable struct {
+	Headers []string      `json:"headers"`
+	Rows    []proposalRow `json:"rows"`
+}
+
+// proposalRow - linha da tabela com link para a proposta
+type proposalRow struct {
+	Link  string   `json:"link"`
+	Cells []string `json:"cells"`
+}
+
+// parseProposalTable - converte a tabela lida no navegador em propostas
+// As colunas são localizadas pelo cabeçalho; sem cabeçalho reconhecido, o número vem
+// do link e a data da primeira célula com formato de data.
+func parseProposalTable(table proposalTable) []Proposal {
+	columns := proposalColumns(table.Headers)
+
+	proposals := make([]Proposal, 0, len(table.Rows))
+	for i, row := range table.Rows {
+		proposal := Proposal{
+			Index:   i,
+			Number:  cellAt(row.Cells, columns["number"]),
+			Status:  cellAt(row.Cells, columns["status"]),
+			Date:    cellAt(row.Cells, columns["date"]),
+			Product: cellAt(row.Cells, columns["product"]),
+		}
+		if proposal.Number == "" {
+			proposal.Number = row.Link
+		}
+		if match := proposalDatePattern.FindString(proposal.Date); match != "" {
+			proposal.Date = match
+		} else {
+			proposal.Date = firstDate(row.Cells)
+		}
+		proposals = append(proposals, proposal)
+	}
+	return proposals
+}
+
+// proposalColumns - índice de cada coluna conhecida pelo texto do cabeçalho
+func proposalColumns(headers []string) map[string]int {
+	columns := map[string]int{"number": -1, "status": -1, "date": -1, "product": -1}
+	for i, header := range headers {
+		folded := foldText(header)
+		switch {
+		case columns["status"] < 0 && containsAny(folded, []string{"situacao", "status", "fase"}):
+			columns["status"] = i
+		case columns["date"] < 0 && strings.Contains(folded, "data"):
+			columns["date"] = i
+		case columns["product"] < 0 && containsAny(folded, []string{"produto", "modalidade", "linha"}):
+			columns["product"] = i
+		case columns["number"] < 0 && containsAny(folded, []string{"proposta", "numero", "contrato"}):
+			columns["number"] = i
+		}
+	}
+	return columns
+}
+
+// cellAt - célula da coluna (vazio se a coluna não existe)
+func cellAt(cells []string, index int) string {
+	if index < 0 || index >= len(cells) {
+		return ""
+	}
+	return strings.TrimSpace(cells[index])
+}
+
+// firstDate - primeira data encontrada nas células da linha
+func firstDate(cells []string) string {
+	for _, cell := range cells {
+		if match := proposalDatePattern.FindString(cell); match != "" {
+			return match
+		}
+	}
+	return ""
+}
+
+// SelectProposals - propostas a extrair conforme o modo pedido
+// most_recent_active escolhe a mais recente entre as ativas; se todas estiverem
+// canceladas/encerradas, devolve *NoActiveProposalError.
+func SelectProposals(proposals []Proposal, selection models.ProposalSelection) ([]Proposal, error) {
+	if len(proposals) == 0 {
+		return nil, ErrNoProposals
+	}
+
+	switch selection {
+	case models.ProposalAll:
+		return proposals, nil
+	case models.ProposalMostRecentActive:
+		var active []Proposal
+		for _, proposal := range proposals {
+			if proposal.Active() {
+				active = append(active, proposal)
+			}
+		}
+		if len(active) == 0 {
+			return nil, &NoActiveProposalError{Proposals: proposals}
+		}
+		return []Proposal{mostRecent(active)}, nil
+	default:
+		return proposals[:1], nil
+	}
+}
+
+// mostRecent - proposta com a maior data (empate ou sem data: a que aparece antes na tabela)
+func mostRecent(proposals []Proposal) Proposal {
+	best := proposals[0]
+	bestDate := best.ParsedDate()
+	for _, proposal := range proposals[1:] {
+		if date := proposal.ParsedDate(); date.After(bestDate) {
+			best, bestDate = proposal, date
+		}
+	}
+	return best
+}
@@ -0,0 +1,221 @@
+package navigation
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/models"
+)
+
+func TestParseProposalTableWithHeaders(t *testing.T) {
+	table := proposalTable{
+		Headers: []string{"Nº Proposta", "Produto", "Data de Cadastro", "Situação"},
+		Rows: []proposalRow{
+			{Link: "8555", Cells: []string{"8555", "SBPE", "15/03/2024 10:30", "Em análise"}},
+			{Link: "8554", Cells: []string{"8554", "MCMV", "02/01/2023", "Cancelada"}},
+		},
+	}
+
+	proposals := parseProposalTable(table)
+	if len(proposals) != 2 {
+		t.Fatalf("%d propostas, esperado 2", len(proposals))
+	}
+
+	want := Proposal{Index: 0, Number: "8555", Status: "Em análise", Date: "15/03/2024 10:30", Product: "SBPE"}
+	if proposals[0] != want {
+		t.Fatalf("proposta 0 = %+v, esperado %+v", proposals[0], want)
+	}
+	if proposals[1].Index != 1 || proposals[1].Status != "Cancelada" || proposals[1].Date != "02/01/2023" {
+		t.Fatalf("proposta 1 = %+v", proposals[1])
+	}
+}
+
+func TestParseProposalTableWithoutHeaders(t *testing.T) {
+	// Sem cabeçalho reconhecido: número pelo link e data pela primeira célula com data
+	table := proposalTable{
+		Rows: []proposalRow{
+			{Link: "0001234", Cells: []string{"0001234", "FULANO DE TAL", "cadastrada em 05/06/2024 09:15:00"}},
+		},
+	}
+
+	proposals := parseProposalTable(table)
+	if len(proposals) != 1 {
+		t.Fatalf("%d propostas, esperado 1", len(proposals))
+	}
+	if proposals[0].Number != "0001234" {
+		t.Fatalf("número %q, esperado o texto do link", proposals[0].Number)
+	}
+	if proposals[0].Date != "05/06/2024 09:15:00" {
+		t.Fatalf("data %q, esperado a data da terceira célula", proposals[0].Date)
+	}
+	if proposals[0].Status != "" || proposals[0].Product != "" {
+		t.Fatalf("colunas sem cabeçalho preenchidas: %+v", proposals[0])
+	}
+}
+
+func TestProposalColumns(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers []string
+		want    map[string]int
+	}{
+		{
+			name:    "cabeçalho completo",
+			headers: []string{"Proposta", "Modalidade", "Data", "Status"},
+			want:    map[string]int{"number": 0, "product": 1, "date": 2, "status": 3},
+		},
+		{
+			name:    "acentos e maiúsculas",
+			headers: []string{"NÚMERO", "SITUAÇÃO", "DATA DA PROPOSTA"},
+			want:    map[string]int{"number": 0, "status": 1, "date": 2, "product": -1},
+		},
+		{
+			name:    "fase no lugar da situação",
+			headers: []string{"Contrato", "Fase"},
+			want:    map[string]int{"number": 0, "status": 1, "date": -1, "product": -1},
+		},
+		{
+			name:    "sem cabeçalho",
+			headers: nil,
+			want:    map[string]int{"number": -1, "status": -1, "date": -1, "product": -1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := proposalColumns(tt.headers)
+			for column, index := range tt.want {
+				if got[column] != index {
+					t.Fatalf("coluna %s = %d, esperado %d (%v)", column, got[column], index, got)
+				}
+			}
+		})
+	}
+}
+
+func TestProposalParsedDate(t *testing.T) {
+	tests := []struct {
+		date string
+		want time.Time
+	}{
+		{date: "15/03/2024 10:30:45", want: time.Date(2024, 3, 15, 10, 30, 45, 0, time.UTC)},
+		{date: "15/03/2024 10:30", want: time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC)},
+		{date: "15/03/2024", want: time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{date: "", want: time.Time{}},
+		{date: "2024-03-15", want: time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.date, func(t *testing.T) {
+			if got := (Proposal{Date: tt.date}).ParsedDate(); !got.Equal(tt.want) {
+				t.Fatalf("ParsedDate(%q) = %s, esperado %s", tt.date, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProposalActive(t *testing.T) {
+	tests := []struct {
+		status string
+		want   bool
+	}{
+		{status: "Em análise", want: true},
+		{status: "Contratada", want: true},
+		{status: "", want: true}, // Sem situação: conta como ativa
+		{status: "Cancelada", want: false},
+		{status: "CANCELADO PELO AGENTE", want: false},
+		{status: "Encerrada", want: false},
+		{status: "Reprovada", want: false},
+		{status: "Proposta Excluída", want: false},
+		{status: "Desistência do cliente", want: false},
+		{status: "Indeferida", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			if got := (Proposal{Status: tt.status}).Active(); got != tt.want {
+				t.Fatalf("Active(%q) = %v, esperado %v", tt.status, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectProposals(t *testing.T) {
+	proposals := []Proposal{
+		{Index: 0, Number: "A", Status: "Cancelada", Date: "10/05/2024"},
+		{Index: 1, Number: "B", Status: "Em análise", Date: "01/02/2024"},
+		{Index: 2, Number: "C", Status: "Contratada", Date: "20/03/2024"},
+		{Index: 3, Number: "D", Status: "Encerrada", Date: "01/06/2024"},
+	}
+
+	tests := []struct {
+		name      string
+		selection models.ProposalSelection
+		want      []string
+	}{
+		{name: "first", selection: models.ProposalFirst, want: []string{"A"}},
+		{name: "padrão vazio", selection: "", want: []string{"A"}},
+		{name: "most_recent_active", selection: models.ProposalMostRecentActive, want: []string{"C"}},
+		{name: "all", selection: models.ProposalAll, want: []string{"A", "B", "C", "D"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selected, err := SelectProposals(proposals, tt.selection)
+			if err != nil {
+				t.Fatalf("SelectProposals: %v", err)
+			}
+			if len(selected) != len(tt.want) {
+				t.Fatalf("%d propostas selecionadas, esperado %d", len(selected), len(tt.want))
+			}
+			for i, number := range tt.want {
+				if selected[i].Number != number {
+					t.Fatalf("proposta %d = %s, esperado %s", i, selected[i].Number, number)
+				}
+			}
+		})
+	}
+}
+
+func TestSelectProposalsMostRecentActiveTie(t *testing.T) {
+	// Mesma data (ou sem data): fica a que aparece antes na tabela
+	proposals := []Proposal{
+		{Number: "A", Status: "Em análise", Date: "01/02/2024"},
+		{Number: "B", Status: "Em análise", Date: "01/02/2024"},
+		{Number: "C", Status: "Em análise"},
+	}
+
+	selected, err := SelectProposals(proposals, models.ProposalMostRecentActive)
+	if err != nil {
+		t.Fatalf("SelectProposals: %v", err)
+	}
+	if selected[0].Number != "A" {
+		t.Fatalf("proposta %s, esperado A", selected[0].Number)
+	}
+}
+
+func TestSelectProposalsErrors(t *testing.T) {
+	if _, err := SelectProposals(nil, models.ProposalFirst); !errors.Is(err, ErrNoProposals) {
+		t.Fatalf("sem propostas: erro %v, esperado ErrNoProposals", err)
+	}
+
+	inactive := []Proposal{
+		{Number: "A", Status: "Cancelada", Date: "10/05/2024"},
+		{Number: "B", Status: "Encerrada", Date: "01/06/2024"},
+	}
+	_, err := SelectProposals(inactive, models.ProposalMostRecentActive)
+
+	var noActiveErr *NoActiveProposalError
+	if !errors.As(err, &noActiveErr) {
+		t.Fatalf("nenhuma ativa: erro %v, esperado NoActiveProposalError", err)
+	}
+	if len(noActiveErr.Proposals) != 2 || noActiveErr.ErrorCode() != "no_active_proposal" {
+		t.Fatalf("NoActiveProposalError inesperado: %+v (%s)", noActiveErr, noActiveErr.ErrorCode())
+	}
+
+	// Os outros modos não olham a situação
+	if selected, err := SelectProposals(inactive, models.ProposalFirst); err != nil || selected[0].Number != "A" {
+		t.Fatalf("first com canceladas: %v, %v", selected, err)
+	}
+}
@@ -2,26 +2,34 @@ package navigation
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/chromedp/chromedp"
 	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/automation/config"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/automation/waits"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/models"
 	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/pkg/logger"
 )
 
 // SearchNavigator - interface para navegação de busca
 type SearchNavigator interface {
+	ReturnToSearch(ctx context.Context, searchURL string) error
+	Search(ctx context.Context, query SearchQuery) error
 	SearchByCPF(ctx context.Context, cpf string) error
-	ClickFirstResult(ctx context.Context) error
-	ExtractAgendamentoAssinatura(ctx context.Context, iframeWaiter IframeWaiter) (string, error)
+	SearchByCNPJ(ctx context.Context, cnpj string) error
+	SearchByProposalNumber(ctx context.Context, number string) error
+	SearchByContractNumber(ctx context.Context, number string) error
+	ListProposals(ctx context.Context) ([]Proposal, error)
+	ClickProposal(ctx context.Context, proposal Proposal) error
 }
 
 // CaixaSearchNavigator - implementação para busca na Caixa
 type CaixaSearchNavigator struct {
 	timeouts   config.Timeouts
 	maxRetries config.MaxRetries
+	waits      *waits.Waits
 }
 
 // NewCaixaSearchNavigator - cria novo navegador de busca
@@ -29,17 +37,107 @@ func NewCaixaSearchNavigator(timeouts config.Timeouts, maxRetries config.MaxRetr
 	return &CaixaSearchNavigator{
 		timeouts:   timeouts,
 		maxRetries: maxRetries,
+		waits:      waits.New(timeouts),
 	}
 }
 
+// ErrSessionExpired - o portal derrubou a sessão e voltou para a tela de login
+var ErrSessionExpired = errors.New("sessão do portal expirou")
+
+// ReturnToSearch - volta para a página de busca (mesmo navegador, já logado)
+// searchURL é a URL da página pós-login, onde fica o formulário de busca
+func (nav *CaixaSearchNavigator) ReturnToSearch(ctx context.Context, searchURL string) error {
+	logger.Info("↩️ Voltando para a página de busca...")
+	
+	var loginVisible bool
+	err := chromedp.Run(ctx,
+		chromedp.Navigate(searchURL),
+		chromedp.WaitReady("body", chromedp.ByQuery),
+		chromedp.Evaluate(`document.querySelector('#username') !== null`, &loginVisible),
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao voltar para a busca: %w", err)
+	}
+	
+	if loginVisible {
+		logger.Error("❌ Portal voltou para a tela de login!")
+		return ErrSessionExpired
+	}
+	
+	logger.Info("✅ Página de busca carregada!")
+	return nil
+}
+
+// SearchQuery - o que buscar no portal (valor já normalizado, só dígitos)
+type SearchQuery struct {
+	Type  models.SearchType
+	Value string
+}
+
+// String - descrição para logs (ex: "CPF 12345678909")
+func (q SearchQuery) String() string {
+	return fmt.Sprintf("%s %s", searchFieldFor(q.Type).label, q.Value)
+}
+
+// searchField - campo do formulário e consulta disparada para cada tipo de busca
+type searchField struct {
+	input    string // ID do campo dentro do iframe
+	consulta string // Argumento de executaConsulta(...) no link "Consultar"
+	label    string
+}
+
+// searchFields - CPF e CNPJ usam o mesmo campo (#cpfCnpj); proposta e contrato têm campos próprios
+var searchFields = map[models.SearchType]searchField{
+	models.SearchTypeCPF:      {input: "cpfCnpj", consulta: "cpfCnpjProposta", label: "CPF"},
+	models.SearchTypeCNPJ:     {input: "cpfCnpj", consulta: "cpfCnpjProposta", label: "CNPJ"},
+	models.SearchTypeProposal: {input: "numeroProposta", consulta: "numeroProposta", label: "Proposta"},
+	models.SearchTypeContract: {input: "numeroContrato", consulta: "numeroContrato", label: "Contrato"},
+}
+
+// searchFieldFor - campo do tipo de busca (CPF se o tipo for desconhecido)
+func searchFieldFor(searchType models.SearchType) searchField {
+	if field, ok := searchFields[searchType]; ok {
+		return field
+	}
+	return searchFields[models.SearchTypeCPF]
+}
+
+// Search - busca conforme o tipo da consulta
+func (nav *CaixaSearchNavigator) Search(ctx context.Context, query SearchQuery) error {
+	return nav.search(ctx, searchFieldFor(query.Type), query.Value)
+}
+
 // SearchByCPF - busca por CPF no portal
 func (nav *CaixaSearchNavigator) SearchByCPF(ctx context.Context, cpf string) error {
-	logger.Info(fmt.Sprintf("🔍 Iniciando busca por CPF: %s", cpf))
+	return nav.search(ctx, searchFields[models.SearchTypeCPF], cpf)
+}
+
+// SearchByCNPJ - busca por CNPJ (operações de pessoa jurídica)
+func (nav *CaixaSearchNavigator) SearchByCNPJ(ctx context.Context, cnpj string) error {
+	return nav.search(ctx, searchFields[models.SearchTypeCNPJ], cnpj)
+}
+
+// SearchByProposalNumber - busca pelo número da proposta
+func (nav *CaixaSearchNavigator) SearchByProposalNumber(ctx context.Context, number string) error {
+	return nav.search(ctx, searchFields[models.SearchTypeProposal], number)
+}
+
+// SearchByContractNumber - busca pelo número do contrato
+func (nav *CaixaSearchNavigator) SearchByContractNumber(ctx context.Context, number string) error {
+	return nav.search(ctx, searchFields[models.SearchTypeContract], number)
+}
+
+// resultsTableScript - a tabela de resultados apareceu no iframe (mesmo sem recarregar a página)
+const resultsTableScript = `!!doc && doc.querySelector('table.tb_lista') !== null`
+
+// search - preenche o campo da busca dentro do iframe e dispara a consulta
+func (nav *CaixaSearchNavigator) search(ctx context.Context, field searchField, value string) error {
+	logger.Info(fmt.Sprintf("🔍 Iniciando busca por %s: %s", field.label, value))
 	
 	// PASSO 1: SEMPRE aguarda iframe PRIMEIRO
 	logger.Info("📍 PASSO 1: Aguardando iframe carregar...")
 	iframeWaiter := NewIframeWaiter(nav.maxRetries, nav.timeouts)
-	iframeNode, err := iframeWaiter.WaitForIframe(ctx, "Busca CPF")
+	iframeNode, err := iframeWaiter.WaitForIframe(ctx, "Busca "+field.label)
 	
 	if err != nil {
 		logger.Error("❌ Iframe não encontrado!")
@@ -48,59 +146,51 @@ func (nav *CaixaSearchNavigator) SearchByCPF(ctx context.Context, cpf string) er
 	
 	logger.Info("✅ Iframe encontrado! Iniciando busca...")
 	
-	// PASSO 2: Busca campo CPF DENTRO do iframe
-	logger.Info("📍 PASSO 2: Procurando campo CPF dentro do iframe...")
+	// PASSO 2: Busca o campo DENTRO do iframe
+	selector := "#" + field.input
+	logger.Info(fmt.Sprintf("📍 PASSO 2: Procurando campo %s dentro do iframe...", selector))
 	err = chromedp.Run(ctx,
-		chromedp.WaitVisible(`#cpfCnpj`, chromedp.ByID, chromedp.FromNode(iframeNode)),
+		chromedp.WaitVisible(selector, chromedp.ByID, chromedp.FromNode(iframeNode)),
 	)
 	
 	if err != nil {
-		logger.Error("❌ Campo CPF não encontrado dentro do iframe!")
-		return fmt.Errorf("campo CPF não encontrado: %w", err)
+		logger.Error(fmt.Sprintf("❌ Campo %s não encontrado dentro do iframe!", field.label))
+		return fmt.Errorf("campo %s não encontrado: %w", field.label, err)
 	}
 	
-	logger.Info("✅ Campo CPF encontrado!")
+	logger.Info(fmt.Sprintf("✅ Campo %s encontrado!", field.label))
 	
-	// PASSO 3: Preenche CPF
-	logger.Info("📍 PASSO 3: Preenchendo CPF...")
+	// PASSO 3: Preenche o valor
+	logger.Info(fmt.Sprintf("📍 PASSO 3: Preenchendo %s...", field.label))
 	err = chromedp.Run(ctx,
-		chromedp.Clear(`#cpfCnpj`, chromedp.ByID, chromedp.FromNode(iframeNode)),
-		chromedp.SendKeys(`#cpfCnpj`, cpf, chromedp.ByID, chromedp.FromNode(iframeNode)),
+		chromedp.Clear(selector, chromedp.ByID, chromedp.FromNode(iframeNode)),
+		chromedp.SendKeys(selector, value, chromedp.ByID, chromedp.FromNode(iframeNode)),
 	)
 	
 	if err != nil {
-		logger.Error("❌ Erro ao preencher CPF!")
+		logger.Error(fmt.Sprintf("❌ Erro ao preencher %s!", field.label))
 		return err
 	}
 	
-	logger.Info("✅ CPF preenchido!")
+	logger.Info(fmt.Sprintf("✅ %s preenchido!", field.label))
 	
-	// PASSO 4: Clica no botão de buscar
+	// PASSO 4: Clica no botão de buscar e aguarda os resultados
 	logger.Info("📍 PASSO 4: Clicando no botão de busca...")
-	err = chromedp.Run(ctx,
-	chromedp.Sleep(1*time.Second),
-	
-	// Clica no link com onclick
-	chromedp.Click(`//a[@onclick="executaConsulta('cpfCnpjProposta');"]`, chromedp.BySearch, chromedp.FromNode(iframeNode)),
-	
-	chromedp.Sleep(3*time.Second),
-)
+	click := chromedp.Click(fmt.Sprintf(`//a[@onclick="executaConsulta('%s');"]`, field.consulta), chromedp.BySearch, chromedp.FromNode(iframeNode))
+	step := waits.Step{Name: "resultados da busca", Replaces: 7 * time.Second}
+	err = nav.waits.NavigationUntil(ctx, step, click, resultsTableScript)
 	if err != nil {
 		logger.Error("❌ Erro ao clicar no botão de busca!")
 		return err
 	}
 	
-	logger.Info("✅ Busca realizada com sucesso! Aguardando resultados...")
-	
-	// PASSO 5: Aguarda resultados
-	time.Sleep(3 * time.Second)
-	
+	logger.Info("✅ Busca realizada com sucesso!")
 	return nil
 }
 
-// ClickFirstResult - clica no primeiro resultado da busca
-func (nav *CaixaSearchNavigator) ClickFirstResult(ctx context.Context) error {
-	logger.Info("🎯 Clicando no primeiro resultado...")
+// ListProposals - lê a tabela de resultados da busca (uma linha por proposta)
+func (nav *CaixaSearchNavigator) ListProposals(ctx context.Context) ([]Proposal, error) {
+	logger.Info("📋 Lendo propostas encontradas...")
 	
 	// PASSO 1: Busca iframe novamente (página pode ter recarregado)
 	logger.Info("📍 PASSO 1: Buscando iframe dos resultados...")
@@ -109,77 +199,77 @@ func (nav *CaixaSearchNavigator) ClickFirstResult(ctx context.Context) error {
 	
 	if err != nil {
 		logger.Error("❌ Iframe dos resultados não encontrado!")
-		return fmt.Errorf("iframe não encontrado: %w", err)
+		return nil, fmt.Errorf("iframe não encontrado: %w", err)
 	}
 	
 	logger.Info("✅ Iframe dos resultados encontrado!")
 	
 	// PASSO 2: Aguarda tabela de resultados aparecer
 	logger.Info("📍 PASSO 2: Aguardando tabela de resultados...")
+	waits.Dropped(ctx, waits.Step{Name: "tabela de resultados", Replaces: 2 * time.Second})
 	err = chromedp.Run(ctx,
-		chromedp.Sleep(2*time.Second),
 		chromedp.WaitVisible(`table.tb_lista`, chromedp.BySearch, chromedp.FromNode(iframeNode)),
 	)
 	
 	if err != nil {
 		logger.Error("❌ Tabela de resultados não encontrada!")
-		return fmt.Errorf("tabela de resultados não encontrada: %w", err)
+		return nil, fmt.Errorf("tabela de resultados não encontrada: %w", err)
 	}
 	
 	logger.Info("✅ Tabela de resultados encontrada!")
 	
-	// PASSO 3: Clica no primeiro link (número da proposta)
-	logger.Info("📍 PASSO 3: Clicando no primeiro resultado...")
-	
-	// XPath para o link com onclick="executa('localizarProposta.do..."
-	xpath := `//table[contains(@class, 'tb_lista')]//a[contains(@onclick, "localizarProposta.do")]`
-	
-	err = chromedp.Run(ctx,
-		chromedp.Sleep(1*time.Second),
-		chromedp.WaitVisible(xpath, chromedp.BySearch, chromedp.FromNode(iframeNode)),
-		chromedp.Click(xpath, chromedp.BySearch, chromedp.FromNode(iframeNode)),
-		chromedp.Sleep(5*time.Second),
-	)
+	// PASSO 3: Lê as linhas da tabela
+	logger.Info("📍 PASSO 3: Lendo linhas da tabela...")
+	var table *proposalTable
+	if err := chromedp.Run(ctx, chromedp.Evaluate(proposalTableScript, &table)); err != nil {
+		logger.Error("❌ Erro ao ler tabela de resultados!")
+		return nil, fmt.Errorf("erro ao ler tabela de resultados: %w", err)
+	}
+	if table == nil {
+		return nil, ErrNoProposals
+	}
 	
-	if err != nil {
-		logger.Error("❌ Erro ao clicar no resultado!")
-		return err
+	proposals := parseProposalTable(*table)
+	if len(proposals) == 0 {
+		logger.Error("❌ Nenhuma proposta na tabela!")
+		return nil, ErrNoProposals
 	}
 	
-	logger.Info("✅ Primeiro resultado clicado! Aguardando próxima página...")
-	return nil
+	for _, proposal := range proposals {
+		logger.Info(fmt.Sprintf("   📄 %s | %s | %s | %s", proposal.Number, proposal.Status, proposal.Date, proposal.Product))
+	}
+	logger.Info(fmt.Sprintf("✅ %d proposta(s) encontrada(s)!", len(proposals)))
+	return proposals, nil
 }
 
-// ExtractAgendamentoAssinatura - extrai data de agendamento de assinatura
-func (nav *CaixaSearchNavigator) ExtractAgendamentoAssinatura(ctx context.Context, iframeWaiter IframeWaiter) (string, error) {
-	logger.Info("📅 Extraindo data de agendamento de assinatura...")
+// ClickProposal - abre a proposta clicando no seu link da tabela de resultados
+func (nav *CaixaSearchNavigator) ClickProposal(ctx context.Context, proposal Proposal) error {
+	logger.Info(fmt.Sprintf("🎯 Abrindo proposta %s...", proposal.Number))
+	
+	iframeWaiter := NewIframeWaiter(nav.maxRetries, nav.timeouts)
+	iframeNode, err := iframeWaiter.WaitForIframe(ctx, "Resultados")
 	
-	// Aguarda iframe
-	iframeNode, err := iframeWaiter.WaitForIframe(ctx, "Proposta Selecionada")
 	if err != nil {
-		logger.Error("❌ Iframe não encontrado!")
-		return "", err
+		logger.Error("❌ Iframe dos resultados não encontrado!")
+		return fmt.Errorf("iframe não encontrado: %w", err)
 	}
 	
-	logger.Info("✅ Iframe encontrado! Procurando agendamento...")
-	
-	// XPath para data de agendamento
-	xpath := `//tr[.//label[contains(., 'Agendamento da Assinatura:')]]/td[@class='alinha_esquerda']`
+	// XPath para o N-ésimo link com onclick="executa('localizarProposta.do..."
+	xpath := fmt.Sprintf(`(//table[contains(@class, 'tb_lista')]//a[contains(@onclick, "localizarProposta.do")])[%d]`, proposal.Index+1)
 	
-	var agendamento string
-	
-	// IMPORTANTE: Precisa estar dentro de chromedp.Run()
 	err = chromedp.Run(ctx,
 		chromedp.WaitVisible(xpath, chromedp.BySearch, chromedp.FromNode(iframeNode)),
-		chromedp.Text(xpath, &agendamento, chromedp.BySearch, chromedp.FromNode(iframeNode)),
 	)
+	if err == nil {
+		step := waits.Step{Name: "proposta selecionada", Replaces: 6 * time.Second}
+		err = nav.waits.Navigation(ctx, step, chromedp.Click(xpath, chromedp.BySearch, chromedp.FromNode(iframeNode)))
+	}
 	
 	if err != nil {
-		logger.Error(fmt.Sprintf("❌ Erro ao extrair agendamento: %v", err))
-		return "", err
+		logger.Error("❌ Erro ao clicar na proposta!")
+		return err
 	}
 	
-	agendamento = strings.TrimSpace(agendamento)
-	logger.Info(fmt.Sprintf("✅ Agendamento: %s", agendamento))
-	return agendamento, nil
-}
\ No newline at end of file
+	logger.Info("✅ Proposta aberta!")
+	return nil
+}
@@ -3,10 +3,13 @@ package automation
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/chromedp/chromedp"
 	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/automation/extractors"
 	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/automation/navigation"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/automation/waits"
 	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/models"
 	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/pkg/logger"
 )
@@ -21,6 +24,11 @@ type Orchestrator struct {
 	menuNav          navigation.MenuNavigator
 	propertyNav      navigation.PropertyNavigator
 	dataCoordinator  *extractors.DataCoordinator
+	progress         ProgressReporter
+	failure          FailureReporter
+	stage            Stage         // Etapa em andamento (vai para as evidências de falha)
+	budget           time.Duration // Tempo total que limita a etapa em andamento (vai no StageTimeoutError)
+	searchURL        string        // Página pós-login (formulário de busca)
 }
 
 // NewOrchestrator - cria novo orquestrador
@@ -36,69 +44,245 @@ func NewOrchestrator(bot *CaixaBot) *Orchestrator {
 		participantsNav:  navigation.NewCaixaParticipantsNavigator(timeouts, maxRetries),
 		menuNav:          navigation.NewCaixaMenuNavigator(timeouts, maxRetries),
 		propertyNav:      navigation.NewCaixaPropertyNavigator(timeouts, maxRetries),
-		dataCoordinator:  extractors.NewDataCoordinator(),
+		dataCoordinator:  extractors.NewDataCoordinator(timeouts),
+		progress:         bot.progress,
+		failure:          bot.failure,
+		budget:           timeouts.RunBudget(),
 	}
 }
 
-func (o *Orchestrator) Execute(ctx context.Context, username, password, cpf string) (*models.ClientData, error) {
+func (o *Orchestrator) Execute(ctx context.Context, username, password string, query SearchQuery, selection models.ProposalSelection) ([]*models.ClientData, error) {
 	logger.Info("🚀 Iniciando processo de automação completo...")
 	logger.Info("========================================")
 	
 	// ETAPA 1: LOGIN
+	if err := o.Login(ctx, username, password); err != nil {
+		return nil, err
+	}
+	
+	return o.SearchAndExtract(ctx, query, selection)
+}
+
+// Login - faz login no portal (etapa 1)
+// Ao final o navegador fica na página de busca
+func (o *Orchestrator) Login(ctx context.Context, username, password string) (err error) {
+	recorder := o.recordBrowserErrors(ctx)
+	defer func() { o.captureFailure(ctx, recorder, err) }()
+	
 	logger.Info("ETAPA 1: LOGIN")
 	logger.Info("========================================")
-	if err := o.executeLogin(ctx, username, password); err != nil {
-		return nil, fmt.Errorf("erro no login: %w", err)
+	o.reportProgress(StageLogin, "Fazendo login no portal")
+	err = o.runStage(ctx, StageLogin, func(ctx context.Context) error {
+		if err := o.executeLogin(ctx, username, password); err != nil {
+			return fmt.Errorf("erro no login: %w", err)
+		}
+		
+		// Guarda a página de busca para voltar a ela (várias propostas ou sessões)
+		if err := chromedp.Run(ctx, chromedp.Location(&o.searchURL)); err != nil {
+			return fmt.Errorf("erro ao ler URL da página de busca: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 	logger.Info("✅ Login realizado com sucesso!")
-	
-	// ETAPA 2: BUSCA POR CPF
+	return nil
+}
+
+// ReturnToSearch - volta o navegador logado para a página de busca
+func (o *Orchestrator) ReturnToSearch(ctx context.Context) (err error) {
+	recorder := o.recordBrowserErrors(ctx)
+	defer func() { o.captureFailure(ctx, recorder, err) }()
+	return o.searchNav.ReturnToSearch(ctx, o.searchURL)
+}
+
+// SearchAndExtract - busca (CPF, CNPJ, proposta ou contrato) e extrai os dados (etapas 2 a 5)
+// Exige o navegador já logado e na página de busca. Retorna um ClientData por
+// proposta escolhida (uma só, exceto com ProposalAll).
+func (o *Orchestrator) SearchAndExtract(ctx context.Context, query SearchQuery, selection models.ProposalSelection) (_ []*models.ClientData, err error) {
+	recorder := o.recordBrowserErrors(ctx)
+	defer func() { o.captureFailure(ctx, recorder, err) }()
+	
+	// ETAPA 2: BUSCA
 	logger.Info("========================================")
-	logger.Info("ETAPA 2: BUSCA POR CPF")
+	logger.Info("ETAPA 2: BUSCA POR " + strings.ToUpper(query.String()))
 	logger.Info("========================================")
-	if err := o.executeSearch(ctx, cpf); err != nil {
+	o.reportProgress(StageSearch, "Buscando "+query.String())
+	var proposals []navigation.Proposal
+	err = o.runStage(ctx, StageSearch, func(ctx context.Context) error {
+		var err error
+		proposals, err = o.executeSearch(ctx, query, selection)
+		return err
+	})
+	if err != nil {
 		return nil, fmt.Errorf("erro na busca: %w", err)
 	}
 	logger.Info("✅ Busca concluída com sucesso!")
 	
-	// Cria clientData vazio
-	clientData := &models.ClientData{}
+	results := make([]*models.ClientData, 0, len(proposals))
+	var firstErr error
+	for i, proposal := range proposals {
+		clientData, err := o.extractSelectedProposal(ctx, query, selection, proposal, i, len(proposals))
+		if err != nil {
+			err = fmt.Errorf("proposta %s: %w", proposal.Number, err)
+			// Com ProposalAll as propostas já extraídas ficam; a que falhou vai com o erro
+			if selection != models.ProposalAll {
+				return nil, err
+			}
+			logger.Error("⚠️ Erro ao extrair " + err.Error())
+			if firstErr == nil {
+				firstErr = err
+			}
+			clientData = &models.ClientData{Proposta: proposal.Model(), Erro: err.Error()}
+		}
+		results = append(results, clientData)
+	}
+	
+	// Nenhuma proposta extraída: não há resultado parcial para devolver
+	if firstErr != nil && allFailed(results) {
+		return nil, firstErr
+	}
+	
+	logger.Info("========================================")
+	logger.Info("✅ AUTOMAÇÃO CONCLUÍDA COM SUCESSO!")
+	logger.Info("========================================")
+	o.reportProgress(StageDone, "Automação concluída")
+	
+	return results, nil
+}
+
+// extractSelectedProposal - extrai a proposta; com ProposalAll, dentro do prazo próprio dela
+func (o *Orchestrator) extractSelectedProposal(ctx context.Context, query SearchQuery, selection models.ProposalSelection, proposal navigation.Proposal, i, total int) (*models.ClientData, error) {
+	if selection == models.ProposalAll {
+		proposalCtx, cancel := o.withProposalDeadline(ctx)
+		defer cancel()
+		ctx = proposalCtx
+	}
+	return o.searchAndExtractProposal(ctx, query, proposal, i, total)
+}
+
+// searchAndExtractProposal - extrai a proposta i de total
+// A partir da segunda: refaz a busca para voltar à tabela de resultados
+func (o *Orchestrator) searchAndExtractProposal(ctx context.Context, query SearchQuery, proposal navigation.Proposal, i, total int) (*models.ClientData, error) {
+	if i > 0 {
+		logger.Info("========================================")
+		logger.Info(fmt.Sprintf("PROPOSTA %d/%d: %s", i+1, total, proposal.Number))
+		logger.Info("========================================")
+		o.reportProgress(StageSearch, fmt.Sprintf("Buscando %s (proposta %d/%d)", query, i+1, total))
+		err := o.runStage(ctx, StageSearch, func(ctx context.Context) error {
+			if err := o.searchNav.ReturnToSearch(ctx, o.searchURL); err != nil {
+				return err
+			}
+			if err := o.searchNav.Search(ctx, query); err != nil {
+				return fmt.Errorf("erro na busca: %w", err)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	
+	return o.extractProposal(ctx, proposal)
+}
+
+// allFailed - nenhuma proposta do resultado foi extraída
+func allFailed(results []*models.ClientData) bool {
+	for _, result := range results {
+		if result.Erro == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// extractProposal - abre a proposta na tabela de resultados e extrai os dados (etapas 3 a 5)
+func (o *Orchestrator) extractProposal(ctx context.Context, proposal navigation.Proposal) (*models.ClientData, error) {
+	var summary *models.ProposalSummary
+	err := o.runStage(ctx, StageSearch, func(ctx context.Context) error {
+		var err error
+		summary, err = o.openProposal(ctx, proposal)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	
+	// Cada etapa devolve o seu resultado parcial; o merger junta sem sobrescrever
+	merger := NewResultMerger()
+	merger.Add(StageSearch, proposalData(proposal, summary))
 	
 	// ETAPA 3: EXTRAÇÃO DE VALORES DA OPERAÇÃO (PRIMEIRO!)
 	logger.Info("========================================")
 	logger.Info("ETAPA 3: EXTRAÇÃO DE VALORES DA OPERAÇÃO")
 	logger.Info("========================================")
-	if err := o.extractFinancialData(ctx, clientData); err != nil {
+	o.reportProgress(StageFinancial, "Extraindo valores da operação")
+	var financialData *models.ClientData
+	err = o.runStage(ctx, StageFinancial, func(ctx context.Context) error {
+		var err error
+		financialData, err = o.extractFinancialData(ctx)
+		return err
+	})
+	if err != nil {
 		logger.Error("⚠️ Erro ao extrair dados financeiros: " + err.Error())
-		// Não retorna erro, continua
+		// Não retorna erro, continua (a não ser que o tempo total tenha acabado)
+		if ctx.Err() != nil {
+			return nil, err
+		}
 	}
+	merger.Add(StageFinancial, financialData)
 	
 	// ETAPA 4: EXTRAÇÃO DE DADOS DO PARTICIPANTE
 	logger.Info("========================================")
-	logger.Info("ETAPA 4: EXTRAÇÃO DE DADOS DO PARTICIPANTE")
+	logger.Info("ETAPA 4: EXTRAÇÃO DE DADOS DOS PARTICIPANTES")
 	logger.Info("========================================")
-	participantData, err := o.extractParticipantData(ctx)
+	o.reportProgress(StageParticipants, "Extraindo dados dos participantes")
+	var participantData *models.ClientData
+	err = o.runStage(ctx, StageParticipants, func(ctx context.Context) error {
+		var err error
+		participantData, err = o.extractParticipantData(ctx)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("erro ao extrair dados do participante: %w", err)
 	}
-	
-	// Merge dos dados
-	clientData = participantData
+	merger.Add(StageParticipants, participantData)
 	
 	// ETAPA 5: EXTRAÇÃO DE DADOS DO IMÓVEL
 	logger.Info("========================================")
 	logger.Info("ETAPA 5: EXTRAÇÃO DE DADOS DO IMÓVEL")
 	logger.Info("========================================")
-	if err := o.extractPropertyData(ctx, clientData); err != nil {
+	o.reportProgress(StageProperty, "Extraindo dados do imóvel")
+	var propertyData *models.ClientData
+	err = o.runStage(ctx, StageProperty, func(ctx context.Context) error {
+		var err error
+		propertyData, err = o.extractPropertyData(ctx)
+		return err
+	})
+	if err != nil {
 		logger.Error("⚠️ Erro ao extrair dados do imóvel: " + err.Error())
-		// Não retorna erro, continua
+		// Não retorna erro, continua (a não ser que o tempo total tenha acabado)
+		if ctx.Err() != nil {
+			return nil, err
+		}
 	}
+	merger.Add(StageProperty, propertyData)
 	
-	logger.Info("========================================")
-	logger.Info("✅ AUTOMAÇÃO CONCLUÍDA COM SUCESSO!")
-	logger.Info("========================================")
-	
-	return clientData, nil
+	if conflicts := merger.Conflicts(); len(conflicts) > 0 {
+		logger.Info(fmt.Sprintf("⚠️ %d conflito(s) entre etapas na proposta %s", len(conflicts), proposal.Number))
+	}
+	return merger.Result(), nil
+}
+
+// proposalData - resultado parcial da tabela de busca e da página "Proposta Selecionada"
+func proposalData(proposal navigation.Proposal, summary *models.ProposalSummary) *models.ClientData {
+	data := &models.ClientData{Proposta: proposal.Model()}
+	if summary != nil {
+		data.ResumoProposta = summary
+		data.AgendamentoAssinatura = summary.AgendamentoAssinaturaTexto
+	}
+	return data
 }
 
 // executeLogin - executa o processo de login
@@ -114,134 +298,206 @@ func (o *Orchestrator) executeLogin(ctx context.Context, username, password stri
 	return nil
 }
 
-// executeSearch - executa a busca por CPF
-func (o *Orchestrator) executeSearch(ctx context.Context, cpf string) error {
-	if err := o.searchNav.SearchByCPF(ctx, cpf); err != nil {
-		return err
+// executeSearch - faz a busca e escolhe as propostas a extrair
+func (o *Orchestrator) executeSearch(ctx context.Context, query SearchQuery, selection models.ProposalSelection) ([]navigation.Proposal, error) {
+	if err := o.searchNav.Search(ctx, query); err != nil {
+		return nil, err
 	}
 	
-	if err := o.searchNav.ClickFirstResult(ctx); err != nil {
-		return err
+	proposals, err := o.searchNav.ListProposals(ctx)
+	if err != nil {
+		return nil, err
 	}
 	
-	// Extrai agendamento de assinatura
-	agendamento, err := o.searchNav.ExtractAgendamentoAssinatura(ctx, o.iframeWaiter)
+	selected, err := navigation.SelectProposals(proposals, selection)
 	if err != nil {
-		logger.Error(fmt.Sprintf("⚠️ Erro ao extrair agendamento: %v", err))
-	} else {
-		logger.Info(fmt.Sprintf("✓ Agendamento: %s", agendamento))
+		return nil, err
+	}
+	logger.Info(fmt.Sprintf("🎯 %d de %d proposta(s) selecionada(s) (%s)", len(selected), len(proposals), selection))
+	return selected, nil
+}
+
+// openProposal - clica na proposta e lê o resumo da página "Proposta Selecionada"
+// Resumo ausente não impede a extração (retorna nil)
+func (o *Orchestrator) openProposal(ctx context.Context, proposal navigation.Proposal) (*models.ProposalSummary, error) {
+	if err := o.searchNav.ClickProposal(ctx, proposal); err != nil {
+		return nil, err
 	}
 	
-	return nil
+	if _, err := o.iframeWaiter.WaitForIframe(ctx, "Proposta Selecionada"); err != nil {
+		logger.Error(fmt.Sprintf("⚠️ Erro ao aguardar proposta selecionada: %v", err))
+		return nil, nil
+	}
+	
+	summary, err := o.dataCoordinator.ExtractProposalSummary(ctx)
+	if err != nil {
+		logger.Error(fmt.Sprintf("⚠️ Erro ao extrair resumo da proposta: %v", err))
+		return nil, nil
+	}
+	return summary, nil
 }
 
 // extractFinancialData - navega e extrai dados financeiros (PRIMEIRA ETAPA!)
-func (o *Orchestrator) extractFinancialData(ctx context.Context, clientData *models.ClientData) error {
+func (o *Orchestrator) extractFinancialData(ctx context.Context) (*models.ClientData, error) {
 	logger.Info("💰 Extraindo valores da operação...")
 	
 	// Navega para Valores da Operação
 	err := o.propertyNav.NavigateToFinancialValues(ctx, o.menuNav, o.iframeWaiter)
 	if err != nil {
-		return fmt.Errorf("erro ao navegar para valores da operação: %w", err)
+		return nil, fmt.Errorf("erro ao navegar para valores da operação: %w", err)
 	}
 	
 	logger.Info("✅ Navegou para Valores da Operação!")
-	
-	// Aguarda página carregar
-	time.Sleep(3 * time.Second)
+	waits.Dropped(ctx, waits.Step{Name: "página Valores da Operação", Replaces: 3 * time.Second})
 	
 	// Aguarda iframe da página de valores
 	iframeNode, err := o.iframeWaiter.WaitForIframe(ctx, "Valores da Operação")
 	if err != nil {
-		return fmt.Errorf("erro ao aguardar iframe: %w", err)
+		return nil, fmt.Errorf("erro ao aguardar iframe: %w", err)
 	}
 	
 	// Extrai dados financeiros
+	clientData := &models.ClientData{}
 	err = o.dataCoordinator.ExtractFinancialData(ctx, iframeNode, clientData)
 	if err != nil {
-		return fmt.Errorf("erro ao extrair valores: %w", err)
+		return clientData, fmt.Errorf("erro ao extrair valores: %w", err) // O que já foi lido vale
 	}
 	
 	logger.Info("✅ Valores da operação extraídos com sucesso!")
-	return nil
+	return clientData, nil
 }
 
-// extractParticipantData - extrai todos os dados do participante
+// extractParticipantData - extrai os dados de todos os participantes
+// O proponente preenche o ClientData; todos (inclusive ele) vão em Participants
 func (o *Orchestrator) extractParticipantData(ctx context.Context) (*models.ClientData, error) {
-	// Clica em "Ir Para" para abrir menu
-	if err := o.menuNav.ClickIrPara(ctx, o.iframeWaiter); err != nil {
-		return nil, fmt.Errorf("erro ao abrir menu: %w", err)
+	if err := o.openParticipantsPage(ctx); err != nil {
+		return nil, err
 	}
 	
-	// Clica em Participantes
-	if err := o.menuNav.ClickMenuOption(ctx, o.iframeWaiter, "Participantes", "participantePI"); err != nil {
+	rows, err := o.participantsNav.ListParticipants(ctx, o.iframeWaiter)
+	if err != nil {
 		return nil, err
 	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("nenhum participante encontrado na página Participantes")
+	}
 	
-	// Aguarda página carregar
-	time.Sleep(3 * time.Second)
+	proponent := proponentIndex(rows)
+	var clientData *models.ClientData
+	participants := make([]models.Participant, 0, len(rows))
+	for i, row := range rows {
+		// Volta para a lista a cada participante (o detalhe substitui a página)
+		if i > 0 {
+			if err := o.openParticipantsPage(ctx); err != nil {
+				return nil, err
+			}
+		}
+		
+		participant, data, err := o.extractParticipant(ctx, row)
+		if err != nil {
+			// Sem o proponente não há resultado; dos demais guarda o que a lista mostrou
+			if i == proponent {
+				return nil, err
+			}
+			logger.Error(fmt.Sprintf("⚠️ Erro ao extrair participante %s (%s): %v", row.RowID, row.Papel, err))
+			participant = &models.Participant{Papel: row.Papel, CPF: row.CPF, Nome: row.Nome, Erro: err.Error()}
+		}
+		if i == proponent {
+			clientData = data
+		}
+		participants = append(participants, *participant)
+	}
 	
-	// Busca iframe da página de participantes
-	iframeNode, err := o.iframeWaiter.WaitForIframe(ctx, "Participantes")
-	if err != nil {
-		return nil, err
+	if clientData == nil {
+		return nil, fmt.Errorf("dados do proponente não extraídos")
 	}
 	
-	// Extrai coobrigado
-	cpfCoobrigado, nomeCoobrigado, err := o.participantsNav.ExtractCoobrigado(ctx, o.iframeWaiter)
-	if err != nil {
-		logger.Error(fmt.Sprintf("⚠️ Erro ao extrair coobrigado: %v", err))
+	// Compatibilidade: coobrigado_cpf/coobrigado_nome vêm da segunda linha (Item2)
+	for _, row := range rows {
+		if row.RowID == "Item2" {
+			clientData.CoobrigadoCPF = row.CPF
+			clientData.CoobrigadoNome = row.Nome
+		}
 	}
+	clientData.Participants = participants
 	
-	// Clica no CPF do proponente
-	if err := o.participantsNav.ClickProponenteCPF(ctx, o.iframeWaiter); err != nil {
-		return nil, err
+	logger.Info(fmt.Sprintf("✅ Dados de %d participante(s) extraídos com sucesso!", len(participants)))
+	return clientData, nil
+}
+
+// proponentIndex - linha do proponente pelo papel lido na página
+// Sem nenhuma linha "Proponente", fica com o Item1 (ou a primeira linha)
+func proponentIndex(rows []navigation.ParticipantRow) int {
+	for i, row := range rows {
+		if strings.HasPrefix(strings.ToLower(strings.TrimSpace(row.Papel)), "proponente") {
+			return i
+		}
+	}
+	for i, row := range rows {
+		if row.RowID == "Item1" {
+			return i
+		}
+	}
+	return 0
+}
+
+// openParticipantsPage - abre a página Participantes pelo menu "Ir Para"
+func (o *Orchestrator) openParticipantsPage(ctx context.Context) error {
+	// Clica em "Ir Para" para abrir menu
+	if err := o.menuNav.ClickIrPara(ctx, o.iframeWaiter); err != nil {
+		return fmt.Errorf("erro ao abrir menu: %w", err)
 	}
 	
-	// Aguarda página carregar
-	time.Sleep(3 * time.Second)
+	// Clica em Participantes
+	if err := o.menuNav.ClickMenuOption(ctx, o.iframeWaiter, "Participantes", "participantePI"); err != nil {
+		return err
+	}
 	
-	// Busca iframe dos detalhes
-	iframeNode, err = o.iframeWaiter.WaitForIframe(ctx, "Detalhes Participante")
-	if err != nil {
-		return nil, err
+	// O clique no menu já esperou a página carregar
+	waits.Dropped(ctx, waits.Step{Name: "página Participantes", Replaces: 3 * time.Second})
+	return nil
+}
+
+// extractParticipant - abre o detalharParticipante da linha e roda os extratores
+func (o *Orchestrator) extractParticipant(ctx context.Context, row navigation.ParticipantRow) (*models.Participant, *models.ClientData, error) {
+	if err := o.participantsNav.ClickParticipant(ctx, o.iframeWaiter, row); err != nil {
+		return nil, nil, err
 	}
 	
-	// Extrai todos os dados do participante
-	clientData, err := o.dataCoordinator.ExtractAllParticipantData(ctx, iframeNode)
+	// O clique já esperou a página de detalhes carregar
+	waits.Dropped(ctx, waits.Step{Name: "página Detalhes Participante", Replaces: 3 * time.Second})
+	
+	// Busca iframe dos detalhes
+	iframeNode, err := o.iframeWaiter.WaitForIframe(ctx, "Detalhes Participante")
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	
-	// Adiciona dados do coobrigado
-	clientData.CoobrigadoCPF = cpfCoobrigado
-	clientData.CoobrigadoNome = nomeCoobrigado
-	
-	logger.Info("✅ Dados do participante extraídos com sucesso!")
-	return clientData, nil
+	return o.dataCoordinator.ExtractParticipant(ctx, iframeNode, row.Papel)
 }
 
 // extractPropertyData - navega e extrai dados do imóvel
-func (o *Orchestrator) extractPropertyData(ctx context.Context, clientData *models.ClientData) error {
+func (o *Orchestrator) extractPropertyData(ctx context.Context) (*models.ClientData, error) {
 	// Navega até página de imóvel
 	if err := o.propertyNav.NavigateToProperty(ctx, o.menuNav, o.iframeWaiter); err != nil {
-		return err
+		return nil, err
 	}
 	
-	// Aguarda página carregar
-	time.Sleep(3 * time.Second)
+	// O clique no menu já esperou a página carregar
+	waits.Dropped(ctx, waits.Step{Name: "página Imóvel", Replaces: 3 * time.Second})
 	
 	// Busca iframe
 	iframeNode, err := o.iframeWaiter.WaitForIframe(ctx, "Página Imóvel")
 	if err != nil {
-		return err
+		return nil, err
 	}
 	
 	// Extrai dados do imóvel
+	clientData := &models.ClientData{}
 	if err := o.dataCoordinator.ExtractPropertyData(ctx, iframeNode, clientData); err != nil {
-		return err
+		return clientData, err // O que já foi lido vale
 	}
 	
 	logger.Info("✅ Dados do imóvel extraídos com sucesso!")
-	return nil
+	return clientData, nil
 }
\ No newline at end of file
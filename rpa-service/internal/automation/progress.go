@@ -0,0 +1,34 @@
+package automation
+
+// Stage - etapa do fluxo de automação
+type Stage string
+
+const (
+	StageLogin        Stage = "login"
+	StageSearch       Stage = "search"
+	StageFinancial    Stage = "financial"
+	StageParticipants Stage = "participants"
+	StageProperty     Stage = "property"
+	StageDone         Stage = "done"
+)
+
+// stagePercent - percentual concluído ao INICIAR cada etapa
+var stagePercent = map[Stage]int{
+	StageLogin:        5,
+	StageSearch:       25,
+	StageFinancial:    45,
+	StageParticipants: 60,
+	StageProperty:     85,
+	StageDone:         100,
+}
+
+// ProgressReporter - recebe as transições de etapa do orquestrador
+type ProgressReporter func(stage Stage, percent int, message string)
+
+// reportProgress - notifica o reporter (se houver) da etapa atual
+func (o *Orchestrator) reportProgress(stage Stage, message string) {
+	if o.progress == nil {
+		return
+	}
+	o.progress(stage, stagePercent[stage], message)
+}
@@ -0,0 +1,408 @@
+package automation
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/automation/waits"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/browserpool"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/models"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/pkg/logger"
+)
+
+var (
+	// ErrSessionNotFound - token desconhecido (nunca existiu, expirou ou foi encerrado)
+	ErrSessionNotFound = errors.New("sessão não encontrada ou expirada")
+
+	// ErrTooManySessions - limite de navegadores abertos atingido
+	ErrTooManySessions = errors.New("limite de sessões abertas atingido")
+)
+
+// SessionConfig - configuração das sessões persistentes
+type SessionConfig struct {
+	IdleTimeout   time.Duration // Sessão sem uso por mais que isso é encerrada
+	MaxSessions   int           // Cada sessão é um Chrome aberto
+	SweepInterval time.Duration // Frequência da varredura de sessões ociosas
+}
+
+// DefaultSessionConfig - configuração padrão das sessões
+func DefaultSessionConfig() SessionConfig {
+	return SessionConfig{
+		IdleTimeout:   15 * time.Minute,
+		MaxSessions:   5,
+		SweepInterval: 30 * time.Second,
+	}
+}
+
+// Session - navegador logado no portal, reaproveitado entre buscas
+type Session struct {
+	Token     string
+	Username  string
+	CreatedAt time.Time
+
+	orchestrator *Orchestrator
+	browserCtx   context.Context
+	cancel       context.CancelFunc
+	pooled       bool // Chrome emprestado do pool (devolvido no Close)
+
+	mu       sync.Mutex // Uma busca por vez no mesmo navegador
+	lastUsed time.Time
+	searches int
+	closed   bool
+}
+
+// OpenSession - abre um Chrome e faz login, deixando o navegador na página de busca
+// O Chrome vive até Session.Close; cancelar o ctx só interrompe o login
+func (bot *CaixaBot) OpenSession(ctx context.Context, username, password string) (*Session, error) {
+	token, err := newSessionToken()
+	if err != nil {
+		return nil, err
+	}
+
+	// O Chrome é da sessão, não da requisição que a abriu
+	browserCtx, cancel, pooled, err := bot.openSessionBrowser()
+	if err != nil {
+		return nil, err
+	}
+	session := &Session{
+		Token:        token,
+		Username:     username,
+		CreatedAt:    time.Now(),
+		orchestrator: NewOrchestrator(bot),
+		browserCtx:   browserCtx,
+		cancel:       cancel,
+		pooled:       pooled,
+	}
+
+	if err := session.login(ctx, password); err != nil {
+		cancel()
+		return nil, err
+	}
+	return session, nil
+}
+
+// openSessionBrowser - Chrome da sessão (vive até Session.Close)
+// A sessão segura o Chrome por muito tempo: do pool só se houver um ocioso agora
+// (sem esperar as execuções curtas); senão abre um Chrome próprio
+func (bot *CaixaBot) openSessionBrowser() (context.Context, context.CancelFunc, bool, error) {
+	if bot.pool != nil {
+		if lease := bot.pool.TryAcquire(); lease != nil {
+			if err := startBrowser(lease.Ctx); err == nil {
+				return lease.Ctx, lease.Release, true, nil
+			}
+			lease.Release()
+		}
+	}
+
+	browserCtx, cancel := bot.createBrowserContext(context.Background())
+	if err := startBrowser(browserCtx); err != nil {
+		cancel()
+		return nil, nil, false, err
+	}
+	return browserCtx, cancel, false, nil
+}
+
+// Search - faz uma busca reaproveitando o navegador logado
+// Cancelar o ctx interrompe a busca, mas mantém a sessão aberta
+func (s *Session) Search(ctx context.Context, query SearchQuery, selection models.ProposalSelection) (*models.SearchResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, ErrSessionNotFound
+	}
+	defer func() { s.lastUsed = time.Now() }()
+
+	// Ações rodam no contexto do navegador; o ctx da requisição só interrompe
+	// Cada busca é limitada a Timeouts.RunBudget (com ProposalAll, cada proposta tem o seu prazo)
+	runCtx, stop := s.orchestrator.bot.withSearchDeadline(s.browserCtx, selection)
+	defer stop()
+	runCtx, stats := waits.WithStats(runCtx)
+	defer func() { logger.Info(stats.Summary()) }()
+	defer context.AfterFunc(ctx, stop)()
+
+	// Logo após o login o navegador já está na busca; depois disso precisa voltar
+	if s.searches > 0 {
+		if err := s.orchestrator.ReturnToSearch(runCtx); err != nil {
+			return &models.SearchResponse{Success: false, Message: err.Error()}, err
+		}
+	}
+	s.searches++
+
+	results, err := s.orchestrator.SearchAndExtract(runCtx, query, selection)
+	if err != nil {
+		return &models.SearchResponse{Success: false, Message: err.Error()}, err
+	}
+
+	return searchResponse(results, selection), nil
+}
+
+// SearchRelogin - Search que, se o portal derrubou o login, loga de novo e repete a busca
+// Para quem guarda a senha e reaproveita a sessão entre várias buscas (ex: lote do worker)
+func (s *Session) SearchRelogin(ctx context.Context, password string, query SearchQuery, selection models.ProposalSelection) (*models.SearchResponse, error) {
+	response, err := s.Search(ctx, query, selection)
+	if !IsSessionExpired(err) {
+		return response, err
+	}
+
+	logger.Info("🔁 Sessão do portal expirou, refazendo login...")
+	if err := s.Relogin(ctx, password); err != nil {
+		return &models.SearchResponse{Success: false, Message: err.Error()}, err
+	}
+	return s.Search(ctx, query, selection)
+}
+
+// Alive - o Chrome da sessão ainda está de pé
+func (s *Session) Alive() bool {
+	return s.browserCtx.Err() == nil
+}
+
+// Relogin - faz login de novo no mesmo Chrome (o portal derrubou a sessão)
+func (s *Session) Relogin(ctx context.Context, password string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrSessionNotFound
+	}
+	return s.login(ctx, password)
+}
+
+// SetProgressReporter - define quem recebe as etapas das próximas buscas
+func (s *Session) SetProgressReporter(reporter ProgressReporter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.orchestrator.progress = reporter
+}
+
+// SetFailureReporter - define quem recebe as evidências das próximas buscas que falharem
+func (s *Session) SetFailureReporter(reporter FailureReporter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.orchestrator.failure = reporter
+}
+
+// Close - encerra o Chrome da sessão (espera a busca em andamento terminar)
+func (s *Session) Close() {
+	s.cancel()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+}
+
+// login - faz login no Chrome da sessão (chamar com s.mu travado ou antes de publicar a sessão)
+func (s *Session) login(ctx context.Context, password string) error {
+	loginCtx, stop := s.orchestrator.bot.withRunDeadline(s.browserCtx)
+	defer stop()
+	loginCtx, stats := waits.WithStats(loginCtx)
+	defer func() { logger.Info(stats.Summary()) }()
+	defer context.AfterFunc(ctx, stop)()
+
+	if err := s.orchestrator.Login(loginCtx, s.Username, password); err != nil {
+		return err
+	}
+
+	s.searches = 0
+	s.lastUsed = time.Now()
+	return nil
+}
+
+// idleSince - momento do último uso (false se há uma busca em andamento)
+func (s *Session) idleSince() (time.Time, bool) {
+	if !s.mu.TryLock() {
+		return time.Time{}, false
+	}
+	defer s.mu.Unlock()
+	return s.lastUsed, true
+}
+
+// SessionManager - sessões persistentes (login uma vez, várias buscas)
+type SessionManager struct {
+	headless bool
+	config   SessionConfig
+	pool     *browserpool.Pool // Opcional: sessões usam Chromes ociosos do pool
+
+	mu       sync.Mutex
+	sessions map[string]*Session
+	opening  int // Logins em andamento (contam no limite)
+}
+
+// NewSessionManager - cria gerenciador com configuração padrão
+func NewSessionManager(headless bool) *SessionManager {
+	return NewSessionManagerWithConfig(headless, DefaultSessionConfig())
+}
+
+// NewSessionManagerWithConfig - cria gerenciador com configuração customizada
+func NewSessionManagerWithConfig(headless bool, config SessionConfig) *SessionManager {
+	return &SessionManager{
+		headless: headless,
+		config:   config,
+		sessions: make(map[string]*Session),
+	}
+}
+
+// SetBrowserPool - sessões passam a usar Chromes ociosos do pool
+// Uma sessão segura o Chrome até fechar (ou ficar ociosa por IdleTimeout), então as
+// sessões nunca ocupam o pool inteiro; sem Chrome livre, a sessão abre um próprio
+func (m *SessionManager) SetBrowserPool(pool *browserpool.Pool) {
+	m.pool = pool
+}
+
+// pooledSessions - sessões abertas com Chrome emprestado do pool
+func (m *SessionManager) pooledSessions() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	count := 0
+	for _, session := range m.sessions {
+		if session.pooled {
+			count++
+		}
+	}
+	return count
+}
+
+// Open - abre um Chrome, faz login e registra a sessão
+// O Chrome sobrevive à requisição; cancelar o ctx só interrompe o login
+func (m *SessionManager) Open(ctx context.Context, username, password string) (*Session, error) {
+	if err := m.reserve(); err != nil {
+		return nil, err
+	}
+	defer m.release()
+
+	bot := NewCaixaBot(m.headless)
+	if m.pool != nil && m.pooledSessions() < m.pool.Stats().Size-1 {
+		// Sempre sobra ao menos um Chrome do pool para as consultas síncronas e o worker
+		bot.SetBrowserPool(m.pool)
+	}
+
+	session, err := bot.OpenSession(ctx, username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	m.sessions[session.Token] = session
+	m.mu.Unlock()
+
+	logger.Info(fmt.Sprintf("🔐 Sessão aberta para %s (%d ativa(s))", username, m.Count()))
+	return session, nil
+}
+
+// Get - sessão pelo token
+func (m *SessionManager) Get(token string) (*Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, ok := m.sessions[token]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	return session, nil
+}
+
+// Close - encerra a sessão e o Chrome
+func (m *SessionManager) Close(token string) error {
+	m.mu.Lock()
+	session, ok := m.sessions[token]
+	delete(m.sessions, token)
+	m.mu.Unlock()
+
+	if !ok {
+		return ErrSessionNotFound
+	}
+
+	session.Close()
+	logger.Info(fmt.Sprintf("🔒 Sessão de %s encerrada", session.Username))
+	return nil
+}
+
+// Count - sessões abertas
+func (m *SessionManager) Count() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.sessions)
+}
+
+// Run - encerra sessões ociosas até o ctx ser cancelado
+func (m *SessionManager) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.config.SweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.closeIdle()
+		}
+	}
+}
+
+// closeIdle - encerra sessões sem uso há mais que IdleTimeout
+func (m *SessionManager) closeIdle() {
+	m.mu.Lock()
+	var expired []string
+	for token, session := range m.sessions {
+		// Sessão com busca em andamento não está ociosa
+		lastUsed, idle := session.idleSince()
+		if idle && time.Since(lastUsed) > m.config.IdleTimeout {
+			expired = append(expired, token)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, token := range expired {
+		logger.Info(fmt.Sprintf("⌛ Sessão ociosa há mais de %s, encerrando...", m.config.IdleTimeout))
+		m.Close(token)
+	}
+}
+
+// Shutdown - encerra todas as sessões (desligamento do servidor)
+func (m *SessionManager) Shutdown() {
+	m.mu.Lock()
+	sessions := m.sessions
+	m.sessions = make(map[string]*Session)
+	m.mu.Unlock()
+
+	for _, session := range sessions {
+		session.Close()
+	}
+	if len(sessions) > 0 {
+		logger.Info(fmt.Sprintf("🔒 %d sessão(ões) encerrada(s)", len(sessions)))
+	}
+}
+
+// reserve - reserva uma vaga para um novo Chrome
+func (m *SessionManager) reserve() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.config.MaxSessions > 0 && len(m.sessions)+m.opening >= m.config.MaxSessions {
+		return ErrTooManySessions
+	}
+	m.opening++
+	return nil
+}
+
+// release - libera a vaga do login (a sessão criada passa a ocupar a sua)
+func (m *SessionManager) release() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.opening--
+}
+
+// newSessionToken - token aleatório (256 bits, URL-safe)
+func newSessionToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("erro ao gerar token de sessão: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
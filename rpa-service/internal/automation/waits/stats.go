@@ -0,0 +1,73 @@
+package waits
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Stats - tempo gasto esperando numa execução, comparado com os sleeps fixos substituídos
+// Sleeps só removidos (Dropped) ficam à parte: sem espera medida, não entram na economia
+type Stats struct {
+	mu       sync.Mutex
+	steps    int
+	waited   time.Duration
+	replaced time.Duration
+
+	dropped      int
+	droppedSleep time.Duration
+}
+
+type statsKey struct{}
+
+// WithStats - passa a medir as esperas feitas com o ctx devolvido
+func WithStats(ctx context.Context) (context.Context, *Stats) {
+	stats := &Stats{}
+	return context.WithValue(ctx, statsKey{}, stats), stats
+}
+
+// Dropped - registra um sleep fixo removido sem espera no lugar
+// (a espera seguinte, ou a própria ação do chromedp, já cobre o que ele aguardava)
+func Dropped(ctx context.Context, step Step) {
+	stats, ok := ctx.Value(statsKey{}).(*Stats)
+	if !ok {
+		return
+	}
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+	stats.dropped++
+	stats.droppedSleep += step.Replaces
+}
+
+// record - soma a espera nas estatísticas do ctx (se houver)
+func record(ctx context.Context, step Step, elapsed time.Duration) {
+	stats, ok := ctx.Value(statsKey{}).(*Stats)
+	if !ok {
+		return
+	}
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+	stats.steps++
+	stats.waited += elapsed
+	stats.replaced += step.Replaces
+}
+
+// Saved - tempo economizado nas esperas medidas em relação aos sleeps fixos (negativo se esperou mais)
+func (s *Stats) Saved() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.replaced - s.waited
+}
+
+// Summary - resumo para o log
+func (s *Stats) Summary() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	summary := fmt.Sprintf("⏱️ Esperas: %d ponto(s), %s aguardando contra %s de sleeps fixos (%s economizados)",
+		s.steps, s.waited.Round(100*time.Millisecond), s.replaced, (s.replaced - s.waited).Round(100*time.Millisecond))
+	if s.dropped > 0 {
+		summary += fmt.Sprintf("; %d sleep(s) removido(s) sem espera no lugar (%s, não medidos)", s.dropped, s.droppedSleep)
+	}
+	return summary
+}
@@ -0,0 +1,260 @@
+package waits
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/automation/config"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/pkg/logger"
+)
+
+// ErrTimeout - a condição esperada não aconteceu dentro do prazo
+var ErrTimeout = errors.New("tempo de espera esgotado")
+
+// Intervalo entre verificações e silêncio de rede que conta como "ociosa"
+const (
+	pollInterval = 100 * time.Millisecond
+	idleQuiet    = 500 * time.Millisecond
+)
+
+// Step - ponto do fluxo onde se espera
+// Replaces é o sleep fixo que havia ali antes (base da medição do tempo economizado)
+type Step struct {
+	Name     string
+	Replaces time.Duration
+}
+
+// Waits - esperas por eventos da página no lugar de sleeps fixos
+type Waits struct {
+	timeouts config.Timeouts
+}
+
+// New - cria o kit de esperas com os timeouts configurados
+func New(timeouts config.Timeouts) *Waits {
+	return &Waits{timeouts: timeouts}
+}
+
+// Timeouts - timeouts usados pelas esperas
+func (w *Waits) Timeouts() config.Timeouts {
+	return w.timeouts
+}
+
+// frameScript - documento do iframe blank.jsp (ou o de cima, se não houver iframe)
+const frameScript = `const frame = document.querySelector('iframe[src="blank.jsp"]');
+	let doc = null, win = null;
+	try { doc = frame ? frame.contentDocument : null; win = frame ? frame.contentWindow : null; } catch (e) {}`
+
+// markScript - marca os documentos atuais; um documento novo não tem a marca
+const markScript = `(() => {
+	%s
+	window.__rpaNavToken = %q;
+	if (win) win.__rpaNavToken = %q;
+	return true;
+})()`
+
+// navigationScript - algum documento (de cima ou do iframe) foi trocado e já carregou
+const navigationScript = `(() => {
+	%s
+	const navigated = window.__rpaNavToken !== %q || (!!win && win.__rpaNavToken !== %q);
+	const ready = document.readyState === 'complete' && (!frame || (!!doc && doc.readyState === 'complete' && !!doc.body));
+	let done = false;
+	try { done = !!(%s); } catch (e) {}
+	return (navigated && ready) || done;
+})()`
+
+// frameReadyScript - o iframe blank.jsp existe e terminou de carregar
+const frameReadyScript = `(() => {
+	%s
+	return !!frame && !!doc && doc.readyState === 'complete' && !!doc.body;
+})()`
+
+// elementScript - o XPath encontra um elemento dentro do iframe (ou da página, sem iframe)
+const elementScript = `(() => {
+	%s
+	const target = doc || document;
+	return target.evaluate(%q, target, null, XPathResult.FIRST_ORDERED_NODE_TYPE, null).singleNodeValue !== null;
+})()`
+
+// Navigation - executa a ação (clique) e espera a página ou o iframe trocarem de documento,
+// carregarem e a rede ficar ociosa
+func (w *Waits) Navigation(ctx context.Context, step Step, action chromedp.Action) error {
+	return w.NavigationUntil(ctx, step, action, "false")
+}
+
+// NavigationUntil - como Navigation, mas também termina quando a expressão JS for verdadeira
+// (ex: mensagem de erro exibida sem recarregar a página)
+func (w *Waits) NavigationUntil(ctx context.Context, step Step, action chromedp.Action, doneExpr string) error {
+	start := time.Now()
+	token := fmt.Sprintf("%d", start.UnixNano())
+
+	tracker, stop := trackNetwork(ctx)
+	defer stop()
+
+	mark := fmt.Sprintf(markScript, frameScript, token, token)
+	if err := chromedp.Run(ctx, chromedp.Evaluate(mark, nil), action); err != nil {
+		return err
+	}
+
+	check := fmt.Sprintf(navigationScript, frameScript, token, token, doneExpr)
+	err := poll(ctx, w.timeouts.PageLoad, func(ctx context.Context) (bool, error) {
+		var done bool
+		if err := chromedp.Run(ctx, chromedp.Evaluate(check, &done)); err != nil {
+			// Contexto de execução destruído no meio da navegação: tenta de novo
+			return false, nil
+		}
+		return done, nil
+	})
+	if err == nil {
+		err = tracker.waitIdle(ctx, w.timeouts.NetworkIdle)
+	}
+	return finish(ctx, step, start, w.timeouts.PageLoad, err)
+}
+
+// NetworkIdle - espera a rede ficar sem requisições por um instante
+// Nunca falha por tempo: depois de Timeouts.NetworkIdle segue em frente
+func (w *Waits) NetworkIdle(ctx context.Context, step Step) error {
+	start := time.Now()
+	tracker, stop := trackNetwork(ctx)
+	defer stop()
+	return finish(ctx, step, start, w.timeouts.NetworkIdle, tracker.waitIdle(ctx, w.timeouts.NetworkIdle))
+}
+
+// FrameReady - espera o iframe blank.jsp existir e terminar de carregar
+func (w *Waits) FrameReady(ctx context.Context, step Step) error {
+	start := time.Now()
+	script := fmt.Sprintf(frameReadyScript, frameScript)
+	err := poll(ctx, w.timeouts.IframeWait, evaluateBool(script))
+	return finish(ctx, step, start, w.timeouts.IframeWait, err)
+}
+
+// ElementInFrame - espera o XPath aparecer dentro do iframe blank.jsp
+func (w *Waits) ElementInFrame(ctx context.Context, step Step, xpath string) error {
+	start := time.Now()
+	script := fmt.Sprintf(elementScript, frameScript, xpath)
+	err := poll(ctx, w.timeouts.ElementWait, evaluateBool(script))
+	return finish(ctx, step, start, w.timeouts.ElementWait, err)
+}
+
+// Until - espera uma condição qualquer (verificada a cada 100ms)
+func (w *Waits) Until(ctx context.Context, step Step, timeout time.Duration, check func(ctx context.Context) (bool, error)) error {
+	start := time.Now()
+	return finish(ctx, step, start, timeout, poll(ctx, timeout, check))
+}
+
+// evaluateBool - condição a partir de uma expressão JS booleana
+func evaluateBool(script string) func(ctx context.Context) (bool, error) {
+	return func(ctx context.Context) (bool, error) {
+		var ok bool
+		if err := chromedp.Run(ctx, chromedp.Evaluate(script, &ok)); err != nil {
+			return false, nil
+		}
+		return ok, nil
+	}
+}
+
+// poll - verifica a condição até ela valer, o prazo acabar ou o ctx ser cancelado (timeout 0 = só o ctx)
+func poll(ctx context.Context, timeout time.Duration, check func(ctx context.Context) (bool, error)) error {
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		ok, err := check(ctx)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-deadline:
+			return ErrTimeout
+		case <-ticker.C:
+		}
+	}
+}
+
+// finish - registra a espera e dá nome ao erro de tempo
+func finish(ctx context.Context, step Step, start time.Time, timeout time.Duration, err error) error {
+	elapsed := time.Since(start)
+	record(ctx, step, elapsed)
+
+	if errors.Is(err, ErrTimeout) {
+		logger.Error(fmt.Sprintf("⏱️ [%s] nada após %s", step.Name, timeout))
+		return fmt.Errorf("%s: %w (%s)", step.Name, ErrTimeout, timeout)
+	}
+	if err == nil {
+		logger.Info(fmt.Sprintf("⏱️ [%s] pronto em %s", step.Name, elapsed.Round(time.Millisecond)))
+	}
+	return err
+}
+
+// networkTracker - requisições em andamento na aba, pelos eventos do CDP
+// Só enxerga o que começou depois de criado (por isso Navigation cria antes do clique)
+type networkTracker struct {
+	mu       sync.Mutex
+	inflight map[network.RequestID]bool
+	last     time.Time
+}
+
+// trackNetwork - começa a acompanhar a rede; stop encerra o listener
+func trackNetwork(ctx context.Context) (*networkTracker, func()) {
+	tracker := &networkTracker{inflight: make(map[network.RequestID]bool), last: time.Now()}
+
+	listenCtx, stop := context.WithCancel(ctx)
+	chromedp.ListenTarget(listenCtx, func(ev interface{}) {
+		tracker.mu.Lock()
+		defer tracker.mu.Unlock()
+		switch ev := ev.(type) {
+		case *network.EventRequestWillBeSent:
+			tracker.inflight[ev.RequestID] = true
+		case *network.EventLoadingFinished:
+			delete(tracker.inflight, ev.RequestID)
+		case *network.EventLoadingFailed:
+			delete(tracker.inflight, ev.RequestID)
+		default:
+			return
+		}
+		tracker.last = time.Now()
+	})
+
+	// Sem o domínio Network habilitado os eventos não chegam (falha = espera só pelo silêncio)
+	_ = chromedp.Run(ctx, network.Enable())
+	return tracker, stop
+}
+
+// idle - nenhuma requisição pendente e nenhum evento de rede há pelo menos idleQuiet
+func (t *networkTracker) idle() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.inflight) == 0 && time.Since(t.last) >= idleQuiet
+}
+
+// waitIdle - espera a rede ociosa; passado o limite segue em frente (long polling não trava o fluxo)
+func (t *networkTracker) waitIdle(ctx context.Context, limit time.Duration) error {
+	err := poll(ctx, limit, func(context.Context) (bool, error) {
+		return t.idle(), nil
+	})
+	if errors.Is(err, ErrTimeout) {
+		t.mu.Lock()
+		pending := len(t.inflight)
+		t.mu.Unlock()
+		logger.Info(fmt.Sprintf("⚠️ Rede não ficou ociosa em %s (%d requisição(ões) pendente(s)), seguindo", limit, pending))
+		return nil
+	}
+	return err
+}
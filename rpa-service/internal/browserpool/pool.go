@@ -0,0 +1,312 @@
+package browserpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/chromedp"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/automation/config"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/pkg/logger"
+)
+
+// ErrPoolClosed - o pool foi encerrado (servidor/worker desligando)
+var ErrPoolClosed = errors.New("pool de navegadores encerrado")
+
+// healthTimeout - tempo máximo para o Chrome responder ao teste de vida
+const healthTimeout = 3 * time.Second
+
+// Config - configuração do pool de navegadores
+type Config struct {
+	Size         int           // Chromes mantidos abertos
+	MaxUses      int           // Execuções por Chrome antes de reciclar (0 = sem limite)
+	RestartDelay time.Duration // Espera entre tentativas de abrir um Chrome que falhou
+	Options      []chromedp.ExecAllocatorOption
+}
+
+// DefaultConfig - configuração padrão do pool
+func DefaultConfig(headless bool) Config {
+	return Config{
+		Size:         2,
+		MaxUses:      20,
+		RestartDelay: 5 * time.Second,
+		Options:      config.DefaultBrowserConfig(headless).Options,
+	}
+}
+
+// Stats - situação atual do pool
+type Stats struct {
+	Size     int `json:"size"`
+	Idle     int `json:"idle"`
+	InUse    int `json:"in_use"`
+	Recycled int `json:"recycled"` // Chromes reciclados desde o início (limite de usos ou queda)
+}
+
+// Pool - Chromes abertos e prontos, emprestados um por execução
+// Cada empréstimo roda num contexto anônimo (incognito) próprio: cookies e
+// sessão do portal não passam de uma execução para outra.
+type Pool struct {
+	config Config
+	idle   chan *pooledBrowser
+	done   chan struct{}
+
+	mu       sync.Mutex
+	closed   bool
+	browsers map[*pooledBrowser]bool // Todos os Chromes abertos (ociosos ou emprestados)
+	inUse    int
+	recycled int
+	nextID   int
+	wg       sync.WaitGroup
+}
+
+// pooledBrowser - um Chrome do pool
+type pooledBrowser struct {
+	id          int
+	rootCtx     context.Context // Contexto que alocou o Chrome
+	rootCancel  context.CancelFunc
+	allocCancel context.CancelFunc
+	uses        int
+}
+
+// New - cria o pool e começa a abrir os Chromes em segundo plano
+func New(cfg Config) *Pool {
+	if cfg.Size < 1 {
+		cfg.Size = 1
+	}
+	p := &Pool{
+		config:   cfg,
+		idle:     make(chan *pooledBrowser, cfg.Size),
+		done:     make(chan struct{}),
+		browsers: make(map[*pooledBrowser]bool),
+	}
+	for i := 0; i < cfg.Size; i++ {
+		p.warm()
+	}
+	logger.Info(fmt.Sprintf("🌐 Pool de navegadores: %d Chrome(s), reciclados a cada %d uso(s)", cfg.Size, cfg.MaxUses))
+	return p
+}
+
+// Acquire - empresta um Chrome (espera um ficar livre) com um contexto incognito novo
+// Devolva com Lease.Release ao terminar
+func (p *Pool) Acquire(ctx context.Context) (*Lease, error) {
+	for {
+		var b *pooledBrowser
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-p.done:
+			return nil, ErrPoolClosed
+		case b = <-p.idle:
+		}
+
+		if lease := p.lend(b); lease != nil {
+			return lease, nil
+		}
+	}
+}
+
+// TryAcquire - empresta um Chrome só se houver um ocioso agora (nil se todos estão emprestados)
+// Para quem segura o Chrome por muito tempo e não deve esperar as execuções curtas
+func (p *Pool) TryAcquire() *Lease {
+	for {
+		var b *pooledBrowser
+		select {
+		case <-p.done:
+			return nil
+		case b = <-p.idle:
+		default:
+			return nil
+		}
+
+		if lease := p.lend(b); lease != nil {
+			return lease
+		}
+	}
+}
+
+// lend - empresta o Chrome ocioso com um contexto incognito novo (nil se ele caiu)
+func (p *Pool) lend(b *pooledBrowser) *Lease {
+	// Chrome caiu enquanto estava ocioso: repõe e quem chamou pega o próximo
+	if !b.alive() {
+		logger.Error(fmt.Sprintf("⚠️ Chrome %d do pool não responde, reciclando", b.id))
+		p.recycle(b)
+		return nil
+	}
+
+	p.mu.Lock()
+	p.inUse++
+	p.mu.Unlock()
+
+	leaseCtx, leaseCancel := chromedp.NewContext(b.rootCtx, chromedp.WithNewBrowserContext())
+	return &Lease{Ctx: leaseCtx, pool: p, browser: b, cancel: leaseCancel}
+}
+
+// Stats - Chromes ociosos, emprestados e reciclados
+func (p *Pool) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return Stats{Size: p.config.Size, Idle: len(p.idle), InUse: p.inUse, Recycled: p.recycled}
+}
+
+// Close - fecha todos os Chromes (inclusive os emprestados)
+func (p *Pool) Close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	close(p.done)
+	browsers := make([]*pooledBrowser, 0, len(p.browsers))
+	for b := range p.browsers {
+		browsers = append(browsers, b)
+	}
+	p.browsers = make(map[*pooledBrowser]bool)
+	p.mu.Unlock()
+
+	for _, b := range browsers {
+		b.close()
+	}
+	p.wg.Wait()
+	logger.Info("🛑 Pool de navegadores encerrado")
+}
+
+// Lease - Chrome emprestado; Ctx é o contexto chromedp (incognito) da execução
+type Lease struct {
+	Ctx context.Context
+
+	pool    *Pool
+	browser *pooledBrowser
+	cancel  context.CancelFunc
+	once    sync.Once
+}
+
+// Release - fecha a aba e o contexto incognito e devolve o Chrome ao pool
+// Recicla o Chrome se ele atingiu MaxUses ou caiu durante a execução
+func (l *Lease) Release() {
+	l.once.Do(func() {
+		l.cancel()
+		l.pool.giveBack(l.browser)
+	})
+}
+
+// giveBack - devolve o Chrome emprestado (ou recicla)
+func (p *Pool) giveBack(b *pooledBrowser) {
+	p.mu.Lock()
+	p.inUse--
+	closed := p.closed
+	p.mu.Unlock()
+
+	if closed {
+		b.close()
+		return
+	}
+
+	b.uses++
+	switch {
+	case !b.alive():
+		logger.Error(fmt.Sprintf("⚠️ Chrome %d caiu durante a execução, reciclando", b.id))
+		p.recycle(b)
+	case p.config.MaxUses > 0 && b.uses >= p.config.MaxUses:
+		logger.Info(fmt.Sprintf("♻️ Chrome %d atingiu %d uso(s), reciclando", b.id, b.uses))
+		p.recycle(b)
+	default:
+		p.idle <- b
+	}
+}
+
+// recycle - fecha o Chrome e abre outro no lugar
+func (p *Pool) recycle(b *pooledBrowser) {
+	p.mu.Lock()
+	delete(p.browsers, b)
+	p.recycled++
+	p.mu.Unlock()
+
+	b.close()
+	p.warm()
+}
+
+// warm - abre um Chrome em segundo plano e o deixa ocioso no pool
+// Se o Chrome não abre, tenta de novo a cada RestartDelay até o pool fechar
+func (p *Pool) warm() {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		for {
+			b, err := p.launch()
+			if err == nil {
+				p.mu.Lock()
+				if p.closed {
+					p.mu.Unlock()
+					b.close()
+					return
+				}
+				p.browsers[b] = true
+				p.mu.Unlock()
+
+				logger.Info(fmt.Sprintf("✅ Chrome %d do pool pronto", b.id))
+				p.idle <- b
+				return
+			}
+
+			if errors.Is(err, ErrPoolClosed) {
+				return
+			}
+			logger.Error(fmt.Sprintf("❌ Erro ao abrir Chrome do pool: %v", err))
+			select {
+			case <-p.done:
+				return
+			case <-time.After(p.config.RestartDelay):
+			}
+		}
+	}()
+}
+
+// launch - abre um Chrome
+// O primeiro chromedp.Run é feito no contexto raiz (sem prazo): o Chrome fica
+// preso a ele, e não ao contexto de quem pegar o empréstimo.
+func (p *Pool) launch() (*pooledBrowser, error) {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil, ErrPoolClosed
+	}
+	p.nextID++
+	id := p.nextID
+	p.mu.Unlock()
+
+	allocCtx, allocCancel := chromedp.NewExecAllocator(context.Background(), p.config.Options...)
+	rootCtx, rootCancel := chromedp.NewContext(allocCtx)
+	if err := chromedp.Run(rootCtx); err != nil {
+		rootCancel()
+		allocCancel()
+		return nil, err
+	}
+
+	return &pooledBrowser{id: id, rootCtx: rootCtx, rootCancel: rootCancel, allocCancel: allocCancel}, nil
+}
+
+// alive - o Chrome responde a comandos
+func (b *pooledBrowser) alive() bool {
+	if b.rootCtx.Err() != nil {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(b.rootCtx, healthTimeout)
+	defer cancel()
+	err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		_, _, _, _, _, err := browser.GetVersion().Do(cdp.WithExecutor(ctx, chromedp.FromContext(ctx).Browser))
+		return err
+	}))
+	return err == nil
+}
+
+// close - encerra o Chrome
+func (b *pooledBrowser) close() {
+	b.rootCancel()
+	b.allocCancel()
+}
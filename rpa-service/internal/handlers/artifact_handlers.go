@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/artifacts"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/pkg/logger"
+)
+
+// SetArtifacts - habilita o download das evidências de falha (GET /api/jobs/{id}/artifacts)
+func (h *JobHandler) SetArtifacts(store *artifacts.Store) {
+	h.artifacts = store
+}
+
+// GetJobArtifacts - evidências da última falha do job em .zip (GET /api/jobs/{id}/artifacts)
+// screenshot.png, page.html, iframe.html e info.json (URL, erro, console e rede)
+// Não consulta a fila: o pacote vale pelo mesmo prazo do job (artifacts.Retention) e some quando o job conclui
+func (h *JobHandler) GetJobArtifacts(w http.ResponseWriter, r *http.Request) {
+	jobID := mux.Vars(r)["id"]
+
+	if h.artifacts == nil {
+		writeError(w, http.StatusNotFound, "Evidências de falha desabilitadas")
+		return
+	}
+
+	// Monta o zip inteiro antes de responder: erro no meio ainda vira 500
+	var archive bytes.Buffer
+	err := h.artifacts.WriteZip(jobID, &archive)
+	if errors.Is(err, artifacts.ErrNotFound) {
+		writeError(w, http.StatusNotFound, "Nenhuma evidência de falha para o job")
+		return
+	}
+	if err != nil {
+		logger.Error(fmt.Sprintf("❌ Erro ao ler evidências do job %s: %v", jobID, err))
+		writeError(w, http.StatusInternalServerError, "Erro ao ler evidências do job")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="job-%s-artifacts.zip"`, jobID))
+	w.Header().Set("Content-Length", strconv.Itoa(archive.Len()))
+	w.WriteHeader(http.StatusOK)
+	archive.WriteTo(w)
+}
@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/automation"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/models"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/queue"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/pkg/documents"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/pkg/logger"
+)
+
+const (
+	maxBatchSize   = 500      // CPFs por lote
+	maxUploadBytes = 1 << 20  // 1 MB de CSV
+	maxFormBytes   = 64 << 10 // Demais campos do multipart e cabeçalhos das partes
+)
+
+// CreateBatch - cria um lote de jobs (POST /api/batches)
+// Aceita JSON (models.BatchRequest) ou multipart com username, password e file (CSV)
+func (h *JobHandler) CreateBatch(w http.ResponseWriter, r *http.Request) {
+	req, err := parseBatchRequest(w, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if req.Username == "" || req.Password == "" {
+		writeError(w, http.StatusBadRequest, "username e password são obrigatórios")
+		return
+	}
+	if req.MaxAttempts < 0 || req.MaxAttempts > maxJobAttempts {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("max_attempts deve estar entre 1 e %d (0 ou ausente usa o padrão)", maxJobAttempts))
+		return
+	}
+
+	if err := h.validateCallbackURL(req.CallbackURL); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	selection, err := automation.ParseProposalSelection(req.ProposalSelection)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	cpfs, invalid := normalizeCPFs(req.CPFs)
+	if len(invalid) > 0 {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("CPFs inválidos: %s", strings.Join(invalid, ", ")))
+		return
+	}
+	if len(cpfs) == 0 {
+		writeError(w, http.StatusBadRequest, "nenhum CPF informado")
+		return
+	}
+	if len(cpfs) > maxBatchSize {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("lote excede o limite de %d CPFs", maxBatchSize))
+		return
+	}
+
+	// Usuário bloqueado por senha errada: recusa o lote inteiro antes de criar os jobs
+	if block, err := h.queue.GetLoginBlock(req.Username); err != nil {
+		logger.Error("⚠️ Erro ao consultar bloqueio de login: " + err.Error())
+	} else if block != nil {
+		writeLoginBlocked(w, &queue.LoginBlockedError{Block: block})
+		return
+	}
+
+	batch, err := h.queue.AddBatch(req.Username, req.Password, cpfs, queue.JobOptions{
+		MaxAttempts:  req.MaxAttempts,
+		ForceRefresh: req.ForceRefresh,
+		CallbackURL:  req.CallbackURL,
+
+		ProposalSelection: selection,
+	})
+	if err != nil {
+		logger.Error("❌ Erro ao criar lote: " + err.Error())
+		writeError(w, http.StatusInternalServerError, "Erro ao criar lote")
+		return
+	}
+
+	logger.Info(fmt.Sprintf("📦 Lote %s criado com %d CPFs", batch.ID, len(batch.JobIDs)))
+
+	// CPFs servidos do cache já terminaram: histórico e callbacks saem daqui
+	for _, jobID := range batch.JobIDs {
+		if job, err := h.queue.GetJobStatus(jobID); err == nil && job.Cached {
+			h.finishCached(job)
+		}
+	}
+
+	statusURL := fmt.Sprintf("/api/batches/%s", batch.ID)
+	w.Header().Set("Location", statusURL)
+	writeJSON(w, http.StatusAccepted, models.BatchSubmitResponse{
+		BatchID:   batch.ID,
+		Total:     len(batch.JobIDs),
+		JobIDs:    batch.JobIDs,
+		Message:   "Lote adicionado na fila",
+		StatusURL: statusURL,
+	})
+}
+
+// GetBatch - progresso agregado e resultados do lote (GET /api/batches/{id})
+func (h *JobHandler) GetBatch(w http.ResponseWriter, r *http.Request) {
+	batchID := mux.Vars(r)["id"]
+
+	batch, jobs, err := h.queue.GetBatch(batchID)
+	if errors.Is(err, queue.ErrBatchNotFound) {
+		writeError(w, http.StatusNotFound, "Lote não encontrado")
+		return
+	}
+	if err != nil {
+		logger.Error(fmt.Sprintf("❌ Erro ao buscar lote %s: %v", batchID, err))
+		writeError(w, http.StatusInternalServerError, "Erro ao buscar lote")
+		return
+	}
+
+	response := models.BatchStatusResponse{
+		BatchID:   batch.ID,
+		Username:  batch.Username,
+		Total:     len(batch.JobIDs),
+		Jobs:      make([]models.JobStatusResponse, 0, len(jobs)),
+		CreatedAt: batch.CreatedAt,
+	}
+
+	for _, job := range jobs {
+		switch job.Status {
+		case queue.StatusPending, queue.StatusRetrying:
+			response.Progress.Pending++
+		case queue.StatusProcessing:
+			response.Progress.Processing++
+		case queue.StatusCompleted:
+			response.Progress.Completed++
+		case queue.StatusFailed:
+			response.Progress.Failed++
+		case queue.StatusCancelled:
+			response.Progress.Cancelled++
+		}
+
+		jobResponse, err := toJobStatusResponse(job)
+		if err != nil {
+			logger.Error(fmt.Sprintf("❌ Resultado inválido no job %s: %v", job.ID, err))
+			continue
+		}
+		response.Jobs = append(response.Jobs, *jobResponse)
+	}
+
+	// Jobs cujo registro expirou no Redis
+	response.Progress.Expired = response.Total - len(jobs)
+	response.Done = response.Progress.Pending == 0 && response.Progress.Processing == 0
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// parseBatchRequest - lê o lote em JSON ou em upload CSV (multipart)
+func parseBatchRequest(w http.ResponseWriter, r *http.Request) (*models.BatchRequest, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	if mediaType != "multipart/form-data" {
+		var req models.BatchRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return nil, errors.New("Invalid request body")
+		}
+		return &req, nil
+	}
+
+	// ParseMultipartForm só limita a memória (o excesso vai para disco): o corpo é limitado aqui
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadBytes+maxFormBytes)
+	if err := r.ParseMultipartForm(maxUploadBytes); err != nil {
+		return nil, fmt.Errorf("upload inválido: %v", err)
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		return nil, errors.New("arquivo CSV (campo 'file') é obrigatório")
+	}
+	defer file.Close()
+
+	cpfs, err := readCPFsFromCSV(io.LimitReader(file, maxUploadBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	req := &models.BatchRequest{
+		Username:     r.FormValue("username"),
+		Password:     r.FormValue("password"),
+		CPFs:         cpfs,
+		ForceRefresh: r.FormValue("force_refresh") == "true",
+		CallbackURL:  r.FormValue("callback_url"),
+
+		ProposalSelection: strings.TrimSpace(r.FormValue("proposal_selection")),
+	}
+	if value := strings.TrimSpace(r.FormValue("max_attempts")); value != "" {
+		maxAttempts, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("max_attempts inválido: %q", value)
+		}
+		req.MaxAttempts = maxAttempts
+	}
+
+	return req, nil
+}
+
+// readCPFsFromCSV - lê a coluna "cpf" (ou a primeira coluna, se não houver cabeçalho)
+func readCPFsFromCSV(reader io.Reader) ([]string, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("CSV inválido: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	// Excel pt-BR separa com ";": o registro inteiro vem em uma coluna só
+	if len(records[0]) == 1 && strings.Contains(records[0][0], ";") {
+		for i, record := range records {
+			records[i] = strings.Split(record[0], ";")
+		}
+	}
+
+	column := 0
+	start := 0
+	for i, header := range records[0] {
+		if strings.EqualFold(strings.TrimSpace(header), "cpf") {
+			column = i
+			start = 1
+			break
+		}
+	}
+
+	cpfs := make([]string, 0, len(records))
+	for _, record := range records[start:] {
+		if column < len(record) && strings.TrimSpace(record[column]) != "" {
+			cpfs = append(cpfs, record[column])
+		}
+	}
+
+	return cpfs, nil
+}
+
+// normalizeCPFs - valida, remove formatação e duplicados
+func normalizeCPFs(values []string) (cpfs []string, invalid []string) {
+	seen := make(map[string]bool, len(values))
+
+	for _, value := range values {
+		cpf, err := documents.NormalizeCPF(value)
+		if err != nil {
+			invalid = append(invalid, value)
+			continue
+		}
+		if seen[cpf] {
+			continue
+		}
+		seen[cpf] = true
+		cpfs = append(cpfs, cpf)
+	}
+
+	return cpfs, invalid
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/queue"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/pkg/logger"
+)
+
+// sseKeepAlive - intervalo do comentário de keep-alive (proxies derrubam conexões ociosas)
+const sseKeepAlive = 15 * time.Second
+
+// StreamJobEvents - transmite o progresso do job via Server-Sent Events (GET /api/jobs/{id}/events)
+func (h *JobHandler) StreamJobEvents(w http.ResponseWriter, r *http.Request) {
+	jobID := mux.Vars(r)["id"]
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "Streaming não suportado")
+		return
+	}
+
+	// Inscreve ANTES de ler o estado atual para não perder eventos entre as duas etapas
+	events, err := h.queue.SubscribeEvents(r.Context(), jobID)
+	if err != nil {
+		logger.Error(fmt.Sprintf("❌ Erro ao assinar eventos do job %s: %v", jobID, err))
+		writeError(w, http.StatusInternalServerError, "Erro ao assinar eventos")
+		return
+	}
+
+	job, err := h.queue.GetJobStatus(jobID)
+	if errors.Is(err, queue.ErrJobNotFound) {
+		writeError(w, http.StatusNotFound, "Job não encontrado")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Erro ao buscar job")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // Nginx: não bufferizar
+	w.WriteHeader(http.StatusOK)
+
+	// Primeiro evento: estado atual (o cliente pode ter chegado no meio do job)
+	current := queue.NewJobEvent(job)
+	writeSSEEvent(w, current)
+	flusher.Flush()
+	if current.IsFinal() {
+		return
+	}
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			writeSSEEvent(w, event)
+			flusher.Flush()
+			if event.IsFinal() {
+				return
+			}
+		}
+	}
+}
+
+// writeSSEEvent - escreve um evento no formato SSE
+// "progress" para eventos intermediários e "done" para o status final
+func writeSSEEvent(w http.ResponseWriter, event queue.JobEvent) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+
+	eventType := "progress"
+	if event.IsFinal() {
+		eventType = "done"
+	}
+
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", eventType, payload)
+}
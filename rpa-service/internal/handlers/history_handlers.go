@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/history"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/models"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/queue"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/pkg/logger"
+)
+
+// HistoryHandler - consultas ao histórico durável (página /historico)
+type HistoryHandler struct {
+	repo history.Repository
+}
+
+// NewHistoryHandler - cria um novo handler de histórico
+func NewHistoryHandler(repo history.Repository) *HistoryHandler {
+	return &HistoryHandler{
+		repo: repo,
+	}
+}
+
+// SearchHistory - consulta o histórico (GET /api/history)
+// Filtros: cpf, search_value, search_type, contract, status, username, from, to, limit, offset
+func (h *HistoryHandler) SearchHistory(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseHistoryFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, err := h.repo.Search(*filter)
+	if err != nil {
+		logger.Error("❌ Erro ao consultar histórico: " + err.Error())
+		writeError(w, http.StatusInternalServerError, "Erro ao consultar histórico")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, page)
+}
+
+// GetHistoryRecord - detalhes de uma consulta, com o ClientData (GET /api/history/{id})
+func (h *HistoryHandler) GetHistoryRecord(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	record, err := h.repo.Get(id)
+	if errors.Is(err, history.ErrRecordNotFound) {
+		writeError(w, http.StatusNotFound, "Consulta não encontrada")
+		return
+	}
+	if err != nil {
+		logger.Error(fmt.Sprintf("❌ Erro ao buscar consulta %s: %v", id, err))
+		writeError(w, http.StatusInternalServerError, "Erro ao buscar consulta")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, record)
+}
+
+// parseHistoryFilter - lê os filtros da query string
+func parseHistoryFilter(r *http.Request) (*history.Filter, error) {
+	query := r.URL.Query()
+
+	filter := &history.Filter{
+		CPF:            query.Get("cpf"),
+		SearchValue:    query.Get("search_value"),
+		SearchType:     strings.ToLower(query.Get("search_type")),
+		ContractNumber: query.Get("contract"),
+		Status:         query.Get("status"),
+		Username:       query.Get("username"),
+	}
+
+	switch models.SearchType(filter.SearchType) {
+	case "", models.SearchTypeCPF, models.SearchTypeCNPJ, models.SearchTypeProposal, models.SearchTypeContract:
+	default:
+		return nil, fmt.Errorf("search_type inválido: %q (use cpf, cnpj, proposal ou contract)", filter.SearchType)
+	}
+
+	if filter.Status != "" && !queue.ValidStatus(filter.Status) {
+		return nil, fmt.Errorf("status inválido: %q", filter.Status)
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > history.MaxLimit {
+			return nil, fmt.Errorf("limit deve estar entre 1 e %d", history.MaxLimit)
+		}
+		filter.Limit = limit
+	}
+
+	if value := query.Get("offset"); value != "" {
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return nil, errors.New("offset inválido")
+		}
+		filter.Offset = offset
+	}
+
+	var err error
+	if filter.From, err = parseTimeParam(query.Get("from"), false); err != nil {
+		return nil, fmt.Errorf("from inválido: %v", err)
+	}
+	if filter.To, err = parseTimeParam(query.Get("to"), true); err != nil {
+		return nil, fmt.Errorf("to inválido: %v", err)
+	}
+
+	return filter, nil
+}
@@ -1,26 +1,67 @@
 package handlers
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
 
 	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/automation"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/browserpool"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/history"
 	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/models"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/queue"
 	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/pkg/logger"
 )
 
 // Handler - gerencia as requisições HTTP
 type Handler struct {
 	headless bool
+	cache    queue.ResultCache
+	inflight *inflightGroup
+	history  history.Repository
+	sessions *automation.SessionManager
+	guard    queue.LoginGuard
+	pool     *browserpool.Pool
 }
 
 // NewHandler - cria um novo handler
-func NewHandler(headless bool) *Handler {
+// resultCache guarda o resultado por CPF (o mesmo cache usado pela fila)
+func NewHandler(headless bool, resultCache queue.ResultCache) *Handler {
 	return &Handler{
 		headless: headless,
+		cache:    resultCache,
+		inflight: newInflightGroup(),
 	}
 }
 
+// SetHistory - grava cada consulta síncrona no histórico durável
+func (h *Handler) SetHistory(repo history.Repository) {
+	h.history = repo
+}
+
+// SetLoginGuard - recusa logins de usuários bloqueados e conta as falhas de credencial
+func (h *Handler) SetLoginGuard(guard queue.LoginGuard) {
+	h.guard = guard
+}
+
+// SetSessions - habilita a API de sessões (/api/login, /api/search, /api/logout)
+func (h *Handler) SetSessions(sessions *automation.SessionManager) {
+	h.sessions = sessions
+}
+
+// SetBrowserPool - executa as consultas síncronas nos Chromes do pool
+func (h *Handler) SetBrowserPool(pool *browserpool.Pool) {
+	h.pool = pool
+}
+
 // LoginAndSearch - endpoint para login e busca
 func (h *Handler) LoginAndSearch(w http.ResponseWriter, r *http.Request) {
 	var req models.LoginAndSearchRequest
@@ -30,21 +71,74 @@ func (h *Handler) LoginAndSearch(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
+	query, err := searchQuery(req.SearchType, req.SearchValue, req.CPF)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	selection, err := automation.ParseProposalSelection(req.ProposalSelection)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	
 	logger.Info("📥 Nova requisição recebida")
 	logger.Info("👤 Usuário: " + req.Username)
-	logger.Info("🔍 CPF: " + req.CPF)
+	logger.Info("🔍 Busca: " + query.String())
 	
-	// Cria bot para cada requisição (com headless configurável)
-	bot := automation.NewCaixaBot(h.headless)
+	w.Header().Set("Content-Type", "application/json")
 	
-	// Executa automação
-	response, err := bot.LoginAndSearch(req.Username, req.Password, req.CPF)
+	// Usuário bloqueado por senha errada: não arrisca bloquear a conta no portal (nem serve o cache)
+	if blockedErr := h.loginBlocked(req.Username); blockedErr != nil {
+		writeLoginBlocked(w, blockedErr)
+		return
+	}
 	
-	w.Header().Set("Content-Type", "application/json")
+	// Consulta recente do mesmo CPF feita com as mesmas credenciais: responde sem abrir o Chrome
+	// (o cache guarda só a primeira proposta)
+	credential := h.credentialTag(req.Username, req.Password)
+	if !req.ForceRefresh && cacheable(query, selection) {
+		if cached := h.cachedResult(req.Username, query.Value); cached != nil && cached.IssuedTo(credential) {
+			if response := cachedResponse(cached); response != nil {
+				logger.Info("💾 Resultado servido do cache")
+				h.recordHistory(req.Username, query, time.Now(), response, nil)
+				w.WriteHeader(http.StatusOK)
+				json.NewEncoder(w).Encode(response)
+				return
+			}
+		}
+	}
+	
+	// Requisições simultâneas da mesma busca, com as mesmas credenciais, compartilham uma única execução
+	// Contexto da requisição: se todos os clientes desconectarem, o Chrome é encerrado
+	startedAt := time.Now()
+	response, shared, err := h.inflight.Do(r.Context(), inflightKey(req.Username, req.Password, query, selection), func(ctx context.Context) (*models.SearchResponse, error) {
+		// Cria bot para cada execução (com headless configurável)
+		bot := automation.NewCaixaBot(h.headless)
+		bot.SetBrowserPool(h.pool)
+		
+		// Executa automação
+		response, err := bot.LoginAndSearch(ctx, req.Username, req.Password, query, selection)
+		h.recordLoginResult(req.Username, err)
+		if err == nil && cacheable(query, selection) {
+			h.cacheResponse(req.Username, credential, query.Value, response)
+		}
+		return response, err
+	})
+	if shared {
+		logger.Info("🔗 Busca já estava em andamento: resultado compartilhado")
+	}
+	
+	// Cada requisição vira uma consulta no histórico (inclusive as que aguardaram a execução de outra)
+	h.recordHistory(req.Username, query, startedAt, response, err)
 	
 	if err != nil {
 		logger.Error("❌ Erro na automação: " + err.Error())
-		w.WriteHeader(http.StatusInternalServerError)
+		if response == nil {
+			response = &models.SearchResponse{Success: false, Message: err.Error()}
+		}
+		response.ErrorCode = automation.ErrorCode(err)
+		w.WriteHeader(automationStatus(err))
 		json.NewEncoder(w).Encode(response)
 		return
 	}
@@ -54,6 +148,188 @@ func (h *Handler) LoginAndSearch(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// cachedResult - resultado recente do CPF consultado pelo usuário (nil se não houver)
+// Quem chama decide se o usuário provou o login (sessão aberta ou CachedResult.IssuedTo)
+func (h *Handler) cachedResult(username, cpf string) *queue.CachedResult {
+	if h.cache == nil {
+		return nil
+	}
+	
+	cached, err := h.cache.GetCachedResult(username, cpf)
+	if err != nil {
+		if !errors.Is(err, queue.ErrCacheMiss) {
+			logger.Error("⚠️ Erro ao ler cache de resultados: " + err.Error())
+		}
+		return nil
+	}
+	return cached
+}
+
+// credentialTag - impressão digital das credenciais (vazia sem cache)
+func (h *Handler) credentialTag(username, password string) string {
+	if h.cache == nil {
+		return ""
+	}
+	return h.cache.CredentialTag(username, password)
+}
+
+// cachedResponse - resposta montada a partir do cache (nil se o resultado guardado for inválido)
+func cachedResponse(cached *queue.CachedResult) *models.SearchResponse {
+	var data models.ClientData
+	if err := json.Unmarshal([]byte(cached.Result), &data); err != nil {
+		logger.Error("⚠️ Resultado inválido no cache: " + err.Error())
+		return nil
+	}
+	
+	return &models.SearchResponse{
+		Success:  true,
+		Message:  "Dados extraídos com sucesso",
+		Data:     &data,
+		Cached:   true,
+		CachedAt: &cached.CachedAt,
+	}
+}
+
+// cacheResponse - guarda o resultado da automação para as próximas consultas do CPF pelo usuário
+// credential vazia: só sessões já logadas como o usuário enxergam o resultado
+func (h *Handler) cacheResponse(username, credential, cpf string, response *models.SearchResponse) {
+	if h.cache == nil || response == nil || response.Data == nil {
+		return
+	}
+	
+	resultJSON, err := json.Marshal(response.Data)
+	if err != nil {
+		return
+	}
+	if err := h.cache.CacheResult(username, credential, cpf, "", string(resultJSON)); err != nil {
+		logger.Error("⚠️ Erro ao gravar cache de resultados: " + err.Error())
+	}
+}
+
+// recordHistory - grava a consulta síncrona no histórico
+func (h *Handler) recordHistory(username string, query automation.SearchQuery, startedAt time.Time, response *models.SearchResponse, searchErr error) {
+	if h.history == nil {
+		return
+	}
+	
+	record := &history.Record{
+		ID:         uuid.New().String(),
+		Source:     history.SourceSync,
+		Username:   username,
+		Status:     queue.StatusCompleted,
+		Attempts:   1,
+		CreatedAt:  startedAt,
+		FinishedAt: time.Now(),
+		
+		SearchType:  string(query.Type),
+		SearchValue: query.Value,
+	}
+	if query.Type == models.SearchTypeCPF {
+		record.CPF = query.Value
+	}
+	record.DurationMs = record.FinishedAt.Sub(record.CreatedAt).Milliseconds()
+	
+	if searchErr != nil {
+		record.Status = queue.StatusFailed
+		record.Error = searchErr.Error()
+	} else if response != nil {
+		record.Cached = response.Cached
+		if len(response.Results) > 0 {
+			record.SetResults(response.Results)
+		} else {
+			record.SetData(response.Data)
+		}
+	}
+	
+	if err := h.history.Save(record); err != nil {
+		logger.Error("⚠️ Erro ao gravar histórico: " + err.Error())
+	}
+}
+
+// loginBlocked - erro de bloqueio se o usuário estiver bloqueado (nil se liberado)
+func (h *Handler) loginBlocked(username string) *queue.LoginBlockedError {
+	if h.guard == nil {
+		return nil
+	}
+	
+	block, err := h.guard.GetLoginBlock(username)
+	if err != nil {
+		logger.Error("⚠️ Erro ao consultar bloqueio de login: " + err.Error())
+		return nil
+	}
+	if block == nil {
+		return nil
+	}
+	return &queue.LoginBlockedError{Block: block}
+}
+
+// recordLoginResult - conta falhas de credencial (e zera no sucesso)
+func (h *Handler) recordLoginResult(username string, err error) {
+	if h.guard == nil {
+		return
+	}
+	
+	block, guardErr := automation.RecordLoginResult(h.guard, username, err)
+	if guardErr != nil {
+		logger.Error("⚠️ Erro ao registrar resultado do login: " + guardErr.Error())
+	}
+	if block != nil {
+		logger.Error(fmt.Sprintf("🚫 Usuário %s bloqueado após %d falha(s) de credencial (%d job(s) da fila falhados)", username, block.Failures, len(block.FailedJobs)))
+	}
+}
+
+// automationStatus - código HTTP de uma falha da automação
+// Falhas de login classificadas têm códigos próprios; o resto é 500
+func automationStatus(err error) int {
+	switch {
+	case errors.Is(err, automation.ErrInvalidCredentials):
+		return http.StatusUnauthorized
+	case errors.Is(err, automation.ErrPasswordExpired):
+		return http.StatusForbidden
+	case errors.Is(err, automation.ErrAccountLocked):
+		return http.StatusLocked
+	case errors.Is(err, automation.ErrPortalUnavailable):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, automation.ErrUnexpectedPage):
+		return http.StatusBadGateway
+	case automation.IsStageTimeout(err):
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// inflightKey - credenciais + valor normalizado da busca (mesma chave para "123.456..." e "123456...")
+// Só compartilham a execução as mesmas credenciais (ela roda com a senha de quem chegou primeiro);
+// tipos de busca e seleções de propostas diferentes também não
+func inflightKey(username, password string, query automation.SearchQuery, selection models.ProposalSelection) string {
+	credential := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(username)) + "\x00" + password))
+	key := hex.EncodeToString(credential[:]) + ":" + query.Value
+	if query.Type != models.SearchTypeCPF {
+		key = string(query.Type) + ":" + key
+	}
+	if selection != models.ProposalFirst {
+		key += ":" + string(selection)
+	}
+	return key
+}
+
+// searchQuery - busca pedida na requisição (search_type + search_value, ou só o cpf)
+func searchQuery(searchType, searchValue, cpf string) (automation.SearchQuery, error) {
+	if searchValue == "" {
+		searchValue = cpf
+	}
+	if searchValue == "" {
+		return automation.SearchQuery{}, errors.New("informe o cpf ou search_value")
+	}
+	return automation.ParseSearchQuery(searchType, searchValue)
+}
+
+// cacheable - o cache por CPF guarda só buscas por CPF com a primeira proposta
+func cacheable(query automation.SearchQuery, selection models.ProposalSelection) bool {
+	return query.Type == models.SearchTypeCPF && selection == models.ProposalFirst
+}
+
 // Health - endpoint de health check
 func (h *Handler) Health(w http.ResponseWriter, r *http.Request) {
 	response := models.HealthResponse{
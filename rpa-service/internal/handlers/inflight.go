@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"context"
+	"sync"
+
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/models"
+)
+
+// inflightCall - automação em andamento compartilhada por várias requisições
+type inflightCall struct {
+	done     chan struct{}
+	response *models.SearchResponse
+	err      error
+	waiters  int
+	cancel   context.CancelFunc
+}
+
+// inflightGroup - junta requisições simultâneas do mesmo CPF em uma única execução
+// A execução só é cancelada quando TODOS os clientes que a aguardam desconectam.
+type inflightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*inflightCall
+}
+
+// newInflightGroup - cria um grupo vazio
+func newInflightGroup() *inflightGroup {
+	return &inflightGroup{
+		calls: make(map[string]*inflightCall),
+	}
+}
+
+// Do - executa fn para a chave ou aguarda a execução que já está em andamento
+// shared == true quando o resultado veio da execução de outra requisição
+func (g *inflightGroup) Do(ctx context.Context, key string, fn func(ctx context.Context) (*models.SearchResponse, error)) (response *models.SearchResponse, shared bool, err error) {
+	g.mu.Lock()
+	call, shared := g.calls[key]
+	if shared {
+		call.waiters++
+	} else {
+		callCtx, cancel := context.WithCancel(context.Background())
+		call = &inflightCall{
+			done:    make(chan struct{}),
+			waiters: 1,
+			cancel:  cancel,
+		}
+		g.calls[key] = call
+
+		go func() {
+			call.response, call.err = fn(callCtx)
+
+			g.mu.Lock()
+			g.forget(key, call)
+			g.mu.Unlock()
+
+			cancel()
+			close(call.done)
+		}()
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-call.done:
+		// Cada requisição recebe a própria cópia (o handler ainda preenche ErrorCode)
+		if call.response != nil {
+			copied := *call.response
+			return &copied, shared, call.err
+		}
+		return nil, shared, call.err
+
+	case <-ctx.Done():
+		g.mu.Lock()
+		call.waiters--
+		if call.waiters == 0 {
+			call.cancel() // Ninguém mais aguarda: encerra o Chrome
+			g.forget(key, call)
+		}
+		g.mu.Unlock()
+		return nil, shared, ctx.Err()
+	}
+}
+
+// forget - remove a execução do grupo (se ainda for a registrada para a chave)
+// Novas requisições não devem se juntar a uma execução já cancelada.
+func (g *inflightGroup) forget(key string, call *inflightCall) {
+	if g.calls[key] == call {
+		delete(g.calls, key)
+	}
+}
@@ -0,0 +1,397 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/artifacts"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/automation"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/history"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/models"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/queue"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/webhook"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/pkg/logger"
+)
+
+// maxJobAttempts - limite de tentativas aceito por job
+const maxJobAttempts = 10
+
+// JobHandler - gerencia as requisições da API assíncrona (fila Redis)
+type JobHandler struct {
+	queue     queue.Queue
+	webhooks  *webhook.Dispatcher // nil = callback_url desabilitado
+	history   history.Repository
+	artifacts *artifacts.Store // nil = evidências de falha desabilitadas
+}
+
+// NewJobHandler - cria um novo handler de jobs
+func NewJobHandler(q queue.Queue, webhooks *webhook.Dispatcher) *JobHandler {
+	return &JobHandler{
+		queue:    q,
+		webhooks: webhooks,
+	}
+}
+
+// SetHistory - grava no histórico os jobs servidos do cache (não passam pelo worker)
+func (h *JobHandler) SetHistory(repo history.Repository) {
+	h.history = repo
+}
+
+// AddJob - enfileira um job de login + busca (POST /api/jobs)
+func (h *JobHandler) AddJob(w http.ResponseWriter, r *http.Request) {
+	var req models.LoginAndSearchRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.Username == "" || req.Password == "" {
+		writeError(w, http.StatusBadRequest, "username e password são obrigatórios")
+		return
+	}
+
+	query, err := searchQuery(req.SearchType, req.SearchValue, req.CPF)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if req.MaxAttempts < 0 || req.MaxAttempts > maxJobAttempts {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("max_attempts deve estar entre 1 e %d (0 ou ausente usa o padrão)", maxJobAttempts))
+		return
+	}
+
+	if err := h.validateCallbackURL(req.CallbackURL); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	selection, err := automation.ParseProposalSelection(req.ProposalSelection)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	submittedAt := time.Now()
+	jobID, err := h.queue.AddJob(req.Username, req.Password, query.Value, queue.JobOptions{
+		MaxAttempts:  req.MaxAttempts,
+		ForceRefresh: req.ForceRefresh,
+		CallbackURL:  req.CallbackURL,
+
+		ProposalSelection: selection,
+		SearchType:        query.Type,
+	})
+	var blockedErr *queue.LoginBlockedError
+	if errors.As(err, &blockedErr) {
+		writeLoginBlocked(w, blockedErr)
+		return
+	}
+	if err != nil {
+		logger.Error("❌ Erro ao enfileirar job: " + err.Error())
+		writeError(w, http.StatusInternalServerError, "Erro ao enfileirar job")
+		return
+	}
+
+	// O job pode ter vindo do cache ou ser um já em andamento para o mesmo CPF
+	job, err := h.queue.GetJobStatus(jobID)
+	if err != nil {
+		logger.Error(fmt.Sprintf("❌ Erro ao buscar job %s: %v", jobID, err))
+		writeError(w, http.StatusInternalServerError, "Erro ao buscar job")
+		return
+	}
+
+	status := http.StatusAccepted
+	message := "Job adicionado na fila"
+	switch {
+	case job.Cached:
+		status = http.StatusOK
+		message = "Resultado recente em cache"
+		logger.Info(fmt.Sprintf("💾 CPF %s servido do cache (job %s)", query.Value, jobID))
+		h.finishCached(job)
+	case job.CreatedAt.Before(submittedAt):
+		message = "Já existe um job em andamento para esta busca"
+		logger.Info(fmt.Sprintf("🔗 %s já em andamento no job %s", query, jobID))
+	default:
+		logger.Info(fmt.Sprintf("📥 Job %s enfileirado (%s)", jobID, query))
+	}
+
+	statusURL := fmt.Sprintf("/api/jobs/%s", jobID)
+	w.Header().Set("Location", statusURL)
+	writeJSON(w, status, models.JobSubmitResponse{
+		JobID:     jobID,
+		Status:    job.Status,
+		Message:   message,
+		StatusURL: statusURL,
+	})
+}
+
+// GetJob - consulta o status de um job (GET /api/jobs/{id})
+func (h *JobHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	jobID := mux.Vars(r)["id"]
+
+	job, err := h.queue.GetJobStatus(jobID)
+	if errors.Is(err, queue.ErrJobNotFound) {
+		writeError(w, http.StatusNotFound, "Job não encontrado")
+		return
+	}
+	if err != nil {
+		logger.Error(fmt.Sprintf("❌ Erro ao buscar job %s: %v", jobID, err))
+		writeError(w, http.StatusInternalServerError, "Erro ao buscar job")
+		return
+	}
+
+	response, err := toJobStatusResponse(job)
+	if err != nil {
+		logger.Error(fmt.Sprintf("❌ Resultado inválido no job %s: %v", jobID, err))
+		writeError(w, http.StatusInternalServerError, "Resultado do job inválido")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// CancelJob - cancela um job (DELETE /api/jobs/{id})
+// 200 se cancelado na hora, 202 se o worker foi sinalizado, 409 se já terminou
+func (h *JobHandler) CancelJob(w http.ResponseWriter, r *http.Request) {
+	jobID := mux.Vars(r)["id"]
+
+	job, err := h.queue.CancelJob(jobID)
+	if errors.Is(err, queue.ErrJobNotFound) {
+		writeError(w, http.StatusNotFound, "Job não encontrado")
+		return
+	}
+	if errors.Is(err, queue.ErrJobFinished) {
+		writeError(w, http.StatusConflict, fmt.Sprintf("Job já finalizado (status: %s)", job.Status))
+		return
+	}
+	if err != nil {
+		logger.Error(fmt.Sprintf("❌ Erro ao cancelar job %s: %v", jobID, err))
+		writeError(w, http.StatusInternalServerError, "Erro ao cancelar job")
+		return
+	}
+
+	response, err := toJobStatusResponse(job)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Resultado do job inválido")
+		return
+	}
+
+	if job.Status == queue.StatusCancelled {
+		logger.Info(fmt.Sprintf("🛑 Job %s cancelado", jobID))
+		writeJSON(w, http.StatusOK, response)
+		return
+	}
+
+	logger.Info(fmt.Sprintf("🛑 Cancelamento do job %s sinalizado ao worker", jobID))
+	writeJSON(w, http.StatusAccepted, response)
+}
+
+// ListJobs - lista jobs com filtros e paginação (GET /api/jobs)
+// Filtros: status (separados por vírgula), cpf, username, from, to, cursor, limit
+func (h *JobHandler) ListJobs(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseJobFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, err := h.queue.ListJobs(*filter)
+	if errors.Is(err, queue.ErrInvalidCursor) {
+		writeError(w, http.StatusBadRequest, "cursor inválido")
+		return
+	}
+	if err != nil {
+		logger.Error("❌ Erro ao listar jobs: " + err.Error())
+		writeError(w, http.StatusInternalServerError, "Erro ao listar jobs")
+		return
+	}
+
+	response := models.JobListResponse{
+		Jobs:       make([]models.JobStatusResponse, 0, len(page.Jobs)),
+		NextCursor: page.NextCursor,
+	}
+	for _, job := range page.Jobs {
+		jobResponse, err := toJobStatusResponse(job)
+		if err != nil {
+			logger.Error(fmt.Sprintf("❌ Resultado inválido no job %s: %v", job.ID, err))
+			continue
+		}
+		response.Jobs = append(response.Jobs, *jobResponse)
+	}
+	response.Count = len(response.Jobs)
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// parseJobFilter - lê os filtros da query string
+func parseJobFilter(r *http.Request) (*queue.JobFilter, error) {
+	query := r.URL.Query()
+
+	filter := &queue.JobFilter{
+		CPF:      query.Get("cpf"),
+		Username: query.Get("username"),
+		Cursor:   query.Get("cursor"),
+	}
+
+	if statuses := query.Get("status"); statuses != "" {
+		for _, status := range strings.Split(statuses, ",") {
+			status = strings.TrimSpace(status)
+			if !queue.ValidStatus(status) {
+				return nil, fmt.Errorf("status inválido: %q", status)
+			}
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > queue.MaxListLimit {
+			return nil, fmt.Errorf("limit deve estar entre 1 e %d", queue.MaxListLimit)
+		}
+		filter.Limit = limit
+	}
+
+	var err error
+	if filter.CreatedFrom, err = parseTimeParam(query.Get("from"), false); err != nil {
+		return nil, fmt.Errorf("from inválido: %v", err)
+	}
+	if filter.CreatedTo, err = parseTimeParam(query.Get("to"), true); err != nil {
+		return nil, fmt.Errorf("to inválido: %v", err)
+	}
+
+	return filter, nil
+}
+
+// parseTimeParam - aceita RFC3339 ou data (AAAA-MM-DD)
+// Data no limite superior cobre o dia inteiro
+func parseTimeParam(value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	day, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, errors.New("use RFC3339 ou AAAA-MM-DD")
+	}
+	if endOfDay {
+		return day.Add(24*time.Hour - time.Millisecond), nil
+	}
+	return day, nil
+}
+
+// validateCallbackURL - callback_url é opcional, mas exige webhooks habilitados
+func (h *JobHandler) validateCallbackURL(callbackURL string) error {
+	if callbackURL == "" {
+		return nil
+	}
+	if h.webhooks == nil {
+		return fmt.Errorf("callback_url indisponível: defina %s no servidor", webhook.SecretEnv)
+	}
+	return h.webhooks.ValidateURL(callbackURL)
+}
+
+// finishCached - jobs servidos do cache terminam sem passar pelo worker:
+// o histórico e o callback saem daqui
+func (h *JobHandler) finishCached(job *queue.Job) {
+	if h.history != nil {
+		record, err := history.FromJob(job)
+		if err == nil {
+			err = h.history.Save(record)
+		}
+		if err != nil {
+			logger.Error(fmt.Sprintf("⚠️ Erro ao gravar histórico do job %s: %v", job.ID, err))
+		}
+	}
+
+	if h.webhooks != nil && job.CallbackURL != "" {
+		if _, err := h.webhooks.NotifyJobFinished(job); err != nil {
+			logger.Error(fmt.Sprintf("⚠️ Erro ao registrar callback do job %s: %v", job.ID, err))
+		}
+	}
+}
+
+// toJobStatusResponse - converte o Job da fila para a resposta da API
+func toJobStatusResponse(job *queue.Job) (*models.JobStatusResponse, error) {
+	response := &models.JobStatusResponse{
+		JobID:     job.ID,
+		Status:    job.Status,
+		CPF:       job.CPF,
+		Username:  job.Username,
+		BatchID:   job.BatchID,
+		Stage:     job.Stage,
+		Progress:  job.Progress,
+		Error:     job.Error,
+		ErrorCode: job.ErrorCode,
+		Attempts:  job.Attempts,
+		CreatedAt: job.CreatedAt,
+		UpdatedAt: job.UpdatedAt,
+
+		MaxAttempts:   job.MaxAttempts,
+		NextAttemptAt: job.NextAttemptAt,
+		Cached:        job.Cached,
+		SearchType:    string(job.SearchType),
+		SearchValue:   job.SearchedValue(),
+	}
+
+	switch job.Status {
+	case queue.StatusCompleted:
+		results, err := job.ParseResults()
+		if err != nil {
+			return nil, err
+		}
+		response.Result = &models.SearchResponse{
+			Success: true,
+			Message: "Dados extraídos com sucesso",
+			Cached:  job.Cached,
+		}
+		if len(results) > 0 {
+			response.Result.Data = results[0]
+		}
+		if job.ProposalSelection == models.ProposalAll {
+			response.Result.Results = results
+		}
+	case queue.StatusFailed, queue.StatusCancelled:
+		response.Result = &models.SearchResponse{
+			Success:   false,
+			Message:   job.Error,
+			ErrorCode: job.ErrorCode,
+		}
+	}
+
+	return response, nil
+}
+
+// writeJSON - escreve resposta JSON com o status informado
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// writeLoginBlocked - 423: usuário bloqueado até um operador liberar
+func writeLoginBlocked(w http.ResponseWriter, blockedErr *queue.LoginBlockedError) {
+	writeJSON(w, http.StatusLocked, models.SearchResponse{
+		Success:   false,
+		Message:   blockedErr.Error(),
+		ErrorCode: blockedErr.ErrorCode(),
+	})
+}
+
+// writeError - escreve erro no formato SearchResponse
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, models.SearchResponse{
+		Success: false,
+		Message: message,
+	})
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/models"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/queue"
+)
+
+// runFakeWorker - faz o papel do worker sem Chrome: pega o próximo job e conclui com o resultado
+// Só usa o núcleo da fila (JobQueue), como um backend novo precisaria oferecer
+func runFakeWorker(t *testing.T, q queue.JobQueue, data *models.ClientData) *queue.Job {
+	t.Helper()
+
+	job, err := q.GetNextJob("test-worker")
+	if err != nil {
+		t.Fatalf("GetNextJob: %v", err)
+	}
+	if job == nil {
+		t.Fatal("GetNextJob: nenhum job na fila")
+	}
+
+	resultJSON, err := json.Marshal(data)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	if err := q.CompleteJob(job.ID, job.Lease(), string(resultJSON)); err != nil {
+		t.Fatalf("CompleteJob: %v", err)
+	}
+	return job
+}
+
+func newTestRouter(q queue.Queue) *mux.Router {
+	jobHandler := NewJobHandler(q, nil)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/jobs", jobHandler.AddJob).Methods("POST")
+	router.HandleFunc("/api/jobs/{id}", jobHandler.GetJob).Methods("GET")
+	return router
+}
+
+func submitJob(t *testing.T, router http.Handler, req models.LoginAndSearchRequest) (int, models.JobSubmitResponse) {
+	t.Helper()
+
+	body, _ := json.Marshal(req)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/jobs", bytes.NewReader(body)))
+
+	var response models.JobSubmitResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("resposta do POST /api/jobs inválida: %v (%s)", err, rec.Body.String())
+	}
+	return rec.Code, response
+}
+
+func getJob(t *testing.T, router http.Handler, statusURL string) models.JobStatusResponse {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, statusURL, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET %s: status %d (%s)", statusURL, rec.Code, rec.Body.String())
+	}
+
+	var response models.JobStatusResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("resposta do GET %s inválida: %v", statusURL, err)
+	}
+	return response
+}
+
+// TestJobEndToEndMemoryQueue - servidor + worker na fila em memória: enfileira, processa,
+// consulta o resultado e serve a mesma busca do cache
+func TestJobEndToEndMemoryQueue(t *testing.T) {
+	q := queue.NewMemoryQueue(queue.DefaultQueueConfig())
+	defer q.Close()
+	router := newTestRouter(q)
+
+	request := models.LoginAndSearchRequest{Username: "operador", Password: "senha", CPF: "52998224725"}
+
+	code, submitted := submitJob(t, router, request)
+	if code != http.StatusAccepted {
+		t.Fatalf("POST /api/jobs: status %d, esperado %d", code, http.StatusAccepted)
+	}
+	if submitted.Status != queue.StatusPending {
+		t.Fatalf("job recém-enfileirado com status %q", submitted.Status)
+	}
+
+	data := &models.ClientData{CPF: "52998224725", Nome: "FULANO DE TAL"}
+	job := runFakeWorker(t, q, data)
+	if job.ID != submitted.JobID {
+		t.Fatalf("worker pegou o job %s, esperado %s", job.ID, submitted.JobID)
+	}
+
+	status := getJob(t, router, submitted.StatusURL)
+	if status.Status != queue.StatusCompleted {
+		t.Fatalf("job com status %q, esperado %q", status.Status, queue.StatusCompleted)
+	}
+	if status.Result == nil || !status.Result.Success || status.Result.Data == nil {
+		t.Fatalf("job concluído sem resultado: %+v", status.Result)
+	}
+	if status.Result.Data.Nome != data.Nome {
+		t.Fatalf("nome %q no resultado, esperado %q", status.Result.Data.Nome, data.Nome)
+	}
+
+	// A mesma busca logo em seguida sai do cache, sem passar pelo worker
+	code, cached := submitJob(t, router, request)
+	if code != http.StatusOK || cached.Status != queue.StatusCompleted {
+		t.Fatalf("segunda busca: status %d / %q, esperado servida do cache", code, cached.Status)
+	}
+	if status := getJob(t, router, cached.StatusURL); !status.Cached || status.Result.Data.Nome != data.Nome {
+		t.Fatalf("resultado do cache inesperado: %+v", status)
+	}
+}
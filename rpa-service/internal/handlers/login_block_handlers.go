@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/models"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/queue"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/pkg/logger"
+)
+
+// LoginBlockHandler - consulta e liberação dos usuários bloqueados por senha errada
+type LoginBlockHandler struct {
+	guard queue.LoginGuard
+}
+
+// NewLoginBlockHandler - cria um novo handler de bloqueios de login
+func NewLoginBlockHandler(guard queue.LoginGuard) *LoginBlockHandler {
+	return &LoginBlockHandler{
+		guard: guard,
+	}
+}
+
+// ListLoginBlocks - usuários bloqueados (GET /api/login-blocks)
+func (h *LoginBlockHandler) ListLoginBlocks(w http.ResponseWriter, r *http.Request) {
+	blocks, err := h.guard.ListLoginBlocks()
+	if err != nil {
+		logger.Error(fmt.Sprintf("❌ Erro ao listar bloqueios de login: %v", err))
+		writeError(w, http.StatusInternalServerError, "Erro ao listar bloqueios")
+		return
+	}
+	if blocks == nil {
+		blocks = []*queue.LoginBlock{}
+	}
+
+	writeJSON(w, http.StatusOK, blocks)
+}
+
+// ClearLoginBlock - libera o usuário depois que o operador corrigiu a senha (DELETE /api/login-blocks/{username})
+func (h *LoginBlockHandler) ClearLoginBlock(w http.ResponseWriter, r *http.Request) {
+	username := mux.Vars(r)["username"]
+
+	err := h.guard.ClearLoginBlock(username)
+	if errors.Is(err, queue.ErrLoginBlockNotFound) {
+		writeError(w, http.StatusNotFound, "Usuário não está bloqueado")
+		return
+	}
+	if err != nil {
+		logger.Error(fmt.Sprintf("❌ Erro ao liberar usuário %s: %v", username, err))
+		writeError(w, http.StatusInternalServerError, "Erro ao liberar usuário")
+		return
+	}
+
+	logger.Info(fmt.Sprintf("🔓 Usuário %s liberado", username))
+	writeJSON(w, http.StatusOK, models.LoginResponse{
+		Success: true,
+		Message: "Usuário liberado",
+	})
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/automation"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/models"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/pkg/logger"
+)
+
+// Login - POST /api/login
+// Abre um Chrome logado no portal e devolve o token da sessão
+func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
+	var req models.LoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Corpo da requisição inválido")
+		return
+	}
+	if req.Username == "" || req.Password == "" {
+		writeError(w, http.StatusBadRequest, "username e password são obrigatórios")
+		return
+	}
+
+	logger.Info("📥 Nova sessão solicitada")
+	logger.Info("👤 Usuário: " + req.Username)
+
+	if blockedErr := h.loginBlocked(req.Username); blockedErr != nil {
+		writeLoginBlocked(w, blockedErr)
+		return
+	}
+
+	session, err := h.sessions.Open(r.Context(), req.Username, req.Password)
+	if errors.Is(err, automation.ErrTooManySessions) {
+		writeJSON(w, http.StatusTooManyRequests, models.LoginResponse{Success: false, Message: err.Error()})
+		return
+	}
+	h.recordLoginResult(req.Username, err)
+	if err != nil {
+		logger.Error("❌ Erro ao abrir sessão: " + err.Error())
+		writeJSON(w, automationStatus(err), models.LoginResponse{
+			Success:   false,
+			Message:   err.Error(),
+			ErrorCode: automation.ErrorCode(err),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.LoginResponse{
+		Success:      true,
+		Message:      "Login realizado com sucesso",
+		SessionToken: session.Token,
+	})
+}
+
+// Search - POST /api/search
+// Busca um CPF no navegador já logado da sessão
+func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
+	var req models.SearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Corpo da requisição inválido")
+		return
+	}
+	if req.SessionToken == "" {
+		req.SessionToken = bearerToken(r)
+	}
+	if req.SessionToken == "" {
+		writeError(w, http.StatusUnauthorized, "session_token é obrigatório")
+		return
+	}
+	query, err := searchQuery(req.SearchType, req.SearchValue, req.CPF)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	selection, err := automation.ParseProposalSelection(req.ProposalSelection)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	session, err := h.sessions.Get(req.SessionToken)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	logger.Info("🔍 Busca (sessão): " + query.String())
+
+	// Consulta recente do mesmo CPF pelo mesmo usuário: nem usa o navegador
+	// A sessão já fez login como o usuário (o cache guarda só a primeira proposta)
+	if !req.ForceRefresh && cacheable(query, selection) {
+		if cached := h.cachedResult(session.Username, query.Value); cached != nil {
+			if response := cachedResponse(cached); response != nil {
+				logger.Info("💾 Resultado servido do cache")
+				h.recordHistory(session.Username, query, time.Now(), response, nil)
+				writeJSON(w, http.StatusOK, response)
+				return
+			}
+		}
+	}
+
+	startedAt := time.Now()
+	response, err := session.Search(r.Context(), query, selection)
+	if errors.Is(err, automation.ErrSessionNotFound) {
+		writeError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if err == nil && cacheable(query, selection) {
+		h.cacheResponse(session.Username, "", query.Value, response)
+	}
+	h.recordHistory(session.Username, query, startedAt, response, err)
+
+	if err != nil {
+		logger.Error("❌ Erro na busca da sessão: " + err.Error())
+
+		// Portal derrubou o login: o Chrome não serve mais, cliente precisa logar de novo
+		if automation.IsSessionExpired(err) {
+			h.sessions.Close(session.Token)
+			writeError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		response.ErrorCode = automation.ErrorCode(err)
+		writeJSON(w, automationStatus(err), response)
+		return
+	}
+
+	logger.Info("✅ Busca da sessão concluída!")
+	writeJSON(w, http.StatusOK, response)
+}
+
+// Logout - POST /api/logout
+// Encerra a sessão e fecha o Chrome
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	var req models.SearchRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "Corpo da requisição inválido")
+			return
+		}
+	}
+	if req.SessionToken == "" {
+		req.SessionToken = bearerToken(r)
+	}
+
+	if err := h.sessions.Close(req.SessionToken); err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.LoginResponse{
+		Success: true,
+		Message: "Sessão encerrada",
+	})
+}
+
+// bearerToken - token do header "Authorization: Bearer <token>"
+func bearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+}
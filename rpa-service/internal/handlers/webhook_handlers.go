@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/webhook"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/pkg/logger"
+)
+
+// WebhookHandler - inspeção e reenvio das entregas de callback
+type WebhookHandler struct {
+	dispatcher *webhook.Dispatcher
+}
+
+// NewWebhookHandler - cria um novo handler de webhooks
+func NewWebhookHandler(dispatcher *webhook.Dispatcher) *WebhookHandler {
+	return &WebhookHandler{
+		dispatcher: dispatcher,
+	}
+}
+
+// ListJobDeliveries - entregas de callback de um job (GET /api/jobs/{id}/deliveries)
+func (h *WebhookHandler) ListJobDeliveries(w http.ResponseWriter, r *http.Request) {
+	jobID := mux.Vars(r)["id"]
+
+	deliveries, err := h.dispatcher.Store().ListByJob(jobID)
+	if err != nil {
+		logger.Error(fmt.Sprintf("❌ Erro ao listar entregas do job %s: %v", jobID, err))
+		writeError(w, http.StatusInternalServerError, "Erro ao listar entregas")
+		return
+	}
+	if deliveries == nil {
+		deliveries = []*webhook.Delivery{}
+	}
+
+	writeJSON(w, http.StatusOK, deliveries)
+}
+
+// GetDelivery - detalhes de uma entrega, com todas as tentativas (GET /api/webhooks/deliveries/{id})
+func (h *WebhookHandler) GetDelivery(w http.ResponseWriter, r *http.Request) {
+	deliveryID := mux.Vars(r)["id"]
+
+	delivery, err := h.dispatcher.Store().Get(deliveryID)
+	if errors.Is(err, webhook.ErrDeliveryNotFound) {
+		writeError(w, http.StatusNotFound, "Entrega não encontrada")
+		return
+	}
+	if err != nil {
+		logger.Error(fmt.Sprintf("❌ Erro ao buscar entrega %s: %v", deliveryID, err))
+		writeError(w, http.StatusInternalServerError, "Erro ao buscar entrega")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, delivery)
+}
+
+// ReplayDelivery - reenvia uma entrega (POST /api/webhooks/deliveries/{id}/replay)
+func (h *WebhookHandler) ReplayDelivery(w http.ResponseWriter, r *http.Request) {
+	deliveryID := mux.Vars(r)["id"]
+
+	delivery, err := h.dispatcher.Replay(deliveryID)
+	switch {
+	case errors.Is(err, webhook.ErrDeliveryNotFound):
+		writeError(w, http.StatusNotFound, "Entrega não encontrada")
+		return
+	case errors.Is(err, webhook.ErrDeliveryInProgress):
+		writeError(w, http.StatusConflict, "Entrega ainda está sendo tentada")
+		return
+	case err != nil:
+		logger.Error(fmt.Sprintf("❌ Erro ao reenviar entrega %s: %v", deliveryID, err))
+		writeError(w, http.StatusInternalServerError, "Erro ao reenviar entrega")
+		return
+	}
+
+	logger.Info(fmt.Sprintf("🔁 Entrega %s do job %s reenviada", delivery.ID, delivery.JobID))
+	writeJSON(w, http.StatusAccepted, delivery)
+}
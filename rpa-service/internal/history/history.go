@@ -0,0 +1,157 @@
+package history
+
+import (
+	"errors"
+	"time"
+
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/models"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/queue"
+)
+
+const (
+	DBPathEnv     = "HISTORY_DB" // Caminho do arquivo SQLite
+	DefaultDBPath = "data/history.db"
+	DefaultLimit  = 50
+	MaxLimit      = 500
+)
+
+// Origem da consulta
+const (
+	SourceJob  = "job"  // API assíncrona (/api/jobs, /api/batches)
+	SourceSync = "sync" // /api/login-and-search
+)
+
+// ErrRecordNotFound - consulta não existe no histórico
+var ErrRecordNotFound = errors.New("consulta não encontrada no histórico")
+
+// Record - uma consulta finalizada (o que o /historico mostra)
+type Record struct {
+	ID             string               `json:"id"` // ID do job (ou gerado, no fluxo síncrono)
+	Source         string               `json:"source"`
+	Username       string               `json:"username"`
+	CPF            string               `json:"cpf,omitempty"` // Só em buscas por CPF
+	SearchType     string               `json:"search_type,omitempty"`
+	SearchValue    string               `json:"search_value,omitempty"` // CPF, CNPJ, proposta ou contrato
+	Status         string               `json:"status"`
+	Error          string               `json:"error,omitempty"`
+	Attempts       int                  `json:"attempts"`
+	BatchID        string               `json:"batch_id,omitempty"`
+	Cached         bool                 `json:"cached,omitempty"`
+	ContractNumber string               `json:"contract_number,omitempty"`
+	ClientName     string               `json:"client_name,omitempty"`
+	Data           *models.ClientData   `json:"data,omitempty"`    // Primeira proposta
+	Results        []*models.ClientData `json:"results,omitempty"` // Todas, com proposal_selection "all" (Data é a primeira)
+	CreatedAt      time.Time            `json:"created_at"`
+	FinishedAt     time.Time            `json:"finished_at"`
+	DurationMs     int64                `json:"duration_ms"`
+}
+
+// Filter - filtros da consulta ao histórico (campos vazios não filtram)
+type Filter struct {
+	CPF            string
+	SearchValue    string // Qualquer tipo de busca
+	SearchType     string // cpf, cnpj, proposal ou contract
+	ContractNumber string
+	Status         string
+	Username       string
+	From           time.Time // Inclusivo (created_at)
+	To             time.Time // Inclusivo (created_at)
+	Limit          int       // 0 = DefaultLimit
+	Offset         int
+}
+
+// Page - uma página do histórico (mais recentes primeiro)
+type Page struct {
+	Records []*Record `json:"records"`
+	Total   int       `json:"total"`
+	Limit   int       `json:"limit"`
+	Offset  int       `json:"offset"`
+}
+
+// Repository - armazenamento durável do histórico (SQLite por padrão)
+type Repository interface {
+	Save(record *Record) error
+	Get(id string) (*Record, error)
+	Search(filter Filter) (*Page, error)
+	Close() error
+}
+
+// FromJob - registro a partir de um job finalizado
+func FromJob(job *queue.Job) (*Record, error) {
+	record := &Record{
+		ID:         job.ID,
+		Source:     SourceJob,
+		Username:   job.Username,
+		CPF:        job.CPF,
+		Status:     job.Status,
+		Error:      job.Error,
+		Attempts:   job.Attempts,
+		BatchID:    job.BatchID,
+		Cached:     job.Cached,
+		CreatedAt:  job.CreatedAt,
+		FinishedAt: job.UpdatedAt,
+
+		SearchType:  string(job.SearchType),
+		SearchValue: job.SearchedValue(),
+	}
+	if record.SearchType == "" {
+		record.SearchType = string(models.SearchTypeCPF) // Vazio = cpf (jobs antigos e lotes)
+	}
+
+	if job.Status == queue.StatusCompleted {
+		results, err := job.ParseResults()
+		if err != nil {
+			return nil, err
+		}
+		record.SetResults(results)
+	}
+
+	record.DurationMs = record.FinishedAt.Sub(record.CreatedAt).Milliseconds()
+	return record, nil
+}
+
+// SetData - anexa o ClientData e copia os campos pesquisáveis
+func (r *Record) SetData(data *models.ClientData) {
+	r.Data = data
+	if data == nil {
+		return
+	}
+	r.ContractNumber = data.NumeroContrato
+	r.ClientName = data.Nome
+}
+
+// SetResults - anexa todas as propostas encontradas (Data fica com a primeira)
+func (r *Record) SetResults(results []*models.ClientData) {
+	r.Results = nil
+	if len(results) == 0 {
+		r.SetData(nil)
+		return
+	}
+
+	r.SetData(results[0])
+	if len(results) > 1 {
+		r.Results = results
+	}
+}
+
+// Proposals - todas as propostas da consulta (vazio se não houver dados)
+func (r *Record) Proposals() []*models.ClientData {
+	if len(r.Results) > 0 {
+		return r.Results
+	}
+	if r.Data != nil {
+		return []*models.ClientData{r.Data}
+	}
+	return nil
+}
+
+// limit - tamanho da página dentro dos limites
+func (f Filter) limit() int {
+	if f.Limit <= 0 {
+		return DefaultLimit
+	}
+	if f.Limit > MaxLimit {
+		return MaxLimit
+	}
+	return f.Limit
+}
@@ -0,0 +1,306 @@
+package history
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/models"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/pkg/documents"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// schema - tabela única de consultas + índices dos filtros da API
+const schema = `
+CREATE TABLE IF NOT EXISTS searches (
+	id              TEXT PRIMARY KEY,
+	source          TEXT NOT NULL,
+	username        TEXT NOT NULL,
+	cpf             TEXT NOT NULL,
+	status          TEXT NOT NULL,
+	error           TEXT NOT NULL DEFAULT '',
+	attempts        INTEGER NOT NULL DEFAULT 0,
+	batch_id        TEXT NOT NULL DEFAULT '',
+	cached          INTEGER NOT NULL DEFAULT 0,
+	contract_number TEXT NOT NULL DEFAULT '',
+	contract_numbers TEXT NOT NULL DEFAULT '',
+	search_type     TEXT NOT NULL DEFAULT '',
+	search_value    TEXT NOT NULL DEFAULT '',
+	client_name     TEXT NOT NULL DEFAULT '',
+	data            TEXT,
+	created_at      INTEGER NOT NULL,
+	finished_at     INTEGER NOT NULL,
+	duration_ms     INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_searches_cpf ON searches (cpf, created_at);
+CREATE INDEX IF NOT EXISTS idx_searches_contract ON searches (contract_number, created_at);
+CREATE INDEX IF NOT EXISTS idx_searches_status ON searches (status, created_at);
+CREATE INDEX IF NOT EXISTS idx_searches_created ON searches (created_at);
+`
+
+// migrations - colunas acrescentadas depois da primeira versão do schema
+// (bancos novos já nascem com elas: o erro de coluna duplicada é ignorado)
+var migrations = []string{
+	`ALTER TABLE searches ADD COLUMN contract_numbers TEXT NOT NULL DEFAULT ''`, // Contratos de todas as propostas
+	`ALTER TABLE searches ADD COLUMN search_type TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE searches ADD COLUMN search_value TEXT NOT NULL DEFAULT ''`, // CPF, CNPJ, proposta ou contrato
+	// Consultas antigas (anteriores ao search_type) eram todas por CPF e guardavam o valor na coluna cpf
+	`UPDATE searches SET search_type = 'cpf',
+		search_value = CASE WHEN search_value = '' THEN cpf ELSE search_value END
+		WHERE search_type = '' AND cpf != ''`,
+}
+
+const selectColumns = `id, source, username, cpf, status, error, attempts, batch_id, cached,
+	contract_number, client_name, data, created_at, finished_at, duration_ms, search_type, search_value`
+
+const insertColumns = selectColumns + `, contract_numbers`
+
+// SQLiteRepository - histórico em um arquivo SQLite
+// Servidor e worker podem abrir o mesmo arquivo (WAL + busy_timeout)
+type SQLiteRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteRepository - abre (ou cria) o banco e aplica o schema
+func NewSQLiteRepository(path string) (*SQLiteRepository, error) {
+	if path != ":memory:" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, fmt.Errorf("erro ao criar diretório do histórico: %w", err)
+		}
+	}
+
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?_journal_mode=WAL&_busy_timeout=5000&_foreign_keys=on", path))
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite aceita um escritor por vez; ":memory:" só existe dentro da conexão
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("erro ao criar schema do histórico: %w", err)
+	}
+	for _, migration := range migrations {
+		if _, err := db.Exec(migration); err != nil && !strings.Contains(err.Error(), "duplicate column") {
+			db.Close()
+			return nil, fmt.Errorf("erro ao atualizar schema do histórico: %w", err)
+		}
+	}
+
+	return &SQLiteRepository{db: db}, nil
+}
+
+// Save - grava (ou atualiza) a consulta
+// Com várias propostas a coluna data guarda o array inteiro
+func (r *SQLiteRepository) Save(record *Record) error {
+	var data interface{}
+	var stored interface{} = record.Data
+	if len(record.Results) > 0 {
+		stored = record.Results
+	}
+	if record.Data != nil || len(record.Results) > 0 {
+		dataJSON, err := json.Marshal(stored)
+		if err != nil {
+			return err
+		}
+		data = string(dataJSON)
+	}
+
+	_, err := r.db.Exec(`
+		INSERT INTO searches (`+insertColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			status = excluded.status,
+			error = excluded.error,
+			attempts = excluded.attempts,
+			cached = excluded.cached,
+			contract_number = excluded.contract_number,
+			contract_numbers = excluded.contract_numbers,
+			client_name = excluded.client_name,
+			data = excluded.data,
+			finished_at = excluded.finished_at,
+			duration_ms = excluded.duration_ms`,
+		record.ID, record.Source, record.Username, cpfKey(record.CPF), record.Status, record.Error,
+		record.Attempts, record.BatchID, record.Cached, contractKey(record.ContractNumber), record.ClientName,
+		data, record.CreatedAt.UnixMilli(), record.FinishedAt.UnixMilli(), record.DurationMs,
+		record.SearchType, searchValueKey(record.SearchValue), contractList(record.Proposals()),
+	)
+	return err
+}
+
+// Get - busca uma consulta pelo ID
+func (r *SQLiteRepository) Get(id string) (*Record, error) {
+	row := r.db.QueryRow(`SELECT `+selectColumns+` FROM searches WHERE id = ?`, id)
+
+	record, err := scanRecord(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrRecordNotFound
+	}
+	return record, err
+}
+
+// Search - consulta o histórico com filtros (mais recentes primeiro)
+func (r *SQLiteRepository) Search(filter Filter) (*Page, error) {
+	var where []string
+	var args []interface{}
+
+	if filter.CPF != "" {
+		where = append(where, "cpf = ?")
+		args = append(args, cpfKey(filter.CPF))
+	}
+	if filter.SearchValue != "" {
+		where = append(where, "search_value = ?")
+		args = append(args, searchValueKey(filter.SearchValue))
+	}
+	if filter.SearchType != "" {
+		where = append(where, "search_type = ?")
+		args = append(args, filter.SearchType)
+	}
+	if filter.ContractNumber != "" {
+		// Qualquer proposta da consulta (contract_number é só a primeira)
+		where = append(where, "(contract_number = ? OR contract_numbers LIKE ?)")
+		contract := contractKey(filter.ContractNumber)
+		args = append(args, contract, "% "+contract+" %")
+	}
+	if filter.Status != "" {
+		where = append(where, "status = ?")
+		args = append(args, filter.Status)
+	}
+	if filter.Username != "" {
+		where = append(where, "username = ?")
+		args = append(args, filter.Username)
+	}
+	if !filter.From.IsZero() {
+		where = append(where, "created_at >= ?")
+		args = append(args, filter.From.UnixMilli())
+	}
+	if !filter.To.IsZero() {
+		where = append(where, "created_at <= ?")
+		args = append(args, filter.To.UnixMilli())
+	}
+
+	clause := ""
+	if len(where) > 0 {
+		clause = " WHERE " + strings.Join(where, " AND ")
+	}
+
+	page := &Page{
+		Records: []*Record{},
+		Limit:   filter.limit(),
+		Offset:  filter.Offset,
+	}
+
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM searches`+clause, args...).Scan(&page.Total); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(
+		`SELECT `+selectColumns+` FROM searches`+clause+` ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?`,
+		append(args, page.Limit, page.Offset)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		record, err := scanRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		page.Records = append(page.Records, record)
+	}
+
+	return page, rows.Err()
+}
+
+// Close - fecha o banco
+func (r *SQLiteRepository) Close() error {
+	return r.db.Close()
+}
+
+// scanner - *sql.Row ou *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanRecord - lê uma linha de searches
+func scanRecord(row scanner) (*Record, error) {
+	var record Record
+	var data sql.NullString
+	var createdAt, finishedAt int64
+
+	err := row.Scan(
+		&record.ID, &record.Source, &record.Username, &record.CPF, &record.Status, &record.Error,
+		&record.Attempts, &record.BatchID, &record.Cached, &record.ContractNumber, &record.ClientName,
+		&data, &createdAt, &finishedAt, &record.DurationMs, &record.SearchType, &record.SearchValue,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	record.CreatedAt = time.UnixMilli(createdAt)
+	record.FinishedAt = time.UnixMilli(finishedAt)
+
+	if data.Valid {
+		if err := record.unmarshalData(data.String); err != nil {
+			return nil, fmt.Errorf("ClientData inválido na consulta %s: %w", record.ID, err)
+		}
+	}
+
+	return &record, nil
+}
+
+// unmarshalData - lê a coluna data (um ClientData ou o array de propostas)
+func (r *Record) unmarshalData(data string) error {
+	if !strings.HasPrefix(strings.TrimSpace(data), "[") {
+		return json.Unmarshal([]byte(data), &r.Data)
+	}
+
+	var results []*models.ClientData
+	if err := json.Unmarshal([]byte(data), &results); err != nil {
+		return err
+	}
+	r.SetResults(results)
+	return nil
+}
+
+// contractList - contratos de todas as propostas, separados por espaço (" 123 456 ")
+// As bordas com espaço deixam o filtro casar o número inteiro com LIKE '% 123 %'
+func contractList(proposals []*models.ClientData) string {
+	var contracts []string
+	for _, proposal := range proposals {
+		if contract := contractKey(proposal.NumeroContrato); contract != "" {
+			contracts = append(contracts, contract)
+		}
+	}
+	if len(contracts) == 0 {
+		return ""
+	}
+	return " " + strings.Join(contracts, " ") + " "
+}
+
+// cpfKey - CPF sem formatação (mesma chave para "123.456..." e "123456...")
+func cpfKey(cpf string) string {
+	if normalized, err := documents.NormalizeCPF(cpf); err == nil {
+		return normalized
+	}
+	return documents.OnlyDigits(cpf)
+}
+
+// searchValueKey - valor buscado sem pontuação (CPF, CNPJ, proposta e contrato são numéricos)
+func searchValueKey(value string) string {
+	return documents.OnlyDigits(value)
+}
+
+// contractKey - número do contrato sem pontuação
+func contractKey(contract string) string {
+	return documents.OnlyDigits(contract)
+}
+
+var _ Repository = (*SQLiteRepository)(nil)
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // LoginRequest - dados para fazer login na Caixa
 type LoginRequest struct {
 	Username string `json:"username"`
@@ -11,12 +13,50 @@ type LoginResponse struct {
 	Success      bool   `json:"success"`
 	Message      string `json:"message"`
 	SessionToken string `json:"session_token,omitempty"`
+	ErrorCode    string `json:"error_code,omitempty"` // Ex: invalid_credentials, account_locked
 }
 
+// SearchType - o que é procurado no portal
+type SearchType string
+
+const (
+	SearchTypeCPF      SearchType = "cpf" // Padrão
+	SearchTypeCNPJ     SearchType = "cnpj"
+	SearchTypeProposal SearchType = "proposal" // Número da proposta
+	SearchTypeContract SearchType = "contract" // Número do contrato
+)
+
+// ProposalSelection - quais propostas do CPF extrair quando a busca encontra várias
+type ProposalSelection string
+
+const (
+	ProposalFirst            ProposalSelection = "first"              // Primeira linha da tabela (padrão)
+	ProposalMostRecentActive ProposalSelection = "most_recent_active" // Mais recente entre as não canceladas/encerradas
+	ProposalAll              ProposalSelection = "all"                // Todas: um ClientData por proposta
+)
+
 // SearchRequest - dados para buscar por CPF
 type SearchRequest struct {
 	CPF          string `json:"cpf"`
-	SessionToken string `json:"session_token"`
+	SessionToken string `json:"session_token"` // Também aceito no header "Authorization: Bearer <token>"
+
+	// Busca por outro documento: cpf (padrão), cnpj, proposal ou contract
+	SearchType  string `json:"search_type,omitempty"`
+	SearchValue string `json:"search_value,omitempty"` // Vazio com search_type "cpf": usa o campo cpf
+
+	// Ignora o cache de resultados e consulta o portal
+	ForceRefresh bool `json:"force_refresh,omitempty"`
+
+	// first (padrão), most_recent_active ou all
+	ProposalSelection string `json:"proposal_selection,omitempty"`
+}
+
+// Proposal - linha da tabela de resultados da busca (table.tb_lista)
+type Proposal struct {
+	Numero   string `json:"numero"`
+	Situacao string `json:"situacao,omitempty"`
+	Data     string `json:"data,omitempty"` // Como exibida no portal (dd/mm/aaaa)
+	Produto  string `json:"produto,omitempty"`
 }
 
 // ClientData - dados extraídos do portal da Caixa
@@ -61,13 +101,93 @@ type ClientData struct {
 	
 	// Dados Financeiros
 	ValorCompraVenda string `json:"valor_compra_venda,omitempty"`
+
+	// Proposta de onde os dados foram extraídos
+	Proposta *Proposal `json:"proposta,omitempty"`
+
+	// Resumo da página "Proposta Selecionada"
+	ResumoProposta *ProposalSummary `json:"resumo_proposta,omitempty"`
+
+	// Todos os participantes da proposta (proponente, cônjuge, coobrigados...)
+	Participants []Participant `json:"participantes,omitempty"`
+
+	// Etapa que forneceu cada campo (nome JSON -> etapa) e valores divergentes entre etapas
+	Fontes    map[string]string `json:"fontes,omitempty"`
+	Conflitos []FieldConflict   `json:"conflitos,omitempty"`
+
+	// Proposta não pôde ser extraída (proposal_selection "all": as demais seguem)
+	Erro string `json:"erro,omitempty"`
+}
+
+// FieldConflict - campo preenchido com valores diferentes por duas etapas
+// Vale o da etapa que preencheu primeiro; o outro fica registrado aqui
+type FieldConflict struct {
+	Campo           string `json:"campo"`
+	Etapa           string `json:"etapa"`
+	Valor           string `json:"valor"`
+	EtapaDescartada string `json:"etapa_descartada"`
+	ValorDescartado string `json:"valor_descartado"`
+}
+
+// ProposalSummary - resumo da proposta selecionada
+type ProposalSummary struct {
+	AgendamentoAssinatura      *time.Time `json:"agendamento_assinatura,omitempty"` // Data/hora interpretada (horário de Brasília)
+	AgendamentoAssinaturaTexto string     `json:"agendamento_assinatura_texto,omitempty"`
+	Situacao                   string     `json:"situacao,omitempty"`
+	Fase                       string     `json:"fase,omitempty"`
+	Agencia                    string     `json:"agencia,omitempty"` // Agência da proposta (não a da conta de débito)
+	Modalidade                 string     `json:"modalidade,omitempty"`
+	Produto                    string     `json:"produto,omitempty"`
+	Correspondente             string     `json:"correspondente,omitempty"`
+}
+
+// Participant - participante da proposta com os dados do detalharParticipante
+type Participant struct {
+	Papel string `json:"papel"` // Como aparece na página Participantes (ex: Proponente, Cônjuge, Coobrigado)
+
+	// Dados Pessoais
+	CPF               string `json:"cpf"`
+	Nome              string `json:"nome"`
+	Ocupacao          string `json:"ocupacao,omitempty"`
+	Nacionalidade     string `json:"nacionalidade,omitempty"`
+	TipoIdentificacao string `json:"tipo_identificacao,omitempty"`
+	RG                string `json:"rg,omitempty"`
+
+	// Dados de Contato
+	TelefoneCelular string `json:"telefone_celular,omitempty"`
+
+	// Endereço Residencial
+	CEP            string `json:"cep,omitempty"`
+	TipoLogradouro string `json:"tipo_logradouro,omitempty"`
+	Logradouro     string `json:"logradouro,omitempty"`
+	Numero         string `json:"numero,omitempty"`
+	Bairro         string `json:"bairro,omitempty"`
+	Municipio      string `json:"municipio,omitempty"`
+	UF             string `json:"uf,omitempty"`
+	Complemento    string `json:"complemento,omitempty"`
+
+	// Dados Bancários
+	ContaDebitoCompleta string `json:"conta_debito_completa,omitempty"`
+	Agencia             string `json:"agencia,omitempty"`
+	ContaCorrente       string `json:"conta_corrente,omitempty"`
+
+	// Detalhes não puderam ser lidos (só papel, CPF e nome da lista)
+	Erro string `json:"erro,omitempty"`
 }
 
 // SearchResponse - resposta da busca
 type SearchResponse struct {
-	Success bool        `json:"success"`
-	Message string      `json:"message"`
-	Data    *ClientData `json:"data,omitempty"`
+	Success   bool        `json:"success"`
+	Message   string      `json:"message"`
+	ErrorCode string      `json:"error_code,omitempty"` // Ex: invalid_credentials, portal_unavailable
+	Data      *ClientData `json:"data,omitempty"`
+
+	// Com proposal_selection "all": um ClientData por proposta (Data é o primeiro)
+	Results []*ClientData `json:"results,omitempty"`
+
+	// Resultado veio do cache (consulta recente do mesmo CPF)
+	Cached   bool       `json:"cached,omitempty"`
+	CachedAt *time.Time `json:"cached_at,omitempty"`
 }
 
 // HealthResponse - resposta do health check
@@ -82,4 +202,98 @@ type LoginAndSearchRequest struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
 	CPF      string `json:"cpf"`
-}
\ No newline at end of file
+
+	// Busca por outro documento: cpf (padrão), cnpj, proposal ou contract
+	SearchType  string `json:"search_type,omitempty"`
+	SearchValue string `json:"search_value,omitempty"` // Vazio com search_type "cpf": usa o campo cpf
+
+	// Ignora o cache de resultados e consulta o portal
+	ForceRefresh bool `json:"force_refresh,omitempty"`
+
+	// first (padrão), most_recent_active ou all
+	ProposalSelection string `json:"proposal_selection,omitempty"`
+
+	// Apenas para a API assíncrona (/api/jobs)
+	MaxAttempts int    `json:"max_attempts,omitempty"`
+	CallbackURL string `json:"callback_url,omitempty"` // POST assinado quando o job terminar
+}
+
+// JobSubmitResponse - resposta ao enfileirar um job (202 Accepted)
+type JobSubmitResponse struct {
+	JobID     string `json:"job_id"`
+	Status    string `json:"status"`
+	Message   string `json:"message"`
+	StatusURL string `json:"status_url"`
+}
+
+// JobStatusResponse - estado atual de um job da fila
+type JobStatusResponse struct {
+	JobID     string          `json:"job_id"`
+	Status    string          `json:"status"`
+	CPF       string          `json:"cpf,omitempty"` // Só em buscas por CPF (o valor buscado fica em search_value)
+	Username  string          `json:"username"`
+	BatchID   string          `json:"batch_id,omitempty"`
+	Stage     string          `json:"stage,omitempty"`
+	Progress  int             `json:"progress"`
+	Error     string          `json:"error,omitempty"`
+	ErrorCode string          `json:"error_code,omitempty"`
+	Attempts  int             `json:"attempts"`
+	Result    *SearchResponse `json:"result,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+
+	MaxAttempts   int        `json:"max_attempts"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	Cached        bool       `json:"cached,omitempty"`
+	SearchType    string     `json:"search_type,omitempty"`
+	SearchValue   string     `json:"search_value,omitempty"`
+}
+
+// JobListResponse - página da listagem de jobs (GET /api/jobs)
+type JobListResponse struct {
+	Jobs       []JobStatusResponse `json:"jobs"`
+	Count      int                 `json:"count"`
+	NextCursor string              `json:"next_cursor,omitempty"`
+}
+
+// BatchRequest - lote de CPFs com um único par de credenciais
+type BatchRequest struct {
+	Username    string   `json:"username"`
+	Password    string   `json:"password"`
+	CPFs        []string `json:"cpfs"`
+	MaxAttempts int      `json:"max_attempts,omitempty"`
+
+	ForceRefresh      bool   `json:"force_refresh,omitempty"`
+	CallbackURL       string `json:"callback_url,omitempty"` // Um callback por CPF do lote
+	ProposalSelection string `json:"proposal_selection,omitempty"`
+}
+
+// BatchSubmitResponse - resposta ao criar um lote (202 Accepted)
+type BatchSubmitResponse struct {
+	BatchID   string   `json:"batch_id"`
+	Total     int      `json:"total"`
+	JobIDs    []string `json:"job_ids"`
+	Message   string   `json:"message"`
+	StatusURL string   `json:"status_url"`
+}
+
+// BatchProgress - contagem dos jobs do lote por status
+type BatchProgress struct {
+	Pending    int `json:"pending"`
+	Processing int `json:"processing"`
+	Completed  int `json:"completed"`
+	Failed     int `json:"failed"`
+	Cancelled  int `json:"cancelled"`
+	Expired    int `json:"expired"`
+}
+
+// BatchStatusResponse - progresso agregado e resultados do lote
+type BatchStatusResponse struct {
+	BatchID   string              `json:"batch_id"`
+	Username  string              `json:"username"`
+	Total     int                 `json:"total"`
+	Done      bool                `json:"done"`
+	Progress  BatchProgress       `json:"progress"`
+	Jobs      []JobStatusResponse `json:"jobs"`
+	CreatedAt time.Time           `json:"created_at"`
+}
@@ -0,0 +1,172 @@
+package queue
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+const BatchKeyPrefix = "rpa:batch:"
+
+// ErrBatchNotFound - lote não existe (ou já expirou) no Redis
+var ErrBatchNotFound = errors.New("lote não encontrado")
+
+// Batch - lote de CPFs submetidos com as mesmas credenciais
+type Batch struct {
+	ID        string    `json:"id"`
+	Username  string    `json:"username"`
+	JobIDs    []string  `json:"job_ids"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AddBatch - cria um lote e enfileira um job filho por CPF
+func (q *RedisQueue) AddBatch(username, password string, cpfs []string, opts JobOptions) (*Batch, error) {
+	batch := &Batch{
+		ID:        uuid.New().String(),
+		Username:  username,
+		JobIDs:    make([]string, 0, len(cpfs)),
+		CreatedAt: time.Now(),
+	}
+
+	// Grava o lote antes dos filhos: o worker pode pegar um job na hora
+	if err := q.saveBatch(batch); err != nil {
+		return nil, err
+	}
+
+	opts.BatchID = batch.ID
+	for _, cpf := range cpfs {
+		jobID, err := q.AddJob(username, password, cpf, opts)
+		if err != nil {
+			err = fmt.Errorf("erro ao enfileirar CPF %s: %w", cpf, err)
+			return nil, q.rollbackBatch(batch, err)
+		}
+		batch.JobIDs = append(batch.JobIDs, jobID)
+	}
+
+	if err := q.saveBatch(batch); err != nil {
+		return nil, q.rollbackBatch(batch, err)
+	}
+
+	return batch, nil
+}
+
+// rollbackBatch - desfaz um lote que falhou no meio: cancela os jobs já enfileirados e apaga o lote
+// Devolve o erro original (com o da limpeza, se ela também falhar)
+func (q *RedisQueue) rollbackBatch(batch *Batch, cause error) error {
+	if err := cancelBatchJobs(q, batch); err != nil {
+		return errors.Join(cause, err)
+	}
+	if err := q.client.Del(q.ctx, BatchKeyPrefix+batch.ID).Err(); err != nil {
+		return errors.Join(cause, err)
+	}
+	return cause
+}
+
+// cancelBatchJobs - cancela os jobs criados pelo lote
+// Jobs de outra requisição aos quais o lote se juntou (mesma busca em andamento) ficam intactos
+func cancelBatchJobs(q Queue, batch *Batch) error {
+	for _, jobID := range batch.JobIDs {
+		job, err := q.GetJobStatus(jobID)
+		if errors.Is(err, ErrJobNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if job.BatchID != batch.ID {
+			continue
+		}
+
+		if _, err := q.CancelJob(jobID); err != nil && !errors.Is(err, ErrJobFinished) {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetBatch - busca o lote e o estado atual de cada job filho
+func (q *RedisQueue) GetBatch(batchID string) (*Batch, []*Job, error) {
+	batchKey := fmt.Sprintf("%s%s", BatchKeyPrefix, batchID)
+	batchJSON, err := q.client.Get(q.ctx, batchKey).Result()
+	if err == redis.Nil {
+		return nil, nil, ErrBatchNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var batch Batch
+	if err := json.Unmarshal([]byte(batchJSON), &batch); err != nil {
+		return nil, nil, err
+	}
+
+	jobs := make([]*Job, 0, len(batch.JobIDs))
+	for _, jobID := range batch.JobIDs {
+		job, err := q.GetJobStatus(jobID)
+		if errors.Is(err, ErrJobNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		jobs = append(jobs, job)
+	}
+
+	return &batch, jobs, nil
+}
+
+// claimScript - move o job da fila para processing só se ele ainda estiver na fila
+// (atômico: outro worker pode pegar o mesmo ID pelo BLMove ao mesmo tempo)
+var claimScript = redis.NewScript(`
+if redis.call('LREM', KEYS[1], 1, ARGV[1]) == 1 then
+	redis.call('RPUSH', KEYS[2], ARGV[1])
+	return 1
+end
+return 0
+`)
+
+// ClaimBatchJob - pega o próximo job pendente do lote (nil se não houver)
+// Usado pelo worker para aproveitar o login já feito nos CPFs seguintes do lote
+func (q *RedisQueue) ClaimBatchJob(workerID, batchID string) (*Job, error) {
+	_, jobs, err := q.GetBatch(batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, job := range jobs {
+		if job.Status != StatusPending {
+			continue
+		}
+
+		moved, err := claimScript.Run(q.ctx, q.client, []string{JobsQueue, JobsProcessing}, job.ID).Int()
+		if err != nil {
+			return nil, err
+		}
+		if moved == 0 {
+			continue // Outro worker pegou primeiro
+		}
+
+		// nil, nil = cancelado enquanto estava na fila: tenta o próximo
+		claimed, err := q.claim(workerID, job.ID)
+		if claimed != nil || err != nil {
+			return claimed, err
+		}
+	}
+
+	return nil, nil
+}
+
+// saveBatch - grava o lote com o mesmo TTL dos jobs
+func (q *RedisQueue) saveBatch(batch *Batch) error {
+	batchJSON, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+
+	batchKey := fmt.Sprintf("%s%s", BatchKeyPrefix, batch.ID)
+	return q.client.Set(q.ctx, batchKey, batchJSON, 24*time.Hour).Err()
+}
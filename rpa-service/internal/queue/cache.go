@@ -0,0 +1,198 @@
+package queue
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/pkg/documents"
+)
+
+const (
+	ResultCachePrefix = "rpa:result:" // rpa:result:<usuário>:<cpf> - último ClientData do CPF consultado pelo usuário
+	ActiveJobPrefix   = "rpa:cpf:"    // rpa:cpf:<usuário>:<cpf>:active (ou <usuário>:<tipo>:<valor>) - job em andamento da busca
+	activeJobSuffix   = ":active"
+	activeJobTTL      = 24 * time.Hour // Mesmo TTL dos jobs
+)
+
+// ErrCacheMiss - não há resultado recente para o CPF
+var ErrCacheMiss = errors.New("resultado não está em cache")
+
+// CachedResult - resultado de uma consulta guardado por usuário e CPF
+type CachedResult struct {
+	Username   string    `json:"username"`
+	CPF        string    `json:"cpf"`
+	JobID      string    `json:"job_id,omitempty"`     // Job que produziu o resultado (vazio no fluxo síncrono)
+	Result     string    `json:"result"`               // ClientData em JSON
+	Credential string    `json:"credential,omitempty"` // CredentialTag de quem produziu (vazio = sessão já logada)
+	CachedAt   time.Time `json:"cached_at"`
+}
+
+// ResultCache - cache de resultados por usuário e CPF (janela de frescor = QueueConfig.ResultTTL)
+// Cada correspondente só enxerga as próprias consultas. Quem não tem um login
+// válido em mãos (sessão aberta) precisa provar as credenciais com IssuedTo.
+type ResultCache interface {
+	GetCachedResult(username, cpf string) (*CachedResult, error)
+	CacheResult(username, credential, cpf, jobID, result string) error
+	CredentialTag(username, password string) string
+}
+
+// IssuedTo - o resultado foi produzido com as credenciais da tag (ver CredentialTag)
+func (c *CachedResult) IssuedTo(credential string) bool {
+	return c.Credential != "" && hmac.Equal([]byte(c.Credential), []byte(credential))
+}
+
+// Fresh - true se o resultado ainda está dentro da janela de frescor
+func (c *CachedResult) Fresh(ttl time.Duration) bool {
+	return ttl > 0 && time.Since(c.CachedAt) < ttl
+}
+
+// GetCachedResult - busca o resultado recente do CPF consultado pelo usuário (ErrCacheMiss se não houver)
+func (q *RedisQueue) GetCachedResult(username, cpf string) (*CachedResult, error) {
+	if q.config.ResultTTL <= 0 {
+		return nil, ErrCacheMiss
+	}
+
+	cachedJSON, err := q.client.Get(q.ctx, resultCacheKey(username, cpf)).Result()
+	if err == redis.Nil {
+		return nil, ErrCacheMiss
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var cached CachedResult
+	if err := json.Unmarshal([]byte(cachedJSON), &cached); err != nil {
+		return nil, err
+	}
+
+	// A janela pode ter sido reduzida depois que o resultado foi gravado
+	if !cached.Fresh(q.config.ResultTTL) {
+		return nil, ErrCacheMiss
+	}
+
+	return &cached, nil
+}
+
+// CacheResult - guarda o resultado do CPF consultado pelo usuário pela janela de frescor
+func (q *RedisQueue) CacheResult(username, credential, cpf, jobID, result string) error {
+	if q.config.ResultTTL <= 0 {
+		return nil
+	}
+
+	cachedJSON, err := json.Marshal(CachedResult{
+		Username:   loginKey(username),
+		CPF:        cpfKey(cpf),
+		JobID:      jobID,
+		Result:     result,
+		Credential: credential,
+		CachedAt:   time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return q.client.Set(q.ctx, resultCacheKey(username, cpf), cachedJSON, q.config.ResultTTL).Err()
+}
+
+// CredentialTag - impressão digital das credenciais (HMAC com a chave ativa)
+func (q *RedisQueue) CredentialTag(username, password string) string {
+	return q.cipher.Tag(username, password)
+}
+
+// addCachedJob - registra um job já completo com o resultado do cache
+func (q *RedisQueue) addCachedJob(job *Job, cached *CachedResult) error {
+	completeFromCache(job, cached)
+
+	if err := q.UpdateJob(job); err != nil {
+		return err
+	}
+	return q.client.RPush(q.ctx, JobsCompleted, job.ID).Err()
+}
+
+// claimActiveJob - registra o job como o ativo do valor buscado (searchKey)
+// Se outro job da mesma busca ainda está na fila ou executando, retorna esse job
+func (q *RedisQueue) claimActiveJob(searchKey, jobID string) (*Job, error) {
+	key := activeJobKey(searchKey)
+
+	claimed, err := q.client.SetNX(q.ctx, key, jobID, activeJobTTL).Result()
+	if err != nil {
+		return nil, err
+	}
+	if claimed {
+		return nil, nil
+	}
+
+	activeID, err := q.client.Get(q.ctx, key).Result()
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	if activeID != "" {
+		active, err := q.GetJobStatus(activeID)
+		if err == nil && !IsFinalStatus(active.Status) {
+			return active, nil
+		}
+		if err != nil && !errors.Is(err, ErrJobNotFound) {
+			return nil, err
+		}
+	}
+
+	// Job anterior terminou (ou expirou): este passa a ser o ativo
+	return nil, q.client.Set(q.ctx, key, jobID, activeJobTTL).Err()
+}
+
+// canShare - o novo pedido pode aguardar o job em andamento?
+// Só com as mesmas credenciais (o job roda com a senha de quem o criou), as mesmas
+// propostas e sem um webhook diferente (cada job chama um único callback)
+func canShare(active, job *Job, opts JobOptions) bool {
+	if active.CredentialTag == "" || !hmac.Equal([]byte(active.CredentialTag), []byte(job.CredentialTag)) {
+		return false
+	}
+	if cacheableSelection(opts.ProposalSelection) != active.Cacheable() || (!active.Cacheable() && opts.ProposalSelection != active.ProposalSelection) {
+		return false
+	}
+	return opts.CallbackURL == "" || opts.CallbackURL == active.CallbackURL
+}
+
+// completeFromCache - preenche o job com o resultado do cache
+func completeFromCache(job *Job, cached *CachedResult) {
+	job.Status = StatusCompleted
+	job.Result = cached.Result
+	job.Cached = true
+	job.Progress = 100
+	job.StageMessage = fmt.Sprintf("resultado em cache de %s", cached.CachedAt.Format("02/01/2006 15:04"))
+	job.SealedPassword = ""
+}
+
+// cpfKey - CPF sem formatação (com zeros à esquerda) para usar como chave
+func cpfKey(cpf string) string {
+	if normalized, err := documents.NormalizeCPF(cpf); err == nil {
+		return normalized
+	}
+	return documents.OnlyDigits(cpf)
+}
+
+// resultCacheKey - chave do resultado em cache do CPF consultado pelo usuário
+func resultCacheKey(username, cpf string) string {
+	return ResultCachePrefix + loginKey(username) + ":" + cpfKey(cpf)
+}
+
+// credentialTag - HMAC de usuário + senha: compara credenciais sem guardar a senha
+func credentialTag(key []byte, username, password string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(loginKey(username)))
+	mac.Write([]byte{0})
+	mac.Write([]byte(password))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// activeJobKey - chave do job em andamento da busca (CPF ou tipo:valor)
+func activeJobKey(searchKey string) string {
+	return ActiveJobPrefix + searchKey + activeJobSuffix
+}
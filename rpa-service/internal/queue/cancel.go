@@ -0,0 +1,134 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	JobsCancelChannel = "rpa:jobs:cancel" // Pub/sub: IDs de jobs em execução a cancelar
+	cancelFlagSuffix  = ":cancel"         // rpa:job:<id>:cancel - pedido de cancelamento pendente
+	cancelFlagTTL     = time.Hour
+)
+
+// ErrJobFinished - o job já terminou e não pode mais ser cancelado
+var ErrJobFinished = errors.New("job já finalizado")
+
+// CancelJob - cancela um job pendente ou sinaliza o worker que está executando
+// Retorna o job atualizado; Status == StatusCancelled se o cancelamento foi imediato
+func (q *RedisQueue) CancelJob(jobID string) (*Job, error) {
+	job, err := q.GetJobStatus(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch job.Status {
+	case StatusCompleted, StatusFailed, StatusCancelled:
+		return job, ErrJobFinished
+
+	case StatusPending:
+		// Ainda na fila: basta remover
+		removed, err := q.client.LRem(q.ctx, JobsQueue, 0, jobID).Result()
+		if err != nil {
+			return nil, err
+		}
+		if removed > 0 {
+			return job, q.markCancelled(job)
+		}
+
+	case StatusRetrying:
+		// Aguardando backoff: remove do agendamento
+		removed, err := q.client.ZRem(q.ctx, JobsDelayed, jobID).Result()
+		if err != nil {
+			return nil, err
+		}
+		if removed > 0 {
+			return job, q.markCancelled(job)
+		}
+	}
+
+	// Em execução (ou acabou de ser pego): deixa o pedido gravado e avisa os workers
+	if err := q.client.Set(q.ctx, q.cancelFlagKey(jobID), "1", cancelFlagTTL).Err(); err != nil {
+		return nil, err
+	}
+	if err := q.client.Publish(q.ctx, JobsCancelChannel, jobID).Err(); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// IsCancelRequested - verifica se há pedido de cancelamento para o job
+func (q *RedisQueue) IsCancelRequested(jobID string) bool {
+	exists, err := q.client.Exists(q.ctx, q.cancelFlagKey(jobID)).Result()
+	return err == nil && exists > 0
+}
+
+// SubscribeCancellations - recebe IDs de jobs cancelados enquanto o ctx estiver ativo
+func (q *RedisQueue) SubscribeCancellations(ctx context.Context) <-chan string {
+	pubsub := q.client.Subscribe(ctx, JobsCancelChannel)
+	jobIDs := make(chan string)
+
+	go func() {
+		defer close(jobIDs)
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				select {
+				case jobIDs <- msg.Payload:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return jobIDs
+}
+
+// MarkCancelled - finaliza como cancelado um job que estava em execução
+// ErrLeaseLost se o lease não é mais deste worker (o reaper devolveu o job para a fila)
+func (q *RedisQueue) MarkCancelled(jobID string, lease Lease) error {
+	if err := q.takeLease(jobID, lease, false); err != nil {
+		return err
+	}
+
+	job, err := q.GetJobStatus(jobID)
+	if err != nil {
+		return err
+	}
+
+	q.releaseLease(jobID)
+	return q.markCancelled(job)
+}
+
+// markCancelled - grava o status final de cancelamento
+func (q *RedisQueue) markCancelled(job *Job) error {
+	job.Status = StatusCancelled
+	job.Error = "job cancelado pelo usuário"
+	job.WorkerID = ""
+	job.NextAttemptAt = nil
+	job.SealedPassword = ""
+
+	if err := q.UpdateJob(job); err != nil {
+		return fmt.Errorf("erro ao cancelar job %s: %w", job.ID, err)
+	}
+
+	q.client.Del(q.ctx, q.cancelFlagKey(job.ID))
+	return nil
+}
+
+// cancelFlagKey - chave do pedido de cancelamento
+func (q *RedisQueue) cancelFlagKey(jobID string) string {
+	return fmt.Sprintf("%s%s%s", JobsKeyPrefix, jobID, cancelFlagSuffix)
+}
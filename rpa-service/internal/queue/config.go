@@ -0,0 +1,27 @@
+package queue
+
+import "time"
+
+// QueueConfig - configurações da fila
+type QueueConfig struct {
+	VisibilityTimeout time.Duration // Tempo que um job fica reservado sem heartbeat
+	HeartbeatInterval time.Duration // Intervalo entre heartbeats do worker
+	ReapInterval      time.Duration // Intervalo entre varreduras de leases expirados
+	PromoteInterval   time.Duration // Intervalo entre promoções de jobs agendados (retry)
+	Retry             RetryPolicy   // Política de novas tentativas
+	ResultTTL         time.Duration // Janela de frescor do cache de resultados por CPF (0 = desligado)
+	LoginFailureLimit int           // Falhas de credencial seguidas até bloquear o usuário (0 = desligado)
+}
+
+// DefaultQueueConfig - configuração padrão da fila
+func DefaultQueueConfig() QueueConfig {
+	return QueueConfig{
+		VisibilityTimeout: 2 * time.Minute,
+		HeartbeatInterval: 30 * time.Second,
+		ReapInterval:      30 * time.Second,
+		PromoteInterval:   5 * time.Second,
+		Retry:             DefaultRetryPolicy(),
+		ResultTTL:         time.Hour,
+		LoginFailureLimit: 2, // O portal bloqueia a conta na 3ª senha errada
+	}
+}
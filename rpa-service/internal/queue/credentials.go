@@ -0,0 +1,151 @@
+package queue
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// CredentialKeysEnv - variável com as chaves AES (formato "id:base64,id:base64")
+// A primeira chave é a ativa; as demais só são usadas para decifrar (rotação)
+const CredentialKeysEnv = "CREDENTIALS_KEYS"
+
+// ErrUnknownCredentialKey - senha cifrada com uma chave que não está mais configurada
+var ErrUnknownCredentialKey = errors.New("chave de credenciais desconhecida")
+
+// CredentialCipher - cifra senhas dos jobs com AES-256-GCM
+type CredentialCipher struct {
+	activeKeyID string
+	keys        map[string]cipher.AEAD
+	tagKey      []byte // Chave do CredentialTag (derivada da chave ativa)
+}
+
+// NewCredentialCipher - cria cifrador a partir de chaves de 32 bytes
+func NewCredentialCipher(activeKeyID string, keys map[string][]byte) (*CredentialCipher, error) {
+	if _, ok := keys[activeKeyID]; !ok {
+		return nil, fmt.Errorf("chave ativa %q não informada", activeKeyID)
+	}
+
+	tagMAC := hmac.New(sha256.New, keys[activeKeyID])
+	tagMAC.Write([]byte("rpa-credential-tag"))
+
+	c := &CredentialCipher{
+		activeKeyID: activeKeyID,
+		keys:        make(map[string]cipher.AEAD, len(keys)),
+		tagKey:      tagMAC.Sum(nil),
+	}
+
+	for id, key := range keys {
+		if strings.ContainsAny(id, ":,") {
+			return nil, fmt.Errorf("id de chave inválido: %q", id)
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("chave %q deve ter 32 bytes (tem %d)", id, len(key))
+		}
+
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		c.keys[id] = aead
+	}
+
+	return c, nil
+}
+
+// NewCredentialCipherFromEnv - lê as chaves de CREDENTIALS_KEYS
+// Ex: CREDENTIALS_KEYS="2025-11:<base64>,2025-06:<base64>" (gere com: openssl rand -base64 32)
+func NewCredentialCipherFromEnv() (*CredentialCipher, error) {
+	raw := strings.TrimSpace(os.Getenv(CredentialKeysEnv))
+	if raw == "" {
+		return nil, fmt.Errorf("%s não configurada (ex: %s=\"v1:$(openssl rand -base64 32)\")", CredentialKeysEnv, CredentialKeysEnv)
+	}
+
+	var activeKeyID string
+	keys := make(map[string][]byte)
+
+	for _, entry := range strings.Split(raw, ",") {
+		id, encoded, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("%s: entrada inválida %q (esperado id:base64)", CredentialKeysEnv, entry)
+		}
+
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("%s: chave %q não é base64 válido: %w", CredentialKeysEnv, id, err)
+		}
+
+		if activeKeyID == "" {
+			activeKeyID = id
+		}
+		keys[id] = key
+	}
+
+	return NewCredentialCipher(activeKeyID, keys)
+}
+
+// Seal - cifra a senha, amarrando o resultado ao job (associated data)
+// Formato: "<keyID>:<base64(nonce || ciphertext)>"
+func (c *CredentialCipher) Seal(plaintext, jobID string) (string, error) {
+	aead := c.keys[c.activeKeyID]
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(jobID))
+	return c.activeKeyID + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Open - decifra uma senha gerada por Seal
+func (c *CredentialCipher) Open(sealed, jobID string) (string, error) {
+	keyID, encoded, ok := strings.Cut(sealed, ":")
+	if !ok {
+		return "", errors.New("credencial cifrada em formato inválido")
+	}
+
+	aead, ok := c.keys[keyID]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownCredentialKey, keyID)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < aead.NonceSize() {
+		return "", errors.New("credencial cifrada truncada")
+	}
+
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(jobID))
+	if err != nil {
+		return "", fmt.Errorf("falha ao decifrar credencial: %w", err)
+	}
+
+	return string(plaintext), nil
+}
+
+// Tag - impressão digital de usuário + senha (mesmas credenciais = mesma tag)
+// Trocar a chave ativa muda todas as tags: o cache antigo só deixa de ser usado
+func (c *CredentialCipher) Tag(username, password string) string {
+	return credentialTag(c.tagKey, username, password)
+}
+
+// NeedsRotation - indica se a senha foi cifrada com uma chave que não é a ativa
+func (c *CredentialCipher) NeedsRotation(sealed string) bool {
+	keyID, _, _ := strings.Cut(sealed, ":")
+	return keyID != c.activeKeyID
+}
@@ -0,0 +1,115 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const eventsChannelSuffix = ":events" // rpa:job:<id>:events - pub/sub de eventos do job
+
+// JobEvent - snapshot do job publicado a cada mudança de status ou etapa
+type JobEvent struct {
+	JobID     string    `json:"job_id"`
+	Status    string    `json:"status"`
+	Stage     string    `json:"stage,omitempty"`
+	Progress  int       `json:"progress"`
+	Message   string    `json:"message,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// IsFinal - evento de status terminal (não haverá outros)
+func (e JobEvent) IsFinal() bool {
+	return IsFinalStatus(e.Status)
+}
+
+// IsFinalStatus - status em que o job não muda mais
+func IsFinalStatus(status string) bool {
+	return status == StatusCompleted || status == StatusFailed || status == StatusCancelled
+}
+
+// NewJobEvent - cria evento a partir do estado atual do job
+func NewJobEvent(job *Job) JobEvent {
+	return JobEvent{
+		JobID:     job.ID,
+		Status:    job.Status,
+		Stage:     job.Stage,
+		Progress:  job.Progress,
+		Message:   job.StageMessage,
+		Error:     job.Error,
+		Timestamp: job.UpdatedAt,
+	}
+}
+
+// PublishProgress - grava a etapa atual no job e publica o evento
+func (q *RedisQueue) PublishProgress(jobID, stage string, progress int, message string) error {
+	job, err := q.GetJobStatus(jobID)
+	if err != nil {
+		return err
+	}
+
+	job.Stage = stage
+	job.Progress = progress
+	job.StageMessage = message
+
+	// UpdateJob publica o evento
+	return q.UpdateJob(job)
+}
+
+// SubscribeEvents - recebe os eventos do job enquanto o ctx estiver ativo
+func (q *RedisQueue) SubscribeEvents(ctx context.Context, jobID string) (<-chan JobEvent, error) {
+	pubsub := q.client.Subscribe(ctx, q.eventsChannel(jobID))
+
+	// Garante que a inscrição está ativa antes de retornar (não perde eventos)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	events := make(chan JobEvent)
+	go func() {
+		defer close(events)
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+
+				var event JobEvent
+				if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+					continue
+				}
+
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// publishEvent - publica o snapshot do job no canal de eventos
+func (q *RedisQueue) publishEvent(job *Job) error {
+	payload, err := json.Marshal(NewJobEvent(job))
+	if err != nil {
+		return err
+	}
+	return q.client.Publish(q.ctx, q.eventsChannel(job.ID), payload).Err()
+}
+
+// eventsChannel - canal pub/sub de eventos do job
+func (q *RedisQueue) eventsChannel(jobID string) string {
+	return fmt.Sprintf("%s%s%s", JobsKeyPrefix, jobID, eventsChannelSuffix)
+}
@@ -2,20 +2,92 @@ package queue
 
 import (
 	"encoding/json"
+	"errors"
+	"strings"
 	"time"
+
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/models"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/pkg/documents"
+)
+
+// Status possíveis de um job
+const (
+	StatusPending    = "pending"
+	StatusProcessing = "processing"
+	StatusCompleted  = "completed"
+	StatusFailed     = "failed"
+	StatusRetrying   = "retrying"
+	StatusCancelled  = "cancelled"
 )
 
 // Job - representa um trabalho na fila
 type Job struct {
 	ID        string    `json:"id"`
 	Username  string    `json:"username"`
-	Password  string    `json:"password"`
-	CPF       string    `json:"cpf"`
-	Status    string    `json:"status"` // pending, processing, completed, failed
+	Password  string    `json:"-"`      // Nunca é persistida: só existe em memória no worker
+	CPF       string    `json:"cpf"`    // Só em buscas por CPF (o valor de qualquer busca fica em SearchValue)
+	Status    string    `json:"status"` // pending, processing, retrying, completed, failed, cancelled
 	Result    string    `json:"result,omitempty"`
 	Error     string    `json:"error,omitempty"`
+	ErrorCode string    `json:"error_code,omitempty"` // Código estável do erro (ex: invalid_credentials)
+	Attempts  int       `json:"attempts"`
+	WorkerID  string    `json:"worker_id,omitempty"`
+	BatchID   string    `json:"batch_id,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+
+	// Progresso da automação
+	Stage        string `json:"stage,omitempty"`
+	Progress     int    `json:"progress"`
+	StageMessage string `json:"stage_message,omitempty"`
+
+	// Senha cifrada (AES-GCM); apagada quando o job termina
+	SealedPassword string `json:"sealed_password,omitempty"`
+
+	// HMAC de usuário + senha: só compartilha job e cache com as mesmas credenciais
+	CredentialTag string `json:"credential_tag,omitempty"`
+
+	// Política de tentativas
+	MaxAttempts   int        `json:"max_attempts"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+
+	// Resultado servido do cache (sem abrir o Chrome)
+	Cached bool `json:"cached,omitempty"`
+
+	// Webhook chamado quando o job termina (opcional)
+	CallbackURL string `json:"callback_url,omitempty"`
+
+	// Propostas a extrair quando o CPF tem várias (vazio = first)
+	ProposalSelection models.ProposalSelection `json:"proposal_selection,omitempty"`
+
+	// Tipo da busca (vazio = cpf) e o valor buscado (CPF, CNPJ, proposta ou contrato)
+	SearchType  models.SearchType `json:"search_type,omitempty"`
+	SearchValue string            `json:"search_value,omitempty"`
+}
+
+// JobOptions - opções por job informadas na submissão
+type JobOptions struct {
+	MaxAttempts  int    // 0 = usa o padrão da RetryPolicy
+	BatchID      string // Lote ao qual o job pertence (opcional)
+	ForceRefresh bool   // Ignora o cache de resultados e consulta o portal
+	CallbackURL  string // Webhook chamado quando o job termina (opcional)
+
+	ProposalSelection models.ProposalSelection // Vazio = first
+	SearchType        models.SearchType        // Vazio = cpf
+}
+
+// codedError - erro com código estável para a API (ex: falhas de login classificadas)
+type codedError interface {
+	ErrorCode() string
+}
+
+// ErrorCodeOf - código estável do erro (vazio se o erro não tiver um)
+func ErrorCodeOf(err error) string {
+	var coded codedError
+	if errors.As(err, &coded) {
+		return coded.ErrorCode()
+	}
+	return ""
 }
 
 // ToJSON - converte Job para JSON
@@ -24,9 +96,83 @@ func (j *Job) ToJSON() (string, error) {
 	return string(data), err
 }
 
+// ParseResult - converte o Result (JSON) de volta para ClientData
+// Com várias propostas, retorna a primeira (as demais em ParseResults)
+func (j *Job) ParseResult() (*models.ClientData, error) {
+	results, err := j.ParseResults()
+	if err != nil || len(results) == 0 {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+// ParseResults - todos os ClientData do Result (array com proposal_selection "all")
+func (j *Job) ParseResults() ([]*models.ClientData, error) {
+	result := strings.TrimSpace(j.Result)
+	if result == "" {
+		return nil, nil
+	}
+
+	if strings.HasPrefix(result, "[") {
+		var results []*models.ClientData
+		if err := json.Unmarshal([]byte(result), &results); err != nil {
+			return nil, err
+		}
+		return results, nil
+	}
+
+	var data models.ClientData
+	if err := json.Unmarshal([]byte(result), &data); err != nil {
+		return nil, err
+	}
+	return []*models.ClientData{&data}, nil
+}
+
+// Cacheable - o resultado do job vale para o cache por CPF
+// O cache guarda só buscas por CPF com a seleção padrão (primeira proposta)
+func (j *Job) Cacheable() bool {
+	return j.searchByCPF() && cacheableSelection(j.ProposalSelection)
+}
+
+// setSearch - guarda o tipo e o valor buscado; o campo CPF só recebe CPFs
+func (j *Job) setSearch(searchType models.SearchType, value string) {
+	j.SearchType = searchType
+	j.SearchValue = value
+	if j.searchByCPF() {
+		j.CPF = value
+	}
+}
+
+// SearchedValue - valor buscado (jobs antigos só têm o campo CPF, qualquer que seja o tipo)
+func (j *Job) SearchedValue() string {
+	if j.SearchValue != "" {
+		return j.SearchValue
+	}
+	return j.CPF
+}
+
+// searchByCPF - o job busca por CPF (jobs antigos não têm SearchType)
+func (j *Job) searchByCPF() bool {
+	return j.SearchType == "" || j.SearchType == models.SearchTypeCPF
+}
+
+// searchKey - chave do valor buscado pelo usuário (CPF sem formatação; outros tipos prefixados)
+// Cada usuário tem a própria chave: o job roda com as credenciais de quem o criou
+func (j *Job) searchKey() string {
+	if j.searchByCPF() {
+		return loginKey(j.Username) + ":" + cpfKey(j.CPF)
+	}
+	return loginKey(j.Username) + ":" + string(j.SearchType) + ":" + documents.OnlyDigits(j.SearchedValue())
+}
+
+// cacheableSelection - a seleção de propostas usa o cache por CPF
+func cacheableSelection(selection models.ProposalSelection) bool {
+	return selection == "" || selection == models.ProposalFirst
+}
+
 // FromJSON - converte JSON para Job
 func FromJSON(data string) (*Job, error) {
 	var job Job
 	err := json.Unmarshal([]byte(data), &job)
 	return &job, err
-}
\ No newline at end of file
+}
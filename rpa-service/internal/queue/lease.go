@@ -0,0 +1,96 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	leaseOwnerSuffix = ":lease"   // rpa:job:<id>:lease - dono do lease atual (<worker>:<tentativa>)
+	leaseReleased    = "released" // Lease finalizado (por quem terminou o job ou pelo reaper)
+	leaseOwnerTTL    = 24 * time.Hour
+)
+
+// ErrLeaseLost - o lease do job expirou e foi recolhido pelo reaper
+// (ou outro worker já está numa tentativa seguinte)
+var ErrLeaseLost = errors.New("lease do job perdido")
+
+// Lease - posse de um job em execução: o worker e a tentativa que ele está rodando
+// Só o dono do lease atual renova (Heartbeat) ou finaliza (Complete/Retry/Fail) o job
+type Lease struct {
+	WorkerID string
+	Attempt  int
+}
+
+// Lease - lease da tentativa atual do job (como devolvido por GetNextJob)
+func (j *Job) Lease() Lease {
+	return Lease{WorkerID: j.WorkerID, Attempt: j.Attempts}
+}
+
+func (l Lease) String() string {
+	return fmt.Sprintf("%s:%d", l.WorkerID, l.Attempt)
+}
+
+// takeLeaseScript - compare-and-set do dono do lease: só quem ainda é o dono finaliza o job
+// ARGV[2] == "1" aceita job sem dono registrado (jobs anteriores ao registro do dono, pelo reaper)
+var takeLeaseScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[1])
+if current == ARGV[1] or (current == false and ARGV[2] == '1') then
+	redis.call('SET', KEYS[1], ARGV[3], 'EX', ARGV[4])
+	return 1
+end
+return 0
+`)
+
+// setLeaseOwner - registra o worker e a tentativa donos do lease (no claim)
+func (q *RedisQueue) setLeaseOwner(job *Job) error {
+	return q.client.Set(q.ctx, q.leaseOwnerKey(job.ID), job.Lease().String(), leaseOwnerTTL).Err()
+}
+
+// ownsLease - o lease ainda é deste worker/tentativa
+func (q *RedisQueue) ownsLease(jobID string, lease Lease) (bool, error) {
+	owner, err := q.client.Get(q.ctx, q.leaseOwnerKey(jobID)).Result()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return owner == lease.String(), nil
+}
+
+// takeLease - assume a finalização do job (ErrLeaseLost se o lease não for mais deste worker)
+// Depois disso nem o reaper nem o worker antigo conseguem gravar o job
+func (q *RedisQueue) takeLease(jobID string, lease Lease, allowUnowned bool) error {
+	allow := "0"
+	if allowUnowned {
+		allow = "1"
+	}
+	taken, err := takeLeaseScript.Run(q.ctx, q.client, []string{q.leaseOwnerKey(jobID)},
+		lease.String(), allow, leaseReleased, int(leaseOwnerTTL.Seconds())).Int()
+	if err != nil {
+		return err
+	}
+	if taken == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// leaseOwnerKey - chave do dono do lease
+func (q *RedisQueue) leaseOwnerKey(jobID string) string {
+	return JobsKeyPrefix + jobID + leaseOwnerSuffix
+}
+
+// ownsLeaseLocked - o job está em execução por este worker/tentativa (fila em memória)
+func (q *MemoryQueue) ownsLeaseLocked(jobID string, lease Lease) bool {
+	job, ok := q.jobs[jobID]
+	if !ok || job.Status != StatusProcessing || job.Lease() != lease {
+		return false
+	}
+	_, leased := q.leases[jobID]
+	return leased
+}
@@ -0,0 +1,290 @@
+package queue
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	JobsIndexCreated  = "rpa:jobs:index:created" // ZSET: todos os jobs por data de criação (ms)
+	JobsIndexStatus   = "rpa:jobs:index:status:" // ZSET por status: rpa:jobs:index:status:<status>
+	JobsIndexCPF      = "rpa:jobs:index:cpf:"    // ZSET por CPF: rpa:jobs:index:cpf:<cpf>
+	JobsIndexUsername = "rpa:jobs:index:user:"   // ZSET por usuário: rpa:jobs:index:user:<loginKey(username)>
+	jobRetention      = 24 * time.Hour           // Mesmo TTL dos jobs: índices mais velhos são podados
+	DefaultListLimit  = 20
+	MaxListLimit      = 100
+)
+
+// ErrInvalidCursor - cursor de paginação malformado
+var ErrInvalidCursor = errors.New("cursor inválido")
+
+// allStatuses - todos os status possíveis (um índice por status)
+var allStatuses = []string{
+	StatusPending,
+	StatusProcessing,
+	StatusCompleted,
+	StatusFailed,
+	StatusRetrying,
+	StatusCancelled,
+}
+
+// ValidStatus - verifica se o status existe
+func ValidStatus(status string) bool {
+	return containsString(allStatuses, status)
+}
+
+// JobFilter - filtros da listagem de jobs (campos vazios não filtram)
+type JobFilter struct {
+	Statuses    []string
+	CPF         string
+	Username    string
+	CreatedFrom time.Time // Inclusivo
+	CreatedTo   time.Time // Inclusivo
+	Cursor      string    // NextCursor da página anterior
+	Limit       int       // 0 = DefaultListLimit
+}
+
+// JobPage - uma página da listagem (mais recentes primeiro)
+type JobPage struct {
+	Jobs       []*Job
+	NextCursor string // Vazio na última página
+}
+
+// Matches - verifica se o job passa nos filtros (exceto cursor)
+func (f JobFilter) Matches(job *Job) bool {
+	if len(f.Statuses) > 0 && !containsString(f.Statuses, job.Status) {
+		return false
+	}
+	if f.CPF != "" && cpfKey(job.CPF) != cpfKey(f.CPF) {
+		return false
+	}
+	if f.Username != "" && loginKey(job.Username) != loginKey(f.Username) {
+		return false
+	}
+	if !f.CreatedFrom.IsZero() && job.CreatedAt.Before(f.CreatedFrom) {
+		return false
+	}
+	if !f.CreatedTo.IsZero() && job.CreatedAt.After(f.CreatedTo) {
+		return false
+	}
+	return true
+}
+
+// limit - tamanho da página dentro dos limites
+func (f JobFilter) limit() int {
+	if f.Limit <= 0 {
+		return DefaultListLimit
+	}
+	if f.Limit > MaxListLimit {
+		return MaxListLimit
+	}
+	return f.Limit
+}
+
+// listCursor - posição na ordenação (criação desc, ID desc)
+type listCursor struct {
+	score int64
+	jobID string
+}
+
+// encodeCursor - cursor opaco para o cliente
+func encodeCursor(job *Job) string {
+	raw := fmt.Sprintf("%d:%s", job.CreatedAt.UnixMilli(), job.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeCursor - lê o cursor (nil se vazio)
+func decodeCursor(cursor string) (*listCursor, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	parts := strings.SplitN(string(raw), ":", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, ErrInvalidCursor
+	}
+
+	score, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &listCursor{score: score, jobID: parts[1]}, nil
+}
+
+// after - true se a entrada vem depois do cursor na ordenação
+func (c *listCursor) after(score int64, jobID string) bool {
+	if c == nil {
+		return true
+	}
+	return score < c.score || (score == c.score && jobID < c.jobID)
+}
+
+// ListJobs - lista jobs com filtros e paginação por cursor
+// Usa o índice mais seletivo disponível e filtra o restante ao ler os jobs
+func (q *RedisQueue) ListJobs(filter JobFilter) (*JobPage, error) {
+	cursor, err := decodeCursor(filter.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	index := q.pickIndex(filter)
+	q.pruneIndex(index)
+
+	limit := filter.limit()
+	page := &JobPage{Jobs: make([]*Job, 0, limit)}
+
+	// Faixa de scores: criação (e cursor) limitam a varredura
+	max := "+inf"
+	if !filter.CreatedTo.IsZero() {
+		max = strconv.FormatInt(filter.CreatedTo.UnixMilli(), 10)
+	}
+	if cursor != nil && (max == "+inf" || cursor.score < filter.CreatedTo.UnixMilli()) {
+		max = strconv.FormatInt(cursor.score, 10)
+	}
+	min := "-inf"
+	if !filter.CreatedFrom.IsZero() {
+		min = strconv.FormatInt(filter.CreatedFrom.UnixMilli(), 10)
+	}
+
+	var offset int64
+	batch := int64(limit * 2)
+
+	// IDs de jobs expirados saem do índice só no fim (não desloca o offset da varredura)
+	var orphans []interface{}
+	defer func() {
+		if len(orphans) > 0 {
+			q.client.ZRem(q.ctx, index, orphans...)
+		}
+	}()
+
+	for {
+		entries, err := q.client.ZRevRangeByScoreWithScores(q.ctx, index, &redis.ZRangeBy{
+			Min:    min,
+			Max:    max,
+			Offset: offset,
+			Count:  batch,
+		}).Result()
+		if err != nil {
+			return nil, err
+		}
+		offset += int64(len(entries))
+
+		for _, entry := range entries {
+			jobID := entry.Member.(string)
+			score := int64(entry.Score)
+			if !cursor.after(score, jobID) {
+				continue
+			}
+
+			job, err := q.GetJobStatus(jobID)
+			if errors.Is(err, ErrJobNotFound) {
+				orphans = append(orphans, jobID)
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			if !filter.Matches(job) {
+				continue
+			}
+
+			if len(page.Jobs) == limit {
+				page.NextCursor = encodeCursor(page.Jobs[limit-1])
+				return page, nil
+			}
+			page.Jobs = append(page.Jobs, job)
+		}
+
+		if int64(len(entries)) < batch {
+			return page, nil
+		}
+	}
+}
+
+// pickIndex - índice mais seletivo para os filtros informados
+func (q *RedisQueue) pickIndex(filter JobFilter) string {
+	switch {
+	case filter.CPF != "":
+		return JobsIndexCPF + cpfKey(filter.CPF)
+	case filter.Username != "":
+		return JobsIndexUsername + loginKey(filter.Username)
+	case len(filter.Statuses) == 1:
+		return JobsIndexStatus + filter.Statuses[0]
+	default:
+		return JobsIndexCreated
+	}
+}
+
+// pruneIndex - remove do índice jobs mais velhos que o TTL dos jobs
+func (q *RedisQueue) pruneIndex(index string) {
+	cutoff := time.Now().Add(-jobRetention).UnixMilli()
+	q.client.ZRemRangeByScore(q.ctx, index, "-inf", strconv.FormatInt(cutoff, 10))
+}
+
+// indexJob - mantém os índices secundários do job (criação, status, CPF e usuário)
+func (q *RedisQueue) indexJob(job *Job) error {
+	member := &redis.Z{
+		Score:  float64(job.CreatedAt.UnixMilli()),
+		Member: job.ID,
+	}
+	userIndex := JobsIndexUsername + loginKey(job.Username)
+
+	_, err := q.client.Pipelined(q.ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(q.ctx, JobsIndexCreated, member)
+
+		// Só buscas por CPF entram no índice de CPF
+		if job.CPF != "" {
+			cpfIndex := JobsIndexCPF + cpfKey(job.CPF)
+			pipe.ZAdd(q.ctx, cpfIndex, member)
+			pipe.Expire(q.ctx, cpfIndex, jobRetention)
+		}
+
+		pipe.ZAdd(q.ctx, userIndex, member)
+		pipe.Expire(q.ctx, userIndex, jobRetention)
+
+		// O job fica apenas no índice do status atual
+		for _, status := range allStatuses {
+			if status == job.Status {
+				pipe.ZAdd(q.ctx, JobsIndexStatus+status, member)
+			} else {
+				pipe.ZRem(q.ctx, JobsIndexStatus+status, job.ID)
+			}
+		}
+		return nil
+	})
+	return err
+}
+
+// sortJobsForListing - ordena como os índices do Redis (criação desc, ID desc)
+func sortJobsForListing(jobs []*Job) {
+	sort.Slice(jobs, func(i, j int) bool {
+		si, sj := jobs[i].CreatedAt.UnixMilli(), jobs[j].CreatedAt.UnixMilli()
+		if si != sj {
+			return si > sj
+		}
+		return jobs[i].ID > jobs[j].ID
+	})
+}
+
+// containsString - verifica se o valor está na lista
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,372 @@
+package queue
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	LoginFailuresPrefix = "rpa:login:failures:" // rpa:login:failures:<username> - falhas de credencial seguidas
+	LoginBlocksKey      = "rpa:login:blocks"    // HASH username → LoginBlock (JSON), até o operador liberar
+	loginFailuresTTL    = 24 * time.Hour        // Falhas antigas deixam de contar
+)
+
+var (
+	// ErrLoginBlocked - usuário bloqueado pelo protetor de falhas de login
+	ErrLoginBlocked = errors.New("login bloqueado para o usuário")
+
+	// ErrLoginBlockNotFound - usuário não está bloqueado
+	ErrLoginBlockNotFound = errors.New("usuário não está bloqueado")
+)
+
+// LoginBlock - bloqueio de um usuário após falhas de credencial seguidas
+type LoginBlock struct {
+	Username   string    `json:"username"`
+	Failures   int       `json:"failures"`
+	Reason     string    `json:"reason"` // Último erro do portal
+	BlockedAt  time.Time `json:"blocked_at"`
+	FailedJobs []string  `json:"failed_jobs,omitempty"` // Jobs na fila falhados no momento do bloqueio
+}
+
+// LoginBlockedError - job/login recusado porque o usuário está bloqueado
+type LoginBlockedError struct {
+	Block *LoginBlock
+}
+
+func (e *LoginBlockedError) Error() string {
+	return fmt.Sprintf("%s %s após %d falha(s) de credencial (%s); um operador precisa liberar em DELETE /api/login-blocks/%s",
+		ErrLoginBlocked, e.Block.Username, e.Block.Failures, e.Block.Reason, e.Block.Username)
+}
+
+func (e *LoginBlockedError) Unwrap() error { return ErrLoginBlocked }
+
+// ErrorCode - código estável do erro
+func (e *LoginBlockedError) ErrorCode() string { return "login_blocked" }
+
+// LoginGuard - evita que senhas erradas repetidas bloqueiem a conta do correspondente no portal
+type LoginGuard interface {
+	// RecordLoginFailure - conta uma falha de credencial; devolve o bloqueio se o limite foi atingido agora
+	RecordLoginFailure(username, reason string) (*LoginBlock, error)
+	// RecordLoginSuccess - zera a contagem de falhas
+	RecordLoginSuccess(username string) error
+	// BlockLogin - bloqueia na hora (ex: portal já informou conta bloqueada); nil se já estava bloqueado
+	BlockLogin(username, reason string) (*LoginBlock, error)
+	// GetLoginBlock - bloqueio atual (nil, nil se o usuário está liberado)
+	GetLoginBlock(username string) (*LoginBlock, error)
+	ListLoginBlocks() ([]*LoginBlock, error)
+	// ClearLoginBlock - liberação manual pelo operador
+	ClearLoginBlock(username string) error
+}
+
+// RecordLoginFailure - conta uma falha de credencial do usuário
+func (q *RedisQueue) RecordLoginFailure(username, reason string) (*LoginBlock, error) {
+	if q.config.LoginFailureLimit <= 0 {
+		return nil, nil
+	}
+
+	key := LoginFailuresPrefix + loginKey(username)
+	failures, err := q.client.Incr(q.ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+	q.client.Expire(q.ctx, key, loginFailuresTTL)
+
+	if int(failures) < q.config.LoginFailureLimit {
+		return nil, nil
+	}
+	return q.block(username, int(failures), reason)
+}
+
+// RecordLoginSuccess - zera a contagem de falhas do usuário
+func (q *RedisQueue) RecordLoginSuccess(username string) error {
+	return q.client.Del(q.ctx, LoginFailuresPrefix+loginKey(username)).Err()
+}
+
+// BlockLogin - bloqueia o usuário imediatamente
+func (q *RedisQueue) BlockLogin(username, reason string) (*LoginBlock, error) {
+	failures, err := q.client.Get(q.ctx, LoginFailuresPrefix+loginKey(username)).Int()
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+	return q.block(username, failures, reason)
+}
+
+// GetLoginBlock - bloqueio atual do usuário (nil se liberado)
+func (q *RedisQueue) GetLoginBlock(username string) (*LoginBlock, error) {
+	blockJSON, err := q.client.HGet(q.ctx, LoginBlocksKey, loginKey(username)).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var block LoginBlock
+	if err := json.Unmarshal([]byte(blockJSON), &block); err != nil {
+		return nil, err
+	}
+	return &block, nil
+}
+
+// ListLoginBlocks - usuários bloqueados, mais recentes primeiro
+func (q *RedisQueue) ListLoginBlocks() ([]*LoginBlock, error) {
+	entries, err := q.client.HGetAll(q.ctx, LoginBlocksKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	blocks := make([]*LoginBlock, 0, len(entries))
+	for _, blockJSON := range entries {
+		var block LoginBlock
+		if err := json.Unmarshal([]byte(blockJSON), &block); err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, &block)
+	}
+	sortLoginBlocks(blocks)
+	return blocks, nil
+}
+
+// ClearLoginBlock - libera o usuário e zera as falhas
+func (q *RedisQueue) ClearLoginBlock(username string) error {
+	removed, err := q.client.HDel(q.ctx, LoginBlocksKey, loginKey(username)).Result()
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		return ErrLoginBlockNotFound
+	}
+	return q.RecordLoginSuccess(username)
+}
+
+// block - grava o bloqueio (só o primeiro a bloquear varre a fila) e falha os jobs em espera
+func (q *RedisQueue) block(username string, failures int, reason string) (*LoginBlock, error) {
+	block := &LoginBlock{
+		Username:  username,
+		Failures:  failures,
+		Reason:    reason,
+		BlockedAt: time.Now(),
+	}
+
+	blockJSON, err := json.Marshal(block)
+	if err != nil {
+		return nil, err
+	}
+	created, err := q.client.HSetNX(q.ctx, LoginBlocksKey, loginKey(username), blockJSON).Result()
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, nil // Outro worker já bloqueou
+	}
+
+	block.FailedJobs, err = q.failQueuedJobs(block)
+	if err != nil {
+		return block, err
+	}
+
+	// Grava de novo com os jobs falhados (para o operador saber o que reenviar)
+	if len(block.FailedJobs) > 0 {
+		if blockJSON, err = json.Marshal(block); err == nil {
+			q.client.HSet(q.ctx, LoginBlocksKey, loginKey(username), blockJSON)
+		}
+	}
+	return block, nil
+}
+
+// failQueuedJobs - falha os jobs do usuário que ainda esperam na fila ou no backoff
+func (q *RedisQueue) failQueuedJobs(block *LoginBlock) ([]string, error) {
+	jobIDs, err := q.client.ZRange(q.ctx, JobsIndexUsername+loginKey(block.Username), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	blockedErr := &LoginBlockedError{Block: block}
+	var failed []string
+	for _, jobID := range jobIDs {
+		job, err := q.GetJobStatus(jobID)
+		if errors.Is(err, ErrJobNotFound) {
+			continue
+		}
+		if err != nil {
+			return failed, err
+		}
+
+		// Só tira o job de onde ele está; se outro worker pegou antes, ele falha no início do processamento
+		var removed int64
+		switch job.Status {
+		case StatusPending:
+			removed, err = q.client.LRem(q.ctx, JobsQueue, 0, jobID).Result()
+		case StatusRetrying:
+			removed, err = q.client.ZRem(q.ctx, JobsDelayed, jobID).Result()
+		default:
+			continue
+		}
+		if err != nil {
+			return failed, err
+		}
+		if removed == 0 {
+			continue
+		}
+
+		failLoginBlocked(job, blockedErr)
+		if err := q.UpdateJob(job); err != nil {
+			return failed, err
+		}
+		failed = append(failed, jobID)
+	}
+	return failed, nil
+}
+
+// failLoginBlocked - marca o job como falho pelo bloqueio do usuário
+func failLoginBlocked(job *Job, blockedErr *LoginBlockedError) {
+	job.Status = StatusFailed
+	job.Error = blockedErr.Error()
+	job.ErrorCode = blockedErr.ErrorCode()
+	job.SealedPassword = ""
+	job.WorkerID = ""
+	job.NextAttemptAt = nil
+}
+
+// loginKey - usuário sem espaços e em minúsculas (o portal não diferencia)
+func loginKey(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+// sortLoginBlocks - mais recentes primeiro
+func sortLoginBlocks(blocks []*LoginBlock) {
+	sort.Slice(blocks, func(i, j int) bool {
+		return blocks[i].BlockedAt.After(blocks[j].BlockedAt)
+	})
+}
+
+// RecordLoginFailure - conta uma falha de credencial do usuário
+func (q *MemoryQueue) RecordLoginFailure(username, reason string) (*LoginBlock, error) {
+	if q.config.LoginFailureLimit <= 0 {
+		return nil, nil
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	key := loginKey(username)
+	q.failures[key]++
+	if q.failures[key] < q.config.LoginFailureLimit {
+		return nil, nil
+	}
+	return q.blockLocked(username, reason), nil
+}
+
+// RecordLoginSuccess - zera a contagem de falhas do usuário
+func (q *MemoryQueue) RecordLoginSuccess(username string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delete(q.failures, loginKey(username))
+	return nil
+}
+
+// BlockLogin - bloqueia o usuário imediatamente
+func (q *MemoryQueue) BlockLogin(username, reason string) (*LoginBlock, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.blockLocked(username, reason), nil
+}
+
+// GetLoginBlock - bloqueio atual do usuário (nil se liberado)
+func (q *MemoryQueue) GetLoginBlock(username string) (*LoginBlock, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	block, ok := q.blocks[loginKey(username)]
+	if !ok {
+		return nil, nil
+	}
+	return cloneLoginBlock(block), nil
+}
+
+// ListLoginBlocks - usuários bloqueados, mais recentes primeiro
+func (q *MemoryQueue) ListLoginBlocks() ([]*LoginBlock, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	blocks := make([]*LoginBlock, 0, len(q.blocks))
+	for _, block := range q.blocks {
+		blocks = append(blocks, cloneLoginBlock(block))
+	}
+	sortLoginBlocks(blocks)
+	return blocks, nil
+}
+
+// ClearLoginBlock - libera o usuário e zera as falhas
+func (q *MemoryQueue) ClearLoginBlock(username string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	key := loginKey(username)
+	if _, ok := q.blocks[key]; !ok {
+		return ErrLoginBlockNotFound
+	}
+	delete(q.blocks, key)
+	delete(q.failures, key)
+	return nil
+}
+
+// blockLocked - grava o bloqueio e falha os jobs do usuário em espera (nil se já estava bloqueado)
+func (q *MemoryQueue) blockLocked(username, reason string) *LoginBlock {
+	key := loginKey(username)
+	if _, blocked := q.blocks[key]; blocked {
+		return nil
+	}
+
+	block := &LoginBlock{
+		Username:  username,
+		Failures:  q.failures[key],
+		Reason:    reason,
+		BlockedAt: time.Now(),
+	}
+	q.blocks[key] = block
+
+	blockedErr := &LoginBlockedError{Block: block}
+	for jobID, stored := range q.jobs {
+		if loginKey(stored.Username) != key {
+			continue
+		}
+
+		switch stored.Status {
+		case StatusPending:
+			if !removeID(&q.pending, jobID) {
+				continue
+			}
+		case StatusRetrying:
+			if _, ok := q.delayed[jobID]; !ok {
+				continue
+			}
+			delete(q.delayed, jobID)
+		default:
+			continue
+		}
+
+		job := cloneJob(stored)
+		failLoginBlocked(job, blockedErr)
+		delete(q.passwords, jobID)
+		q.saveLocked(job)
+		block.FailedJobs = append(block.FailedJobs, jobID)
+	}
+
+	return cloneLoginBlock(block)
+}
+
+// cloneLoginBlock - cópia independente do bloqueio
+func cloneLoginBlock(block *LoginBlock) *LoginBlock {
+	copied := *block
+	copied.FailedJobs = append([]string(nil), block.FailedJobs...)
+	return &copied
+}
@@ -0,0 +1,755 @@
+package queue
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// subscriberBuffer - eventos enfileirados por assinante antes de descartar (como no pub/sub do Redis)
+const subscriberBuffer = 64
+
+// MemoryQueue - fila em memória com a mesma semântica da RedisQueue
+// Útil para testes e para rodar servidor + worker no mesmo processo sem Redis.
+// Nada sobrevive a um restart e os jobs não expiram.
+type MemoryQueue struct {
+	mu     sync.Mutex
+	config QueueConfig
+
+	jobs       map[string]*Job
+	passwords  map[string]string // Senhas ficam fora do Job, como na RedisQueue
+	batches    map[string]*Batch
+	pending    []string
+	processing []string
+	completed  []string
+	dead       []string
+	leases     map[string]time.Time
+	delayed    map[string]time.Time
+	cancelled  map[string]bool
+	results    map[string]*CachedResult // Cache de resultados por usuário:CPF
+	active     map[string]string        // usuário:CPF → job em andamento
+	tagKey     []byte                   // Chave do CredentialTag (aleatória: só vale neste processo)
+	failures   map[string]int           // Usuário → falhas de credencial seguidas
+	blocks     map[string]*LoginBlock   // Usuário → bloqueio
+
+	notify     chan struct{}
+	cancelSubs map[chan string]struct{}
+	eventSubs  map[string]map[chan JobEvent]struct{}
+}
+
+// NewMemoryQueue - cria fila em memória
+func NewMemoryQueue(config QueueConfig) *MemoryQueue {
+	return &MemoryQueue{
+		config:     config,
+		jobs:       make(map[string]*Job),
+		passwords:  make(map[string]string),
+		batches:    make(map[string]*Batch),
+		leases:     make(map[string]time.Time),
+		delayed:    make(map[string]time.Time),
+		cancelled:  make(map[string]bool),
+		results:    make(map[string]*CachedResult),
+		active:     make(map[string]string),
+		failures:   make(map[string]int),
+		blocks:     make(map[string]*LoginBlock),
+		tagKey:     randomTagKey(),
+		notify:     make(chan struct{}, 1),
+		cancelSubs: make(map[chan string]struct{}),
+		eventSubs:  make(map[string]map[chan JobEvent]struct{}),
+	}
+}
+
+// GetConfig - retorna configurações da fila
+func (q *MemoryQueue) GetConfig() QueueConfig {
+	return q.config
+}
+
+// Ping - fila em memória está sempre disponível
+func (q *MemoryQueue) Ping() error {
+	return nil
+}
+
+// Close - nada a liberar
+func (q *MemoryQueue) Close() error {
+	return nil
+}
+
+// AddJob - adiciona job na fila
+func (q *MemoryQueue) AddJob(username, password, value string, opts JobOptions) (string, error) {
+	maxAttempts := opts.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = q.config.Retry.MaxAttempts
+	}
+
+	job := &Job{
+		ID:          uuid.New().String(),
+		Username:    username,
+		Status:      StatusPending,
+		MaxAttempts: maxAttempts,
+		BatchID:     opts.BatchID,
+		CallbackURL: opts.CallbackURL,
+		CreatedAt:   time.Now(),
+
+		ProposalSelection: opts.ProposalSelection,
+		CredentialTag:     q.CredentialTag(username, password),
+	}
+	job.setSearch(opts.SearchType, value)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	// Usuário bloqueado por senha errada: nem entra na fila (nem lê o cache)
+	if block, blocked := q.blocks[loginKey(username)]; blocked {
+		return "", &LoginBlockedError{Block: cloneLoginBlock(block)}
+	}
+
+	// Resultado recente do mesmo CPF, consultado com as mesmas credenciais: responde sem abrir o Chrome
+	if cached, ok := q.cachedLocked(username, job.CPF); ok && cached.IssuedTo(job.CredentialTag) && !opts.ForceRefresh && job.Cacheable() {
+		completeFromCache(job, cached)
+		q.saveLocked(job)
+		q.completed = append(q.completed, job.ID)
+		return job.ID, nil
+	}
+
+	// Mesma busca do mesmo usuário já na fila ou executando: reaproveita o job em andamento
+	active, exists := q.jobs[q.active[job.searchKey()]]
+	switch {
+	case !exists || IsFinalStatus(active.Status):
+		q.active[job.searchKey()] = job.ID
+	case canShare(active, job, opts):
+		return active.ID, nil
+	}
+
+	q.passwords[job.ID] = password
+	q.saveLocked(job)
+	q.pending = append(q.pending, job.ID)
+
+	q.signal()
+	return job.ID, nil
+}
+
+// GetNextJob - pega próximo job da fila (espera até 5s, como o BLMove)
+func (q *MemoryQueue) GetNextJob(workerID string) (*Job, error) {
+	timeout := time.NewTimer(5 * time.Second)
+	defer timeout.Stop()
+
+	for {
+		q.mu.Lock()
+		if len(q.pending) > 0 {
+			job, err := q.claimLocked(workerID, 0)
+			q.mu.Unlock()
+			return job, err
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-q.notify:
+		case <-timeout.C:
+			return nil, nil // Fila vazia
+		}
+	}
+}
+
+// claimLocked - move o job pendente na posição index para processing e reserva o lease
+func (q *MemoryQueue) claimLocked(workerID string, index int) (*Job, error) {
+	jobID := q.pending[index]
+	q.pending = append(q.pending[:index:index], q.pending[index+1:]...)
+	q.processing = append(q.processing, jobID)
+	q.leases[jobID] = q.leaseDeadline()
+
+	job, ok := q.jobs[jobID]
+	if !ok {
+		q.releaseLeaseLocked(jobID)
+		return nil, ErrJobNotFound
+	}
+	job = cloneJob(job)
+
+	if q.cancelled[jobID] {
+		q.releaseLeaseLocked(jobID)
+		q.markCancelledLocked(job)
+		return nil, nil
+	}
+
+	job.Status = StatusProcessing
+	job.Attempts++
+	job.WorkerID = workerID
+	job.NextAttemptAt = nil
+	job.Stage = ""
+	job.StageMessage = ""
+	job.Progress = 0
+	q.saveLocked(job)
+
+	job.Password = q.passwords[jobID]
+	return job, nil
+}
+
+// UpdateJob - atualiza status do job
+func (q *MemoryQueue) UpdateJob(job *Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.saveLocked(job)
+	return nil
+}
+
+// CompleteJob - marca job como completo (ErrLeaseLost se o lease não é mais deste worker)
+func (q *MemoryQueue) CompleteJob(jobID string, lease Lease, result string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !q.ownsLeaseLocked(jobID, lease) {
+		return ErrLeaseLost
+	}
+
+	job, err := q.getLocked(jobID)
+	if err != nil {
+		return err
+	}
+
+	job.Status = StatusCompleted
+	job.Result = result
+	job.Progress = 100
+	delete(q.passwords, jobID)
+	q.saveLocked(job)
+
+	q.releaseLeaseLocked(jobID)
+	q.completed = append(q.completed, jobID)
+
+	// Próximas consultas do mesmo CPF usam este resultado
+	if job.Cacheable() {
+		q.cacheLocked(job.Username, job.CredentialTag, job.CPF, job.ID, result)
+	}
+	return nil
+}
+
+// ListJobs - lista jobs com filtros e paginação por cursor (mesma ordenação da RedisQueue)
+func (q *MemoryQueue) ListJobs(filter JobFilter) (*JobPage, error) {
+	cursor, err := decodeCursor(filter.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	q.mu.Lock()
+	jobs := make([]*Job, 0, len(q.jobs))
+	for _, job := range q.jobs {
+		if filter.Matches(job) && cursor.after(job.CreatedAt.UnixMilli(), job.ID) {
+			jobs = append(jobs, cloneJob(job))
+		}
+	}
+	q.mu.Unlock()
+
+	sortJobsForListing(jobs)
+
+	limit := filter.limit()
+	page := &JobPage{Jobs: jobs}
+	if len(jobs) > limit {
+		page.Jobs = jobs[:limit]
+		page.NextCursor = encodeCursor(jobs[limit-1])
+	}
+	return page, nil
+}
+
+// GetCachedResult - busca o resultado recente do CPF consultado pelo usuário (ErrCacheMiss se não houver)
+func (q *MemoryQueue) GetCachedResult(username, cpf string) (*CachedResult, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	cached, ok := q.cachedLocked(username, cpf)
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+	copied := *cached
+	return &copied, nil
+}
+
+// CacheResult - guarda o resultado do CPF consultado pelo usuário pela janela de frescor
+func (q *MemoryQueue) CacheResult(username, credential, cpf, jobID, result string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.cacheLocked(username, credential, cpf, jobID, result)
+	return nil
+}
+
+// CredentialTag - impressão digital das credenciais
+func (q *MemoryQueue) CredentialTag(username, password string) string {
+	return credentialTag(q.tagKey, username, password)
+}
+
+// cachedLocked - resultado do CPF consultado pelo usuário se ainda estiver fresco
+func (q *MemoryQueue) cachedLocked(username, cpf string) (*CachedResult, bool) {
+	key := resultCacheKey(username, cpf)
+	cached, ok := q.results[key]
+	if !ok {
+		return nil, false
+	}
+	if !cached.Fresh(q.config.ResultTTL) {
+		delete(q.results, key)
+		return nil, false
+	}
+	return cached, true
+}
+
+// cacheLocked - grava o resultado do CPF consultado pelo usuário (no-op com o cache desligado)
+func (q *MemoryQueue) cacheLocked(username, credential, cpf, jobID, result string) {
+	if q.config.ResultTTL <= 0 {
+		return
+	}
+	q.results[resultCacheKey(username, cpf)] = &CachedResult{
+		Username:   loginKey(username),
+		CPF:        cpfKey(cpf),
+		JobID:      jobID,
+		Result:     result,
+		Credential: credential,
+		CachedAt:   time.Now(),
+	}
+}
+
+// randomTagKey - chave aleatória para o CredentialTag da fila em memória
+func randomTagKey() []byte {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic("erro ao gerar chave aleatória: " + err.Error())
+	}
+	return key
+}
+
+// FailJob - marca job como falho (ErrLeaseLost se o lease não é mais deste worker)
+func (q *MemoryQueue) FailJob(jobID string, lease Lease, errorMsg string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !q.ownsLeaseLocked(jobID, lease) {
+		return ErrLeaseLost
+	}
+
+	job, err := q.getLocked(jobID)
+	if err != nil {
+		return err
+	}
+
+	job.Status = StatusFailed
+	job.Error = errorMsg
+	delete(q.passwords, jobID)
+	q.saveLocked(job)
+
+	q.releaseLeaseLocked(jobID)
+	return nil
+}
+
+// GetJobStatus - busca status de um job
+func (q *MemoryQueue) GetJobStatus(jobID string) (*Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.getLocked(jobID)
+}
+
+// Heartbeat - renova o lease de um job em processamento
+// ErrLeaseLost se o reaper recolheu o job ou outra tentativa já é a dona do lease
+func (q *MemoryQueue) Heartbeat(jobID string, lease Lease) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !q.ownsLeaseLocked(jobID, lease) {
+		return ErrLeaseLost
+	}
+	q.leases[jobID] = q.leaseDeadline()
+	return nil
+}
+
+// ReapExpiredJobs - devolve para a fila (ou falha) jobs com lease expirado
+func (q *MemoryQueue) ReapExpiredJobs() (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	// Jobs em processing sem lease ganham um (mesma regra da RedisQueue)
+	for _, jobID := range q.processing {
+		if _, ok := q.leases[jobID]; !ok {
+			q.leases[jobID] = q.leaseDeadline()
+		}
+	}
+
+	now := time.Now()
+	reaped := 0
+	for jobID, deadline := range q.leases {
+		if deadline.After(now) {
+			continue
+		}
+
+		q.releaseLeaseLocked(jobID)
+
+		job, err := q.getLocked(jobID)
+		if err != nil {
+			continue
+		}
+
+		q.scheduleRetryOrDeadLetterLocked(job, errLeaseExpired(job))
+		reaped++
+	}
+
+	if reaped > 0 {
+		q.signal()
+	}
+	return reaped, nil
+}
+
+// RetryJob - agenda nova tentativa com backoff ou envia o job para a dead-letter queue
+// ErrLeaseLost se o lease não é mais deste worker
+func (q *MemoryQueue) RetryJob(jobID string, lease Lease, jobErr error) (bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !q.ownsLeaseLocked(jobID, lease) {
+		return false, ErrLeaseLost
+	}
+
+	job, err := q.getLocked(jobID)
+	if err != nil {
+		return false, err
+	}
+
+	retried := q.scheduleRetryOrDeadLetterLocked(job, jobErr)
+	q.releaseLeaseLocked(jobID)
+	return retried, nil
+}
+
+// scheduleRetryOrDeadLetterLocked - decide entre nova tentativa, falha definitiva ou dead-letter
+func (q *MemoryQueue) scheduleRetryOrDeadLetterLocked(job *Job, jobErr error) bool {
+	if q.cancelled[job.ID] {
+		q.markCancelledLocked(job)
+		return false
+	}
+
+	job.Error = jobErr.Error()
+	job.ErrorCode = ErrorCodeOf(jobErr)
+	job.WorkerID = ""
+
+	decision := q.config.Retry.Decide(job.Attempts, job.MaxAttempts, jobErr)
+	if !decision.Retry {
+		job.Status = StatusFailed
+		delete(q.passwords, job.ID)
+		q.saveLocked(job)
+
+		if decision.DeadLetter {
+			q.dead = append(q.dead, job.ID)
+		}
+		return false
+	}
+
+	job.Status = StatusRetrying
+	job.NextAttemptAt = &decision.NextAttempt
+	q.saveLocked(job)
+	q.delayed[job.ID] = decision.NextAttempt
+	return true
+}
+
+// PromoteDelayedJobs - move para a fila os jobs cujo horário de retry chegou
+func (q *MemoryQueue) PromoteDelayedJobs() (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	promoted := 0
+	for jobID, nextAttempt := range q.delayed {
+		if nextAttempt.After(now) {
+			continue
+		}
+		delete(q.delayed, jobID)
+
+		job, err := q.getLocked(jobID)
+		if err != nil {
+			continue
+		}
+
+		job.Status = StatusPending
+		q.saveLocked(job)
+		q.pending = append(q.pending, jobID)
+		promoted++
+	}
+
+	if promoted > 0 {
+		q.signal()
+	}
+	return promoted, nil
+}
+
+// CancelJob - cancela um job pendente ou sinaliza o worker que está executando
+func (q *MemoryQueue) CancelJob(jobID string) (*Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job, err := q.getLocked(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch job.Status {
+	case StatusCompleted, StatusFailed, StatusCancelled:
+		return job, ErrJobFinished
+
+	case StatusPending:
+		if removeID(&q.pending, jobID) {
+			q.markCancelledLocked(job)
+			return job, nil
+		}
+
+	case StatusRetrying:
+		if _, ok := q.delayed[jobID]; ok {
+			delete(q.delayed, jobID)
+			q.markCancelledLocked(job)
+			return job, nil
+		}
+	}
+
+	q.cancelled[jobID] = true
+	for sub := range q.cancelSubs {
+		select {
+		case sub <- jobID:
+		default:
+		}
+	}
+
+	return job, nil
+}
+
+// IsCancelRequested - verifica se há pedido de cancelamento para o job
+func (q *MemoryQueue) IsCancelRequested(jobID string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.cancelled[jobID]
+}
+
+// SubscribeCancellations - recebe IDs de jobs cancelados enquanto o ctx estiver ativo
+func (q *MemoryQueue) SubscribeCancellations(ctx context.Context) <-chan string {
+	sub := make(chan string, subscriberBuffer)
+
+	q.mu.Lock()
+	q.cancelSubs[sub] = struct{}{}
+	q.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		q.mu.Lock()
+		delete(q.cancelSubs, sub)
+		close(sub)
+		q.mu.Unlock()
+	}()
+
+	return sub
+}
+
+// MarkCancelled - finaliza como cancelado um job que estava em execução
+// ErrLeaseLost se o lease não é mais deste worker
+func (q *MemoryQueue) MarkCancelled(jobID string, lease Lease) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !q.ownsLeaseLocked(jobID, lease) {
+		return ErrLeaseLost
+	}
+
+	job, err := q.getLocked(jobID)
+	if err != nil {
+		return err
+	}
+
+	q.releaseLeaseLocked(jobID)
+	q.markCancelledLocked(job)
+	return nil
+}
+
+// markCancelledLocked - grava o status final de cancelamento
+func (q *MemoryQueue) markCancelledLocked(job *Job) {
+	job.Status = StatusCancelled
+	job.Error = "job cancelado pelo usuário"
+	job.WorkerID = ""
+	job.NextAttemptAt = nil
+	delete(q.passwords, job.ID)
+	delete(q.cancelled, job.ID)
+	q.saveLocked(job)
+}
+
+// AddBatch - cria um lote e enfileira um job filho por CPF
+func (q *MemoryQueue) AddBatch(username, password string, cpfs []string, opts JobOptions) (*Batch, error) {
+	batch := &Batch{
+		ID:        uuid.New().String(),
+		Username:  username,
+		JobIDs:    make([]string, 0, len(cpfs)),
+		CreatedAt: time.Now(),
+	}
+
+	opts.BatchID = batch.ID
+	for _, cpf := range cpfs {
+		jobID, err := q.AddJob(username, password, cpf, opts)
+		if err != nil {
+			// Desfaz o lote: nenhum job fica na fila sem o registro do lote
+			if cancelErr := cancelBatchJobs(q, batch); cancelErr != nil {
+				return nil, errors.Join(err, cancelErr)
+			}
+			return nil, err
+		}
+		batch.JobIDs = append(batch.JobIDs, jobID)
+	}
+
+	q.mu.Lock()
+	q.batches[batch.ID] = batch
+	q.mu.Unlock()
+
+	return batch, nil
+}
+
+// ClaimBatchJob - pega o próximo job pendente do lote (nil se não houver)
+func (q *MemoryQueue) ClaimBatchJob(workerID, batchID string) (*Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i := 0; i < len(q.pending); i++ {
+		job, ok := q.jobs[q.pending[i]]
+		if !ok || job.BatchID != batchID {
+			continue
+		}
+
+		// nil, nil = cancelado enquanto estava na fila: tenta o próximo
+		claimed, err := q.claimLocked(workerID, i)
+		if claimed != nil || err != nil {
+			return claimed, err
+		}
+		i-- // claimLocked removeu o item da posição i
+	}
+	return nil, nil
+}
+
+// GetBatch - busca o lote e o estado atual de cada job filho
+func (q *MemoryQueue) GetBatch(batchID string) (*Batch, []*Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	batch, ok := q.batches[batchID]
+	if !ok {
+		return nil, nil, ErrBatchNotFound
+	}
+
+	jobs := make([]*Job, 0, len(batch.JobIDs))
+	for _, jobID := range batch.JobIDs {
+		if job, err := q.getLocked(jobID); err == nil {
+			jobs = append(jobs, job)
+		}
+	}
+
+	copied := *batch
+	copied.JobIDs = append([]string(nil), batch.JobIDs...)
+	return &copied, jobs, nil
+}
+
+// PublishProgress - grava a etapa atual no job e publica o evento
+func (q *MemoryQueue) PublishProgress(jobID, stage string, progress int, message string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job, err := q.getLocked(jobID)
+	if err != nil {
+		return err
+	}
+
+	job.Stage = stage
+	job.Progress = progress
+	job.StageMessage = message
+	q.saveLocked(job)
+	return nil
+}
+
+// SubscribeEvents - recebe os eventos do job enquanto o ctx estiver ativo
+func (q *MemoryQueue) SubscribeEvents(ctx context.Context, jobID string) (<-chan JobEvent, error) {
+	sub := make(chan JobEvent, subscriberBuffer)
+
+	q.mu.Lock()
+	if q.eventSubs[jobID] == nil {
+		q.eventSubs[jobID] = make(map[chan JobEvent]struct{})
+	}
+	q.eventSubs[jobID][sub] = struct{}{}
+	q.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		q.mu.Lock()
+		delete(q.eventSubs[jobID], sub)
+		if len(q.eventSubs[jobID]) == 0 {
+			delete(q.eventSubs, jobID)
+		}
+		close(sub)
+		q.mu.Unlock()
+	}()
+
+	return sub, nil
+}
+
+// saveLocked - grava cópia do job (sem senha) e publica o evento
+func (q *MemoryQueue) saveLocked(job *Job) {
+	job.UpdatedAt = time.Now()
+
+	stored := cloneJob(job)
+	stored.Password = ""
+	q.jobs[job.ID] = stored
+
+	event := NewJobEvent(stored)
+	for sub := range q.eventSubs[job.ID] {
+		select {
+		case sub <- event:
+		default: // Assinante lento: descarta, como o Redis faria
+		}
+	}
+}
+
+// getLocked - retorna cópia do job
+func (q *MemoryQueue) getLocked(jobID string) (*Job, error) {
+	job, ok := q.jobs[jobID]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	return cloneJob(job), nil
+}
+
+// releaseLeaseLocked - remove o job de processing e apaga o lease
+func (q *MemoryQueue) releaseLeaseLocked(jobID string) {
+	removeID(&q.processing, jobID)
+	delete(q.leases, jobID)
+}
+
+// leaseDeadline - calcula a expiração de um lease renovado agora
+func (q *MemoryQueue) leaseDeadline() time.Time {
+	return time.Now().Add(q.config.VisibilityTimeout)
+}
+
+// signal - acorda um worker esperando em GetNextJob
+func (q *MemoryQueue) signal() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// cloneJob - cópia independente do job (inclusive ponteiros)
+func cloneJob(job *Job) *Job {
+	copied := *job
+	if job.NextAttemptAt != nil {
+		nextAttempt := *job.NextAttemptAt
+		copied.NextAttemptAt = &nextAttempt
+	}
+	return &copied
+}
+
+// removeID - remove a primeira ocorrência do ID da lista
+func removeID(ids *[]string, jobID string) bool {
+	for i, id := range *ids {
+		if id == jobID {
+			*ids = append((*ids)[:i], (*ids)[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package queue
+
+import "context"
+
+// JobQueue - núcleo da fila: enfileirar, pegar, atualizar e finalizar jobs
+// É o mínimo que um backend novo precisa para o fluxo servidor + worker
+type JobQueue interface {
+	AddJob(username, password, value string, opts JobOptions) (string, error) // value: CPF ou o valor do SearchType
+	GetNextJob(workerID string) (*Job, error)
+	UpdateJob(job *Job) error
+	CompleteJob(jobID string, lease Lease, result string) error
+	FailJob(jobID string, lease Lease, errorMsg string) error
+	GetJobStatus(jobID string) (*Job, error)
+}
+
+// JobLister - listagem paginada dos jobs (GET /api/jobs)
+type JobLister interface {
+	ListJobs(filter JobFilter) (*JobPage, error)
+}
+
+// LeaseManager - leases dos jobs em execução e novas tentativas
+type LeaseManager interface {
+	Heartbeat(jobID string, lease Lease) error
+	ReapExpiredJobs() (int, error)
+	RetryJob(jobID string, lease Lease, jobErr error) (bool, error)
+	PromoteDelayedJobs() (int, error)
+}
+
+// Canceller - cancelamento de jobs na fila ou em execução
+type Canceller interface {
+	CancelJob(jobID string) (*Job, error)
+	IsCancelRequested(jobID string) bool
+	SubscribeCancellations(ctx context.Context) <-chan string
+	MarkCancelled(jobID string, lease Lease) error
+}
+
+// BatchQueue - lotes de CPFs com as mesmas credenciais
+type BatchQueue interface {
+	AddBatch(username, password string, cpfs []string, opts JobOptions) (*Batch, error)
+	GetBatch(batchID string) (*Batch, []*Job, error)
+	ClaimBatchJob(workerID, batchID string) (*Job, error)
+}
+
+// EventBus - progresso dos jobs (SSE /api/jobs/{id}/events)
+type EventBus interface {
+	PublishProgress(jobID, stage string, progress int, message string) error
+	SubscribeEvents(ctx context.Context, jobID string) (<-chan JobEvent, error)
+}
+
+// Queue - backend completo da fila (Redis em produção, memória em testes/dev)
+// Quem só precisa de uma parte depende do papel correspondente (ex: LoginGuard, ResultCache)
+type Queue interface {
+	JobQueue
+	JobLister
+	LeaseManager
+	Canceller
+	BatchQueue
+	EventBus
+
+	// Cache de resultados por CPF
+	ResultCache
+
+	// Proteção das contas contra senha errada repetida
+	LoginGuard
+
+	GetConfig() QueueConfig
+	Ping() error
+	Close() error
+}
+
+var (
+	_ Queue = (*RedisQueue)(nil)
+	_ Queue = (*MemoryQueue)(nil)
+)
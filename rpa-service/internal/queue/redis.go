@@ -2,6 +2,8 @@ package queue
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -14,15 +16,29 @@ const (
 	JobsProcessing  = "rpa:jobs:processing"
 	JobsCompleted   = "rpa:jobs:completed"
 	JobsKeyPrefix   = "rpa:job:"
+	JobsLeases      = "rpa:jobs:leases"  // ZSET: job ID -> expiração do lease (unix ms)
+	JobsDelayed     = "rpa:jobs:delayed" // ZSET: job ID -> horário da próxima tentativa (unix ms)
+	JobsDead        = "rpa:jobs:dead"    // Jobs que esgotaram as tentativas
 )
 
+// ErrJobNotFound - job não existe (ou já expirou) no Redis
+var ErrJobNotFound = errors.New("job não encontrado")
+
+
 type RedisQueue struct {
 	client *redis.Client
 	ctx    context.Context
+	config QueueConfig
+	cipher *CredentialCipher
 }
 
 // NewRedisQueue - cria nova fila Redis
-func NewRedisQueue(addr string) *RedisQueue {
+func NewRedisQueue(addr string, cipher *CredentialCipher) *RedisQueue {
+	return NewRedisQueueWithConfig(addr, cipher, DefaultQueueConfig())
+}
+
+// NewRedisQueueWithConfig - cria fila Redis com configuração customizada
+func NewRedisQueueWithConfig(addr string, cipher *CredentialCipher, config QueueConfig) *RedisQueue {
 	client := redis.NewClient(&redis.Options{
 		Addr: addr, // Ex: "localhost:6379"
 	})
@@ -30,20 +46,83 @@ func NewRedisQueue(addr string) *RedisQueue {
 	return &RedisQueue{
 		client: client,
 		ctx:    context.Background(),
+		config: config,
+		cipher: cipher,
 	}
 }
 
+// GetConfig - retorna configurações da fila
+func (q *RedisQueue) GetConfig() QueueConfig {
+	return q.config
+}
+
+// Ping - verifica conexão com o Redis
+func (q *RedisQueue) Ping() error {
+	return q.client.Ping(q.ctx).Err()
+}
+
+// Close - fecha conexão com o Redis
+func (q *RedisQueue) Close() error {
+	return q.client.Close()
+}
+
 // AddJob - adiciona job na fila
-func (q *RedisQueue) AddJob(username, password, cpf string) (string, error) {
+func (q *RedisQueue) AddJob(username, password, value string, opts JobOptions) (string, error) {
+	maxAttempts := opts.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = q.config.Retry.MaxAttempts
+	}
+
 	job := &Job{
-		ID:        uuid.New().String(),
-		Username:  username,
-		Password:  password,
-		CPF:       cpf,
-		Status:    "pending",
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		ID:          uuid.New().String(),
+		Username:    username,
+		Status:      StatusPending,
+		MaxAttempts: maxAttempts,
+		BatchID:     opts.BatchID,
+		CallbackURL: opts.CallbackURL,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+
+		ProposalSelection: opts.ProposalSelection,
+		CredentialTag:     q.CredentialTag(username, password),
+	}
+	job.setSearch(opts.SearchType, value)
+
+	// Usuário bloqueado por senha errada: nem entra na fila (nem lê o cache)
+	block, err := q.GetLoginBlock(username)
+	if err != nil {
+		return "", err
+	}
+	if block != nil {
+		return "", &LoginBlockedError{Block: block}
+	}
+
+	// Resultado recente do mesmo CPF, consultado com as mesmas credenciais: responde sem abrir o Chrome
+	if !opts.ForceRefresh && job.Cacheable() {
+		cached, err := q.GetCachedResult(username, job.CPF)
+		if err == nil && cached.IssuedTo(job.CredentialTag) {
+			return job.ID, q.addCachedJob(job, cached)
+		}
+		if err != nil && !errors.Is(err, ErrCacheMiss) {
+			return "", err
+		}
+	}
+
+	// Mesma busca do mesmo usuário já na fila ou executando: reaproveita o job em andamento
+	active, err := q.claimActiveJob(job.searchKey(), job.ID)
+	if err != nil {
+		return "", err
+	}
+	if active != nil && canShare(active, job, opts) {
+		return active.ID, nil
+	}
+
+	// Senha nunca vai em texto puro para o Redis
+	sealed, err := q.cipher.Seal(password, job.ID)
+	if err != nil {
+		return "", fmt.Errorf("erro ao cifrar credenciais: %w", err)
 	}
+	job.SealedPassword = sealed
 
 	// Salva job no Redis
 	jobJSON, err := job.ToJSON()
@@ -57,6 +136,11 @@ func (q *RedisQueue) AddJob(username, password, cpf string) (string, error) {
 		return "", err
 	}
 
+	// Índices da listagem (GET /api/jobs)
+	if err := q.indexJob(job); err != nil {
+		return "", err
+	}
+
 	// Adiciona na fila
 	err = q.client.RPush(q.ctx, JobsQueue, job.ID).Err()
 	if err != nil {
@@ -66,8 +150,8 @@ func (q *RedisQueue) AddJob(username, password, cpf string) (string, error) {
 	return job.ID, nil
 }
 
-// GetNextJob - pega próximo job da fila
-func (q *RedisQueue) GetNextJob() (*Job, error) {
+// GetNextJob - pega próximo job da fila e reserva um lease para o worker
+func (q *RedisQueue) GetNextJob(workerID string) (*Job, error) {
 	// Move job da fila para processing (atomic)
 	result, err := q.client.BLMove(q.ctx, JobsQueue, JobsProcessing, "LEFT", "RIGHT", 5*time.Second).Result()
 	
@@ -78,14 +162,391 @@ func (q *RedisQueue) GetNextJob() (*Job, error) {
 		return nil, err
 	}
 
+	return q.claim(workerID, result)
+}
+
+// ClaimFailedError - o job foi pego da fila mas falhou antes de rodar (ex: senha não decifrável)
+// O job já está gravado como falho: quem pegou só precisa avisar (histórico, callback)
+type ClaimFailedError struct {
+	JobID string
+	Err   error
+}
+
+func (e *ClaimFailedError) Error() string {
+	return fmt.Sprintf("job %s falhou ao ser pego da fila: %v", e.JobID, e.Err)
+}
+
+func (e *ClaimFailedError) Unwrap() error {
+	return e.Err
+}
+
+// claim - reserva o lease de um job já movido para processing e o marca como em execução
+func (q *RedisQueue) claim(workerID, jobID string) (*Job, error) {
+	// Reserva o job: se o worker morrer, o lease expira e o reaper devolve o job
+	if err := q.client.ZAdd(q.ctx, JobsLeases, &redis.Z{
+		Score:  float64(q.leaseDeadline().UnixMilli()),
+		Member: jobID,
+	}).Err(); err != nil {
+		return nil, err
+	}
+
 	// Busca dados do job
-	jobKey := fmt.Sprintf("%s%s", JobsKeyPrefix, result)
-	jobJSON, err := q.client.Get(q.ctx, jobKey).Result()
+	job, err := q.GetJobStatus(jobID)
 	if err != nil {
+		// Job expirou ou sumiu: não deixa o ID órfão em processing
+		q.releaseLease(jobID)
 		return nil, err
 	}
 
-	return FromJSON(jobJSON)
+	// Cancelado enquanto ainda estava na fila (corrida com o LRem do CancelJob)
+	if q.IsCancelRequested(job.ID) {
+		q.releaseLease(jobID)
+		return nil, q.markCancelled(job)
+	}
+
+	// Decifra a senha apenas em memória (Password não é serializado)
+	password, openErr := q.cipher.Open(job.SealedPassword, job.ID)
+	job.Password = password
+
+	job.Status = StatusProcessing
+	job.Attempts++
+	job.WorkerID = workerID
+	job.NextAttemptAt = nil
+	job.Stage = ""
+	job.StageMessage = ""
+	job.Progress = 0
+
+	if err := q.UpdateJob(job); err != nil {
+		return nil, err
+	}
+	if err := q.setLeaseOwner(job); err != nil {
+		return nil, err
+	}
+
+	// Sem a senha não há como rodar: a tentativa falha como qualquer outra (FailJob)
+	if openErr != nil {
+		failErr := &ClaimFailedError{JobID: job.ID, Err: fmt.Errorf("credenciais indisponíveis: %w", openErr)}
+		if err := q.FailJob(job.ID, job.Lease(), failErr.Err.Error()); err != nil {
+			return nil, fmt.Errorf("erro ao falhar job %s: %w", job.ID, err)
+		}
+		return nil, failErr
+	}
+
+	return job, nil
+}
+
+// Heartbeat - renova o lease de um job em processamento
+// ErrLeaseLost se o reaper recolheu o job ou outra tentativa já é a dona do lease
+func (q *RedisQueue) Heartbeat(jobID string, lease Lease) error {
+	owns, err := q.ownsLease(jobID, lease)
+	if err != nil {
+		return err
+	}
+	if !owns {
+		return ErrLeaseLost
+	}
+
+	// Só renova se o lease ainda existir (reaper pode ter recolhido)
+	_, err = q.client.ZScore(q.ctx, JobsLeases, jobID).Result()
+	if err == redis.Nil {
+		return ErrLeaseLost
+	}
+	if err != nil {
+		return err
+	}
+
+	return q.client.ZAddXX(q.ctx, JobsLeases, &redis.Z{
+		Score:  float64(q.leaseDeadline().UnixMilli()),
+		Member: jobID,
+	}).Err()
+}
+
+// ReapExpiredJobs - devolve para a fila (ou falha) jobs com lease expirado
+func (q *RedisQueue) ReapExpiredJobs() (int, error) {
+	if err := q.adoptOrphanJobs(); err != nil {
+		return 0, err
+	}
+
+	now := fmt.Sprintf("%d", time.Now().UnixMilli())
+	expired, err := q.client.ZRangeByScore(q.ctx, JobsLeases, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: now,
+	}).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	reaped := 0
+	for _, jobID := range expired {
+		// ZRem funciona como trava: só um reaper processa cada job
+		removed, err := q.client.ZRem(q.ctx, JobsLeases, jobID).Result()
+		if err != nil {
+			return reaped, err
+		}
+		if removed == 0 {
+			continue
+		}
+
+		q.client.LRem(q.ctx, JobsProcessing, 1, jobID)
+
+		job, err := q.GetJobStatus(jobID)
+		if errors.Is(err, ErrJobNotFound) {
+			continue
+		}
+		if err != nil {
+			return reaped, err
+		}
+
+		// O worker terminou o job entre o vencimento e agora: ele grava o resultado
+		if err := q.takeLease(jobID, job.Lease(), true); errors.Is(err, ErrLeaseLost) {
+			continue
+		} else if err != nil {
+			return reaped, err
+		}
+
+		// Worker morto conta como falha recuperável: segue a política de retry
+		if _, err := q.scheduleRetryOrDeadLetter(job, errLeaseExpired(job)); err != nil {
+			return reaped, err
+		}
+
+		reaped++
+	}
+
+	return reaped, nil
+}
+
+// RetryJob - agenda nova tentativa com backoff ou envia o job para a dead-letter queue
+// Retorna true se o job foi reagendado; ErrLeaseLost se o lease não é mais deste worker
+func (q *RedisQueue) RetryJob(jobID string, lease Lease, jobErr error) (bool, error) {
+	if err := q.takeLease(jobID, lease, false); err != nil {
+		return false, err
+	}
+
+	job, err := q.GetJobStatus(jobID)
+	if err != nil {
+		return false, err
+	}
+
+	retried, err := q.scheduleRetryOrDeadLetter(job, jobErr)
+	if err != nil {
+		return false, err
+	}
+
+	q.releaseLease(jobID)
+	return retried, nil
+}
+
+// scheduleRetryOrDeadLetter - decide entre nova tentativa, falha definitiva ou dead-letter
+func (q *RedisQueue) scheduleRetryOrDeadLetter(job *Job, jobErr error) (bool, error) {
+	// Usuário pediu cancelamento: não tenta de novo
+	if q.IsCancelRequested(job.ID) {
+		return false, q.markCancelled(job)
+	}
+
+	job.Error = jobErr.Error()
+	job.ErrorCode = ErrorCodeOf(jobErr)
+	job.WorkerID = ""
+
+	decision := q.config.Retry.Decide(job.Attempts, job.MaxAttempts, jobErr)
+
+	// Erro permanente (ex: senha errada) ou tentativas esgotadas: falha definitiva
+	if !decision.Retry {
+		job.Status = StatusFailed
+		job.SealedPassword = ""
+		if err := q.UpdateJob(job); err != nil {
+			return false, err
+		}
+
+		// Esgotou as tentativas: vai para a dead-letter queue
+		if decision.DeadLetter {
+			return false, q.client.RPush(q.ctx, JobsDead, job.ID).Err()
+		}
+		return false, nil
+	}
+
+	// Agenda nova tentativa com backoff exponencial
+	job.Status = StatusRetrying
+	job.NextAttemptAt = &decision.NextAttempt
+	if err := q.UpdateJob(job); err != nil {
+		return false, err
+	}
+
+	err := q.client.ZAdd(q.ctx, JobsDelayed, &redis.Z{
+		Score:  float64(decision.NextAttempt.UnixMilli()),
+		Member: job.ID,
+	}).Err()
+	return err == nil, err
+}
+
+// PromoteDelayedJobs - move para a fila os jobs cujo horário de retry chegou
+func (q *RedisQueue) PromoteDelayedJobs() (int, error) {
+	now := fmt.Sprintf("%d", time.Now().UnixMilli())
+	due, err := q.client.ZRangeByScore(q.ctx, JobsDelayed, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: now,
+	}).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	promoted := 0
+	for _, jobID := range due {
+		// ZRem funciona como trava: só um worker promove cada job
+		removed, err := q.client.ZRem(q.ctx, JobsDelayed, jobID).Result()
+		if err != nil {
+			return promoted, err
+		}
+		if removed == 0 {
+			continue
+		}
+
+		job, err := q.GetJobStatus(jobID)
+		if errors.Is(err, ErrJobNotFound) {
+			continue
+		}
+		if err != nil {
+			return promoted, err
+		}
+
+		job.Status = StatusPending
+		if err := q.UpdateJob(job); err != nil {
+			return promoted, err
+		}
+		if err := q.client.RPush(q.ctx, JobsQueue, jobID).Err(); err != nil {
+			return promoted, err
+		}
+
+		promoted++
+	}
+
+	return promoted, nil
+}
+
+// RotateCredentials - recifra com a chave ativa as senhas de jobs ainda não finalizados
+// (também migra jobs antigos que guardavam a senha em texto puro)
+func (q *RedisQueue) RotateCredentials() (int, error) {
+	ids, err := q.client.LRange(q.ctx, JobsQueue, 0, -1).Result()
+	if err != nil {
+		return 0, err
+	}
+	delayed, err := q.client.ZRange(q.ctx, JobsDelayed, 0, -1).Result()
+	if err != nil {
+		return 0, err
+	}
+	ids = append(ids, delayed...)
+
+	rotated := 0
+	for _, jobID := range ids {
+		changed, err := q.rotateJobCredentials(jobID)
+		if err != nil {
+			return rotated, fmt.Errorf("job %s: %w", jobID, err)
+		}
+		if changed {
+			rotated++
+		}
+	}
+
+	return rotated, nil
+}
+
+// rotateJobCredentials - recifra a senha de um job dentro de um WATCH
+// (evita sobrescrever o job se um worker pegá-lo no meio da rotação)
+func (q *RedisQueue) rotateJobCredentials(jobID string) (bool, error) {
+	jobKey := fmt.Sprintf("%s%s", JobsKeyPrefix, jobID)
+	changed := false
+
+	err := q.client.Watch(q.ctx, func(tx *redis.Tx) error {
+		jobJSON, err := tx.Get(q.ctx, jobKey).Result()
+		if err == redis.Nil {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		job, err := FromJSON(jobJSON)
+		if err != nil {
+			return err
+		}
+
+		var password string
+		switch {
+		case job.SealedPassword != "" && q.cipher.NeedsRotation(job.SealedPassword):
+			if password, err = q.cipher.Open(job.SealedPassword, job.ID); err != nil {
+				return err
+			}
+		case job.SealedPassword == "":
+			// Jobs gravados antes da cifragem guardavam a senha em texto puro
+			if password = legacyPassword(jobJSON); password == "" {
+				return nil
+			}
+		default:
+			return nil
+		}
+
+		if job.SealedPassword, err = q.cipher.Seal(password, job.ID); err != nil {
+			return err
+		}
+
+		updated, err := job.ToJSON()
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.TxPipelined(q.ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(q.ctx, jobKey, updated, redis.KeepTTL)
+			return nil
+		})
+		changed = err == nil
+		return err
+	}, jobKey)
+
+	// Job mudou durante a rotação (worker pegou): fica para a próxima execução
+	if err == redis.TxFailedErr {
+		return false, nil
+	}
+	return changed, err
+}
+
+// legacyPassword - lê o campo "password" em texto puro de jobs antigos
+func legacyPassword(jobJSON string) string {
+	var legacy struct {
+		Password string `json:"password"`
+	}
+	json.Unmarshal([]byte(jobJSON), &legacy)
+	return legacy.Password
+}
+
+// adoptOrphanJobs - cria lease para jobs em processing sem lease
+// (worker caiu entre o BLMove e o ZAdd)
+func (q *RedisQueue) adoptOrphanJobs() error {
+	processing, err := q.client.LRange(q.ctx, JobsProcessing, 0, -1).Result()
+	if err != nil {
+		return err
+	}
+
+	for _, jobID := range processing {
+		err := q.client.ZAddNX(q.ctx, JobsLeases, &redis.Z{
+			Score:  float64(q.leaseDeadline().UnixMilli()),
+			Member: jobID,
+		}).Err()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// leaseDeadline - calcula a expiração de um lease renovado agora
+func (q *RedisQueue) leaseDeadline() time.Time {
+	return time.Now().Add(q.config.VisibilityTimeout)
+}
+
+// releaseLease - remove o job de processing e apaga o lease
+func (q *RedisQueue) releaseLease(jobID string) {
+	q.client.LRem(q.ctx, JobsProcessing, 1, jobID)
+	q.client.ZRem(q.ctx, JobsLeases, jobID)
 }
 
 // UpdateJob - atualiza status do job
@@ -98,11 +559,26 @@ func (q *RedisQueue) UpdateJob(job *Job) error {
 	}
 
 	jobKey := fmt.Sprintf("%s%s", JobsKeyPrefix, job.ID)
-	return q.client.Set(q.ctx, jobKey, jobJSON, 24*time.Hour).Err()
+	if err := q.client.Set(q.ctx, jobKey, jobJSON, 24*time.Hour).Err(); err != nil {
+		return err
+	}
+
+	// Mantém os índices da listagem (status muda em Complete/Fail/Retry/Cancel)
+	if err := q.indexJob(job); err != nil {
+		return err
+	}
+
+	// Notifica quem acompanha o job (SSE); falha aqui não invalida a atualização
+	q.publishEvent(job)
+	return nil
 }
 
-// CompleteJob - marca job como completo
-func (q *RedisQueue) CompleteJob(jobID string, result string) error {
+// CompleteJob - marca job como completo (ErrLeaseLost se o lease não é mais deste worker)
+func (q *RedisQueue) CompleteJob(jobID string, lease Lease, result string) error {
+	if err := q.takeLease(jobID, lease, false); err != nil {
+		return err
+	}
+
 	jobKey := fmt.Sprintf("%s%s", JobsKeyPrefix, jobID)
 	jobJSON, err := q.client.Get(q.ctx, jobKey).Result()
 	if err != nil {
@@ -114,8 +590,10 @@ func (q *RedisQueue) CompleteJob(jobID string, result string) error {
 		return err
 	}
 
-	job.Status = "completed"
+	job.Status = StatusCompleted
 	job.Result = result
+	job.Progress = 100
+	job.SealedPassword = ""
 
 	// Atualiza job
 	if err := q.UpdateJob(job); err != nil {
@@ -123,14 +601,23 @@ func (q *RedisQueue) CompleteJob(jobID string, result string) error {
 	}
 
 	// Remove de processing, adiciona em completed
-	q.client.LRem(q.ctx, JobsProcessing, 1, jobID)
+	q.releaseLease(jobID)
 	q.client.RPush(q.ctx, JobsCompleted, jobID)
 
+	// Próximas consultas do mesmo CPF usam este resultado
+	if job.Cacheable() {
+		q.CacheResult(job.Username, job.CredentialTag, job.CPF, job.ID, result)
+	}
+
 	return nil
 }
 
-// FailJob - marca job como falho
-func (q *RedisQueue) FailJob(jobID string, errorMsg string) error {
+// FailJob - marca job como falho (ErrLeaseLost se o lease não é mais deste worker)
+func (q *RedisQueue) FailJob(jobID string, lease Lease, errorMsg string) error {
+	if err := q.takeLease(jobID, lease, false); err != nil {
+		return err
+	}
+
 	jobKey := fmt.Sprintf("%s%s", JobsKeyPrefix, jobID)
 	jobJSON, err := q.client.Get(q.ctx, jobKey).Result()
 	if err != nil {
@@ -142,8 +629,9 @@ func (q *RedisQueue) FailJob(jobID string, errorMsg string) error {
 		return err
 	}
 
-	job.Status = "failed"
+	job.Status = StatusFailed
 	job.Error = errorMsg
+	job.SealedPassword = ""
 
 	// Atualiza job
 	if err := q.UpdateJob(job); err != nil {
@@ -151,7 +639,7 @@ func (q *RedisQueue) FailJob(jobID string, errorMsg string) error {
 	}
 
 	// Remove de processing
-	q.client.LRem(q.ctx, JobsProcessing, 1, jobID)
+	q.releaseLease(jobID)
 
 	return nil
 }
@@ -160,9 +648,12 @@ func (q *RedisQueue) FailJob(jobID string, errorMsg string) error {
 func (q *RedisQueue) GetJobStatus(jobID string) (*Job, error) {
 	jobKey := fmt.Sprintf("%s%s", JobsKeyPrefix, jobID)
 	jobJSON, err := q.client.Get(q.ctx, jobKey).Result()
+	if err == redis.Nil {
+		return nil, ErrJobNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
 
 	return FromJSON(jobJSON)
-}
\ No newline at end of file
+}
@@ -0,0 +1,134 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// ErrNonRetryable - marca erros que não devem gerar nova tentativa
+var ErrNonRetryable = errors.New("erro não recuperável")
+
+// nonRetryableError - embrulha um erro marcando-o como não recuperável
+type nonRetryableError struct {
+	err error
+}
+
+func (e *nonRetryableError) Error() string { return e.err.Error() }
+
+func (e *nonRetryableError) Unwrap() []error { return []error{e.err, ErrNonRetryable} }
+
+// NonRetryable - marca um erro como não recuperável (ex: senha errada)
+func NonRetryable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &nonRetryableError{err: err}
+}
+
+// errLeaseExpired - erro registrado quando o worker some no meio do job
+// (conta como falha recuperável e segue a política de retry)
+func errLeaseExpired(job *Job) error {
+	return fmt.Errorf("lease expirou na tentativa %d (worker %s)", job.Attempts, job.WorkerID)
+}
+
+// RetryPolicy - política de novas tentativas com backoff exponencial
+type RetryPolicy struct {
+	MaxAttempts int           // Tentativas padrão por job (pode ser sobrescrito no job)
+	BaseDelay   time.Duration // Espera antes da 2ª tentativa
+	MaxDelay    time.Duration // Teto do backoff
+	Multiplier  float64       // Fator de crescimento entre tentativas
+	Jitter      float64       // Variação aleatória (0.2 = ±20%)
+	Permanent   []error       // Erros que nunca devem ser re-tentados
+	// Códigos de erro (ErrorCode) que nunca devem ser re-tentados
+	// Senha errada, expirada ou usuário bloqueado: repetir não adianta e pode bloquear a conta
+	PermanentCodes []string
+}
+
+// DefaultRetryPolicy - política padrão de tentativas
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   30 * time.Second,
+		MaxDelay:    10 * time.Minute,
+		Multiplier:  2,
+		Jitter:      0.2,
+		Permanent:   []error{context.Canceled, ErrLoginBlocked},
+		PermanentCodes: []string{
+			"invalid_credentials", "password_expired", "account_locked",
+			"no_active_proposal", // Repetir a busca não faz aparecer proposta ativa
+		},
+	}
+}
+
+// Backoff - tempo de espera antes da próxima tentativa
+// attempt é o número da tentativa que acabou de falhar (1, 2, ...)
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	delay := float64(p.BaseDelay) * math.Pow(p.Multiplier, float64(attempt-1))
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+
+	if p.Jitter > 0 {
+		delay += delay * p.Jitter * (2*rand.Float64() - 1)
+	}
+
+	return time.Duration(delay)
+}
+
+// IsRetryable - classifica o erro: true se vale a pena tentar de novo
+func (p RetryPolicy) IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, ErrNonRetryable) {
+		return false
+	}
+
+	for _, permanent := range p.Permanent {
+		if errors.Is(err, permanent) {
+			return false
+		}
+	}
+
+	if code := ErrorCodeOf(err); code != "" {
+		for _, permanent := range p.PermanentCodes {
+			if code == permanent {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// RetryDecision - o que fazer com um job que falhou
+type RetryDecision struct {
+	Retry       bool      // Reagendar para NextAttempt
+	DeadLetter  bool      // Esgotou as tentativas
+	NextAttempt time.Time // Só quando Retry == true
+}
+
+// Decide - aplica a política ao job que acabou de falhar
+// maxAttempts é o limite do job (0 = padrão da política)
+func (p RetryPolicy) Decide(attempts, maxAttempts int, jobErr error) RetryDecision {
+	if !p.IsRetryable(jobErr) {
+		return RetryDecision{}
+	}
+
+	if maxAttempts <= 0 {
+		maxAttempts = p.MaxAttempts
+	}
+	if attempts >= maxAttempts {
+		return RetryDecision{DeadLetter: true}
+	}
+
+	return RetryDecision{
+		Retry:       true,
+		NextAttempt: time.Now().Add(p.Backoff(attempts)),
+	}
+}
@@ -0,0 +1,129 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// testCodedError - erro com ErrorCode, como as falhas de login classificadas
+type testCodedError struct{ code string }
+
+func (e testCodedError) Error() string     { return "erro " + e.code }
+func (e testCodedError) ErrorCode() string { return e.code }
+
+func TestBackoffGrowthAndCap(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 30 * time.Second, MaxDelay: 5 * time.Minute, Multiplier: 2}
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 0, want: 30 * time.Second}, // Abaixo de 1 conta como a primeira
+		{attempt: 1, want: 30 * time.Second},
+		{attempt: 2, want: time.Minute},
+		{attempt: 3, want: 2 * time.Minute},
+		{attempt: 4, want: 4 * time.Minute},
+		{attempt: 5, want: 5 * time.Minute}, // 8min passa do MaxDelay
+		{attempt: 20, want: 5 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("tentativa %d", tt.attempt), func(t *testing.T) {
+			if got := policy.Backoff(tt.attempt); got != tt.want {
+				t.Fatalf("Backoff(%d) = %s, esperado %s", tt.attempt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoffJitterStaysInRange(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: time.Minute, MaxDelay: 10 * time.Minute, Multiplier: 2, Jitter: 0.2}
+
+	for i := 0; i < 100; i++ {
+		got := policy.Backoff(2) // 2min ± 20%
+		if got < 96*time.Second || got > 144*time.Second {
+			t.Fatalf("Backoff(2) = %s, fora de 2min ± 20%%", got)
+		}
+	}
+
+	// O jitter vale sobre o teto: nunca passa de MaxDelay + Jitter
+	for i := 0; i < 100; i++ {
+		if got := policy.Backoff(10); got > 12*time.Minute {
+			t.Fatalf("Backoff(10) = %s, acima de MaxDelay + jitter", got)
+		}
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	policy := DefaultRetryPolicy()
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "sem erro", err: nil, want: false},
+		{name: "erro qualquer", err: errors.New("timeout no portal"), want: true},
+		{name: "NonRetryable", err: NonRetryable(errors.New("dado inválido")), want: false},
+		{name: "cancelado", err: fmt.Errorf("busca: %w", context.Canceled), want: false},
+		{name: "usuário bloqueado", err: &LoginBlockedError{Block: &LoginBlock{Username: "operador"}}, want: false},
+		{name: "senha errada", err: testCodedError{code: "invalid_credentials"}, want: false},
+		{name: "senha expirada", err: testCodedError{code: "password_expired"}, want: false},
+		{name: "conta bloqueada", err: fmt.Errorf("login: %w", testCodedError{code: "account_locked"}), want: false},
+		{name: "sem proposta ativa", err: testCodedError{code: "no_active_proposal"}, want: false},
+		{name: "etapa estourou o tempo", err: testCodedError{code: "stage_timeout"}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.IsRetryable(tt.err); got != tt.want {
+				t.Fatalf("IsRetryable(%v) = %v, esperado %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecide(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: 10 * time.Minute, Multiplier: 2,
+		PermanentCodes: []string{"invalid_credentials"}}
+	transient := errors.New("portal fora do ar")
+
+	tests := []struct {
+		name        string
+		attempts    int
+		maxAttempts int
+		err         error
+		want        RetryDecision
+		wantDelay   time.Duration
+	}{
+		{name: "primeira falha", attempts: 1, err: transient, want: RetryDecision{Retry: true}, wantDelay: time.Minute},
+		{name: "segunda falha", attempts: 2, err: transient, want: RetryDecision{Retry: true}, wantDelay: 2 * time.Minute},
+		{name: "limite da política", attempts: 3, err: transient, want: RetryDecision{DeadLetter: true}},
+		{name: "limite do job acima da política", attempts: 3, maxAttempts: 5, err: transient, want: RetryDecision{Retry: true}, wantDelay: 4 * time.Minute},
+		{name: "limite do job abaixo da política", attempts: 1, maxAttempts: 1, err: transient, want: RetryDecision{DeadLetter: true}},
+		{name: "código permanente", attempts: 1, err: testCodedError{code: "invalid_credentials"}, want: RetryDecision{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := time.Now()
+			got := policy.Decide(tt.attempts, tt.maxAttempts, tt.err)
+
+			if got.Retry != tt.want.Retry || got.DeadLetter != tt.want.DeadLetter {
+				t.Fatalf("Decide(%d, %d) = %+v, esperado %+v", tt.attempts, tt.maxAttempts, got, tt.want)
+			}
+			if !got.Retry {
+				if !got.NextAttempt.IsZero() {
+					t.Fatalf("NextAttempt preenchido sem retry: %s", got.NextAttempt)
+				}
+				return
+			}
+			if delay := got.NextAttempt.Sub(before); delay < tt.wantDelay || delay > tt.wantDelay+time.Second {
+				t.Fatalf("próxima tentativa em %s, esperado %s", delay, tt.wantDelay)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package webhook
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+)
+
+// ErrPrivateDestination - callback_url aponta para a própria máquina ou para a rede interna
+var ErrPrivateDestination = errors.New("callback_url não pode apontar para endereço local, privado ou link-local")
+
+// cgnatRange - 100.64.0.0/10 (NAT de operadora, também usado em redes internas de nuvem)
+var cgnatRange = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// ValidateURL - valida o callback_url informado na submissão
+// Recusa localhost e IPs internos escritos na URL; nomes que resolvem para a rede
+// interna são barrados na hora da conexão (dialer do dispatcher)
+func (d *Dispatcher) ValidateURL(callbackURL string) error {
+	parsed, err := url.Parse(callbackURL)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return ErrInvalidURL
+	}
+	if d.config.AllowPrivate {
+		return nil
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(parsed.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrPrivateDestination
+	}
+	if ip := net.ParseIP(host); ip != nil && !publicIP(ip) {
+		return ErrPrivateDestination
+	}
+	return nil
+}
+
+// publicIP - endereço roteável na internet (nem loopback, privado, link-local ou multicast)
+// Inclui 169.254.169.254, o serviço de metadados das nuvens
+func publicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() || cgnatRange.Contains(ip))
+}
+
+// newHTTPClient - cliente dos callbacks
+// Sem AllowPrivate, o dialer confere o IP já resolvido de cada conexão (inclusive redirects),
+// então um DNS que aponta para a rede interna não passa
+func newHTTPClient(config Config) *http.Client {
+	dialer := &net.Dialer{Timeout: config.Timeout}
+	if !config.AllowPrivate {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+				return ErrPrivateDestination
+			}
+			return nil
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	transport.Proxy = nil // Proxy do ambiente contornaria a checagem do IP de destino
+
+	return &http.Client{Timeout: config.Timeout, Transport: transport}
+}
@@ -0,0 +1,283 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	DeliveryKeyPrefix = "rpa:webhook:delivery:" // rpa:webhook:delivery:<id> - entrega (JSON)
+	JobDeliveriesKey  = "rpa:webhook:job:"      // rpa:webhook:job:<jobID> - IDs das entregas do job
+	PendingKey        = "rpa:webhook:pending"   // ZSET: entregas pendentes por horário da próxima tentativa (ms)
+	deliveryTTL       = 7 * 24 * time.Hour      // Log de entregas fica mais que os jobs (para auditoria)
+)
+
+// Status da entrega
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+var (
+	// ErrDeliveryNotFound - entrega não existe (ou já expirou)
+	ErrDeliveryNotFound = errors.New("entrega não encontrada")
+
+	// ErrDeliveryInProgress - entrega ainda está sendo tentada
+	ErrDeliveryInProgress = errors.New("entrega em andamento")
+)
+
+// Attempt - uma tentativa de POST no callback
+type Attempt struct {
+	At         time.Time `json:"at"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Duration   string    `json:"duration,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Delivery - registro de um callback (corpo enviado + histórico de tentativas)
+type Delivery struct {
+	ID          string     `json:"id"`
+	JobID       string     `json:"job_id"`
+	Event       string     `json:"event"`
+	URL         string     `json:"url"`
+	Body        string     `json:"body"`
+	Status      string     `json:"status"`
+	Attempts    []Attempt  `json:"attempts"`
+	Replays     int        `json:"replays,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+
+	CycleAttempts int        `json:"cycle_attempts,omitempty"`  // Tentativas desde a criação ou o último reenvio
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"` // Só enquanto pendente
+}
+
+// Store - log de entregas (Redis em produção, memória em testes/dev)
+type Store interface {
+	Save(delivery *Delivery) error
+	Get(deliveryID string) (*Delivery, error)
+	ListByJob(jobID string) ([]*Delivery, error)
+
+	// ClaimDue - reserva por claimFor até limit entregas pendentes já vencidas
+	// Reservada, a entrega só volta a vencer quando a reserva expira (ou no próximo Save)
+	ClaimDue(now time.Time, claimFor time.Duration, limit int) ([]*Delivery, error)
+}
+
+// RedisStore - log de entregas no Redis
+type RedisStore struct {
+	client *redis.Client
+	ctx    context.Context
+}
+
+// NewRedisStore - cria log de entregas no Redis
+func NewRedisStore(addr string) *RedisStore {
+	return &RedisStore{
+		client: redis.NewClient(&redis.Options{Addr: addr}),
+		ctx:    context.Background(),
+	}
+}
+
+// claimDueScript - pega as entregas vencidas e empurra a próxima tentativa de cada uma
+// para o fim da reserva (atômico: dois processos nunca pegam a mesma entrega)
+var claimDueScript = redis.NewScript(`
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[3])
+for _, id in ipairs(ids) do
+	redis.call('ZADD', KEYS[1], ARGV[2], id)
+end
+return ids
+`)
+
+// Save - grava a entrega e a associa ao job
+func (s *RedisStore) Save(delivery *Delivery) error {
+	deliveryJSON, err := json.Marshal(delivery)
+	if err != nil {
+		return err
+	}
+
+	jobKey := JobDeliveriesKey + delivery.JobID
+	_, err = s.client.TxPipelined(s.ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(s.ctx, DeliveryKeyPrefix+delivery.ID, deliveryJSON, deliveryTTL)
+		pipe.ZAdd(s.ctx, jobKey, &redis.Z{
+			Score:  float64(delivery.CreatedAt.UnixMilli()),
+			Member: delivery.ID,
+		})
+		pipe.Expire(s.ctx, jobKey, deliveryTTL)
+
+		if delivery.Status == DeliveryPending && delivery.NextAttemptAt != nil {
+			pipe.ZAdd(s.ctx, PendingKey, &redis.Z{
+				Score:  float64(delivery.NextAttemptAt.UnixMilli()),
+				Member: delivery.ID,
+			})
+		} else {
+			pipe.ZRem(s.ctx, PendingKey, delivery.ID)
+		}
+		return nil
+	})
+	return err
+}
+
+// ClaimDue - reserva as entregas vencidas
+func (s *RedisStore) ClaimDue(now time.Time, claimFor time.Duration, limit int) ([]*Delivery, error) {
+	ids, err := claimDueScript.Run(s.ctx, s.client, []string{PendingKey},
+		now.UnixMilli(), now.Add(claimFor).UnixMilli(), limit).StringSlice()
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]*Delivery, 0, len(ids))
+	for _, id := range ids {
+		delivery, err := s.Get(id)
+		if errors.Is(err, ErrDeliveryNotFound) {
+			s.client.ZRem(s.ctx, PendingKey, id) // Expirou do log
+			continue
+		}
+		if err != nil {
+			return deliveries, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, nil
+}
+
+// Get - busca uma entrega
+func (s *RedisStore) Get(deliveryID string) (*Delivery, error) {
+	deliveryJSON, err := s.client.Get(s.ctx, DeliveryKeyPrefix+deliveryID).Result()
+	if err == redis.Nil {
+		return nil, ErrDeliveryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var delivery Delivery
+	if err := json.Unmarshal([]byte(deliveryJSON), &delivery); err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// ListByJob - entregas do job, mais antigas primeiro
+func (s *RedisStore) ListByJob(jobID string) ([]*Delivery, error) {
+	ids, err := s.client.ZRange(s.ctx, JobDeliveriesKey+jobID, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]*Delivery, 0, len(ids))
+	for _, id := range ids {
+		delivery, err := s.Get(id)
+		if errors.Is(err, ErrDeliveryNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, nil
+}
+
+// Close - fecha conexão com o Redis
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
+
+// MemoryStore - log de entregas em memória
+type MemoryStore struct {
+	mu         sync.Mutex
+	deliveries map[string]*Delivery
+}
+
+// NewMemoryStore - cria log de entregas em memória
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		deliveries: make(map[string]*Delivery),
+	}
+}
+
+// Save - grava uma cópia da entrega
+func (s *MemoryStore) Save(delivery *Delivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deliveries[delivery.ID] = cloneDelivery(delivery)
+	return nil
+}
+
+// ClaimDue - reserva as entregas vencidas (a reserva adia NextAttemptAt da cópia guardada)
+func (s *MemoryStore) ClaimDue(now time.Time, claimFor time.Duration, limit int) ([]*Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []*Delivery
+	for _, delivery := range s.deliveries {
+		if len(due) >= limit {
+			break
+		}
+		if delivery.Status != DeliveryPending || delivery.NextAttemptAt == nil || delivery.NextAttemptAt.After(now) {
+			continue
+		}
+
+		claimedUntil := now.Add(claimFor)
+		delivery.NextAttemptAt = &claimedUntil
+		due = append(due, cloneDelivery(delivery))
+	}
+	return due, nil
+}
+
+// Get - busca uma entrega
+func (s *MemoryStore) Get(deliveryID string) (*Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delivery, ok := s.deliveries[deliveryID]
+	if !ok {
+		return nil, ErrDeliveryNotFound
+	}
+	return cloneDelivery(delivery), nil
+}
+
+// ListByJob - entregas do job, mais antigas primeiro
+func (s *MemoryStore) ListByJob(jobID string) ([]*Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deliveries []*Delivery
+	for _, delivery := range s.deliveries {
+		if delivery.JobID == jobID {
+			deliveries = append(deliveries, cloneDelivery(delivery))
+		}
+	}
+
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].CreatedAt.Before(deliveries[j].CreatedAt)
+	})
+	return deliveries, nil
+}
+
+// cloneDelivery - cópia independente (o dispatcher altera a entrega em background)
+func cloneDelivery(delivery *Delivery) *Delivery {
+	copied := *delivery
+	copied.Attempts = append([]Attempt(nil), delivery.Attempts...)
+	if delivery.DeliveredAt != nil {
+		deliveredAt := *delivery.DeliveredAt
+		copied.DeliveredAt = &deliveredAt
+	}
+	if delivery.NextAttemptAt != nil {
+		nextAttemptAt := *delivery.NextAttemptAt
+		copied.NextAttemptAt = &nextAttemptAt
+	}
+	return &copied
+}
+
+var (
+	_ Store = (*RedisStore)(nil)
+	_ Store = (*MemoryStore)(nil)
+)
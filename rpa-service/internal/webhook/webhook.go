@@ -0,0 +1,348 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/models"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/queue"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/pkg/logger"
+)
+
+const (
+	SecretEnv = "WEBHOOK_SECRET" // Segredo compartilhado com o CRM para assinar os callbacks
+
+	HeaderSignature = "X-RPA-Signature" // "sha256=<hex>" de HMAC(segredo, timestamp + "." + corpo)
+	HeaderTimestamp = "X-RPA-Timestamp" // Unix (segundos) usado na assinatura
+	HeaderDelivery  = "X-RPA-Delivery"  // ID da entrega (igual em todas as tentativas)
+	HeaderEvent     = "X-RPA-Event"
+
+	EventJobCompleted = "job.completed"
+	EventJobFailed    = "job.failed"
+)
+
+// ErrInvalidURL - callback_url precisa ser http(s) absoluta
+var ErrInvalidURL = errors.New("callback_url deve ser uma URL http(s) absoluta")
+
+// Config - configuração das entregas
+type Config struct {
+	MaxAttempts  int           // Tentativas por entrega
+	BaseDelay    time.Duration // Espera antes da 2ª tentativa
+	MaxDelay     time.Duration // Teto do backoff
+	Timeout      time.Duration // Timeout de cada POST
+	PollInterval time.Duration // Frequência com que o log é lido atrás de entregas vencidas
+	AllowPrivate bool          // Aceita callback em localhost/rede interna (só para desenvolvimento)
+}
+
+// DefaultConfig - configuração padrão das entregas
+func DefaultConfig() Config {
+	return Config{
+		MaxAttempts:  6,
+		BaseDelay:    10 * time.Second,
+		MaxDelay:     10 * time.Minute,
+		Timeout:      15 * time.Second,
+		PollInterval: 5 * time.Second,
+	}
+}
+
+// claimBatch - entregas vencidas pegas por leitura do log
+const claimBatch = 20
+
+// Payload - corpo enviado ao callback_url
+type Payload struct {
+	Event      string             `json:"event"`
+	JobID      string             `json:"job_id"`
+	Status     string             `json:"status"`
+	CPF        string             `json:"cpf,omitempty"` // Só em buscas por CPF
+	BatchID    string             `json:"batch_id,omitempty"`
+	Attempts   int                `json:"attempts"`
+	Error      string             `json:"error,omitempty"`
+	ErrorCode  string             `json:"error_code,omitempty"`
+	Cached     bool               `json:"cached,omitempty"`
+	Data       *models.ClientData `json:"data,omitempty"`
+	FinishedAt time.Time          `json:"finished_at"`
+
+	// Com proposal_selection "all": um ClientData por proposta (Data é o primeiro)
+	Results []*models.ClientData `json:"results,omitempty"`
+
+	// Tipo da busca e o valor buscado (CPF, CNPJ, proposta ou contrato)
+	SearchType  string `json:"search_type,omitempty"`
+	SearchValue string `json:"search_value,omitempty"`
+}
+
+// Dispatcher - envia os callbacks assinados e registra as entregas
+// As tentativas saem do log de entregas (Run): nada se perde se o processo reiniciar
+// no meio do backoff, e qualquer processo com o mesmo log continua a entrega
+type Dispatcher struct {
+	secret []byte
+	store  Store
+	config Config
+	client *http.Client
+	wake   chan struct{} // Entrega nova ou reenviada: lê o log sem esperar o PollInterval
+}
+
+// NewDispatcher - cria dispatcher com configuração padrão
+func NewDispatcher(secret string, store Store) *Dispatcher {
+	return NewDispatcherWithConfig(secret, store, DefaultConfig())
+}
+
+// NewDispatcherWithConfig - cria dispatcher com configuração customizada
+func NewDispatcherWithConfig(secret string, store Store, config Config) *Dispatcher {
+	return &Dispatcher{
+		secret: []byte(secret),
+		store:  store,
+		config: config,
+		client: newHTTPClient(config),
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// Sign - assinatura HMAC-SHA256 de timestamp + "." + corpo
+// O CRM recalcula com o mesmo segredo e compara com o header X-RPA-Signature
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// NotifyJobFinished - registra o callback de um job finalizado (Run faz a entrega)
+// Retorna nil, nil se o job não tem callback_url
+func (d *Dispatcher) NotifyJobFinished(job *queue.Job) (*Delivery, error) {
+	if job.CallbackURL == "" {
+		return nil, nil
+	}
+
+	payload := Payload{
+		JobID:      job.ID,
+		Status:     job.Status,
+		CPF:        job.CPF,
+		BatchID:    job.BatchID,
+		Attempts:   job.Attempts,
+		Cached:     job.Cached,
+		FinishedAt: job.UpdatedAt,
+
+		SearchType:  string(job.SearchType),
+		SearchValue: job.SearchedValue(),
+	}
+
+	switch job.Status {
+	case queue.StatusCompleted:
+		results, err := job.ParseResults()
+		if err != nil {
+			return nil, err
+		}
+		payload.Event = EventJobCompleted
+		if len(results) > 0 {
+			payload.Data = results[0]
+		}
+		if job.ProposalSelection == models.ProposalAll {
+			payload.Results = results
+		}
+	case queue.StatusFailed:
+		payload.Event = EventJobFailed
+		payload.Error = job.Error
+		payload.ErrorCode = job.ErrorCode
+	default:
+		return nil, fmt.Errorf("job %s não está finalizado (status %s)", job.ID, job.Status)
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	delivery := &Delivery{
+		ID:            uuid.New().String(),
+		JobID:         job.ID,
+		Event:         payload.Event,
+		URL:           job.CallbackURL,
+		Body:          string(body),
+		Status:        DeliveryPending,
+		NextAttemptAt: &now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := d.store.Save(delivery); err != nil {
+		return nil, err
+	}
+
+	d.signal()
+	return delivery, nil
+}
+
+// Replay - reenvia uma entrega já registrada (mesmo corpo, nova assinatura)
+func (d *Dispatcher) Replay(deliveryID string) (*Delivery, error) {
+	delivery, err := d.store.Get(deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	// Pendente: ainda está no ciclo de tentativas (o log garante que ela continua)
+	if delivery.Status == DeliveryPending {
+		return delivery, ErrDeliveryInProgress
+	}
+
+	now := time.Now()
+	delivery.Status = DeliveryPending
+	delivery.Replays++
+	delivery.CycleAttempts = 0
+	delivery.NextAttemptAt = &now
+	delivery.UpdatedAt = now
+	if err := d.store.Save(delivery); err != nil {
+		return nil, err
+	}
+
+	d.signal()
+	return delivery, nil
+}
+
+// Store - log de entregas usado pelo dispatcher
+func (d *Dispatcher) Store() Store {
+	return d.store
+}
+
+// Run - entrega os callbacks pendentes do log até o ctx ser cancelado
+// Cada entrega vencida é reservada no log (ClaimDue) antes do POST: com servidor e
+// worker lendo o mesmo log, só um deles tenta. Ao desligar, POSTs em andamento são
+// interrompidos e a entrega volta a vencer quando a reserva expira.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.config.PollInterval)
+	defer ticker.Stop()
+
+	var inFlight sync.WaitGroup
+	defer inFlight.Wait()
+
+	for {
+		deliveries, err := d.store.ClaimDue(time.Now(), d.claimFor(), claimBatch)
+		if err != nil {
+			logger.Error(fmt.Sprintf("⚠️ Erro ao ler entregas pendentes: %v", err))
+		}
+		for _, delivery := range deliveries {
+			inFlight.Add(1)
+			go func(delivery *Delivery) {
+				defer inFlight.Done()
+				d.attempt(ctx, delivery)
+			}(delivery)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// signal - acorda o Run (entrega nova ou reenviada)
+func (d *Dispatcher) signal() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// claimFor - por quanto tempo uma entrega fica reservada para quem a pegou
+// (POST com timeout + folga para gravar o resultado)
+func (d *Dispatcher) claimFor() time.Duration {
+	return 2*d.config.Timeout + d.config.PollInterval
+}
+
+// attempt - uma tentativa da entrega; grava o resultado e agenda a próxima (backoff exponencial)
+func (d *Dispatcher) attempt(ctx context.Context, delivery *Delivery) {
+	result := d.post(ctx, delivery)
+	if ctx.Err() != nil {
+		// Desligando: a tentativa não conta, a entrega continua pendente no log
+		return
+	}
+
+	delivery.Attempts = append(delivery.Attempts, result)
+	delivery.CycleAttempts++
+	delivery.UpdatedAt = time.Now()
+	delivery.NextAttemptAt = nil
+
+	switch {
+	case result.Error == "":
+		delivery.Status = DeliveryDelivered
+		delivery.DeliveredAt = &result.At
+		logger.Info(fmt.Sprintf("📨 Callback do job %s entregue (%s)", delivery.JobID, delivery.URL))
+
+	case delivery.CycleAttempts >= d.config.MaxAttempts:
+		delivery.Status = DeliveryFailed
+		logger.Error(fmt.Sprintf("💀 Callback do job %s desistiu após %d tentativas (entrega %s)", delivery.JobID, d.config.MaxAttempts, delivery.ID))
+
+	default:
+		next := time.Now().Add(d.backoff(delivery.CycleAttempts))
+		delivery.NextAttemptAt = &next
+		logger.Error(fmt.Sprintf("⚠️ Callback do job %s falhou (tentativa %d/%d): %s", delivery.JobID, delivery.CycleAttempts, d.config.MaxAttempts, result.Error))
+	}
+
+	d.save(delivery)
+}
+
+// post - uma tentativa de entrega
+// Qualquer resposta fora de 2xx conta como falha
+func (d *Dispatcher) post(ctx context.Context, delivery *Delivery) Attempt {
+	started := time.Now()
+	attempt := Attempt{At: started}
+
+	timestamp := strconv.FormatInt(started.Unix(), 10)
+	body := []byte(delivery.Body)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(d.secret, timestamp, body))
+	req.Header.Set(HeaderDelivery, delivery.ID)
+	req.Header.Set(HeaderEvent, delivery.Event)
+
+	resp, err := d.client.Do(req)
+	attempt.Duration = time.Since(started).String()
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	attempt.StatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		attempt.Error = fmt.Sprintf("resposta HTTP %d", resp.StatusCode)
+	}
+	return attempt
+}
+
+// backoff - espera antes da próxima tentativa (dobra a cada falha)
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	delay := time.Duration(float64(d.config.BaseDelay) * math.Pow(2, float64(attempt-1)))
+	if d.config.MaxDelay > 0 && delay > d.config.MaxDelay {
+		return d.config.MaxDelay
+	}
+	return delay
+}
+
+// save - grava o estado da entrega (erro aqui só é logado: a entrega continua)
+func (d *Dispatcher) save(delivery *Delivery) {
+	if err := d.store.Save(delivery); err != nil {
+		logger.Error(fmt.Sprintf("⚠️ Erro ao gravar entrega %s: %v", delivery.ID, err))
+	}
+}
@@ -0,0 +1,174 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestValidateURL(t *testing.T) {
+	dispatcher := NewDispatcher("segredo", NewMemoryStore())
+
+	tests := []struct {
+		url  string
+		want error
+	}{
+		{url: "https://crm.exemplo.com.br/webhooks/rpa", want: nil},
+		{url: "http://203.0.113.10:8080/callback", want: nil},
+		{url: "https://[2001:db8::1]/callback", want: nil},
+
+		{url: "", want: ErrInvalidURL},
+		{url: "ftp://crm.exemplo.com.br/callback", want: ErrInvalidURL},
+		{url: "https:///sem-host", want: ErrInvalidURL},
+		{url: "crm.exemplo.com.br/callback", want: ErrInvalidURL},
+
+		{url: "http://localhost:8080/callback", want: ErrPrivateDestination},
+		{url: "http://LOCALHOST./callback", want: ErrPrivateDestination},
+		{url: "http://api.localhost/callback", want: ErrPrivateDestination},
+		{url: "http://127.0.0.1/callback", want: ErrPrivateDestination},
+		{url: "http://127.8.9.10/callback", want: ErrPrivateDestination},
+		{url: "http://[::1]/callback", want: ErrPrivateDestination},
+		{url: "http://0.0.0.0/callback", want: ErrPrivateDestination},
+		{url: "http://10.0.0.5/callback", want: ErrPrivateDestination},
+		{url: "http://172.16.0.1/callback", want: ErrPrivateDestination},
+		{url: "http://172.31.255.254/callback", want: ErrPrivateDestination},
+		{url: "http://192.168.1.20/callback", want: ErrPrivateDestination},
+		{url: "http://169.254.169.254/latest/meta-data/", want: ErrPrivateDestination},
+		{url: "http://100.64.0.1/callback", want: ErrPrivateDestination},
+		{url: "http://100.127.255.254/callback", want: ErrPrivateDestination},
+		{url: "http://[fd00::1]/callback", want: ErrPrivateDestination},
+		{url: "http://[fe80::1]/callback", want: ErrPrivateDestination},
+		{url: "http://[::ffff:127.0.0.1]/callback", want: ErrPrivateDestination},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if err := dispatcher.ValidateURL(tt.url); !errors.Is(err, tt.want) {
+				t.Fatalf("ValidateURL(%q) = %v, esperado %v", tt.url, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateURLAllowPrivate(t *testing.T) {
+	config := DefaultConfig()
+	config.AllowPrivate = true
+	dispatcher := NewDispatcherWithConfig("segredo", NewMemoryStore(), config)
+
+	if err := dispatcher.ValidateURL("http://localhost:8080/callback"); err != nil {
+		t.Fatalf("AllowPrivate: ValidateURL(localhost) = %v", err)
+	}
+	// Continua exigindo uma URL http(s) válida
+	if err := dispatcher.ValidateURL("ftp://localhost/callback"); !errors.Is(err, ErrInvalidURL) {
+		t.Fatalf("AllowPrivate: ValidateURL(ftp) = %v, esperado ErrInvalidURL", err)
+	}
+}
+
+func TestPublicIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{ip: "8.8.8.8", want: true},
+		{ip: "100.63.255.255", want: true}, // Logo antes do CGNAT
+		{ip: "100.128.0.0", want: true},    // Logo depois do CGNAT
+		{ip: "172.32.0.1", want: true},     // Fora do 172.16.0.0/12
+		{ip: "2001:4860:4860::8888", want: true},
+		{ip: "127.0.0.1", want: false},
+		{ip: "10.255.255.255", want: false},
+		{ip: "192.168.0.1", want: false},
+		{ip: "169.254.169.254", want: false},
+		{ip: "100.64.0.0", want: false},
+		{ip: "224.0.0.1", want: false},
+		{ip: "0.0.0.0", want: false},
+		{ip: "::", want: false},
+		{ip: "fc00::1", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			if got := publicIP(net.ParseIP(tt.ip)); got != tt.want {
+				t.Fatalf("publicIP(%s) = %v, esperado %v", tt.ip, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSign(t *testing.T) {
+	// Vetor calculado fora do Go: HMAC-SHA256("segredo-do-crm", "1700000000." + corpo)
+	body := []byte(`{"job_id":"abc"}`)
+	want := "sha256=7398b29869ba1c4c4a207ab72bc82ded2cfed8191b4833bef508b617eb827b8e"
+
+	if got := Sign([]byte("segredo-do-crm"), "1700000000", body); got != want {
+		t.Fatalf("Sign = %s, esperado %s", got, want)
+	}
+	if Sign([]byte("outro-segredo"), "1700000000", body) == want {
+		t.Fatal("assinatura não depende do segredo")
+	}
+	if Sign([]byte("segredo-do-crm"), "1700000001", body) == want {
+		t.Fatal("assinatura não depende do timestamp")
+	}
+}
+
+// TestPostSignsRequest - o CRM consegue validar a assinatura com os headers recebidos
+func TestPostSignsRequest(t *testing.T) {
+	var received *http.Request
+	var receivedBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		receivedBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.AllowPrivate = true // httptest escuta em 127.0.0.1
+	dispatcher := NewDispatcherWithConfig("segredo-do-crm", NewMemoryStore(), config)
+
+	delivery := &Delivery{ID: "entrega-1", Event: "job.completed", URL: server.URL, Body: `{"job_id":"abc","status":"completed"}`}
+	attempt := dispatcher.post(context.Background(), delivery)
+	if attempt.Error != "" || attempt.StatusCode != http.StatusNoContent {
+		t.Fatalf("post: %+v", attempt)
+	}
+
+	mac := hmac.New(sha256.New, []byte("segredo-do-crm"))
+	mac.Write([]byte(received.Header.Get(HeaderTimestamp) + "."))
+	mac.Write(receivedBody)
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	if got := received.Header.Get(HeaderSignature); !hmac.Equal([]byte(got), []byte(want)) {
+		t.Fatalf("%s = %s, esperado %s", HeaderSignature, got, want)
+	}
+	if received.Header.Get(HeaderDelivery) != "entrega-1" || received.Header.Get(HeaderEvent) != "job.completed" {
+		t.Fatalf("headers da entrega: %v", received.Header)
+	}
+}
+
+// TestPostBlocksPrivateAtDial - sem AllowPrivate a conexão com a rede interna é recusada no dialer
+// (cobre nomes que resolvem para IP interno, que o ValidateURL não vê)
+func TestPostBlocksPrivateAtDial(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	dispatcher := NewDispatcher("segredo", NewMemoryStore())
+	delivery := &Delivery{ID: "entrega-1", URL: strings.Replace(server.URL, "127.0.0.1", "localhost", 1), Body: `{}`}
+
+	attempt := dispatcher.post(context.Background(), delivery)
+	if called {
+		t.Fatal("callback entregue para endereço local")
+	}
+	if !strings.Contains(attempt.Error, ErrPrivateDestination.Error()) {
+		t.Fatalf("erro %q, esperado %q", attempt.Error, ErrPrivateDestination)
+	}
+}
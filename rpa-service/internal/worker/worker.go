@@ -0,0 +1,574 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/artifacts"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/automation"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/browserpool"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/history"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/models"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/queue"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/webhook"
+	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/pkg/logger"
+)
+
+// Worker - consome jobs da fila e executa a automação
+// Funciona com qualquer backend de fila (Redis ou memória)
+type Worker struct {
+	id        string
+	queue     queue.Queue
+	headless  bool
+	current   runningJob
+	webhooks  *webhook.Dispatcher
+	history   history.Repository
+	pool      *browserpool.Pool
+	artifacts *artifacts.Store
+}
+
+// New - cria um worker para a fila informada
+func New(id string, q queue.Queue, headless bool) *Worker {
+	return &Worker{
+		id:       id,
+		queue:    q,
+		headless: headless,
+	}
+}
+
+// SetWebhooks - habilita os callbacks (callback_url) dos jobs finalizados
+func (w *Worker) SetWebhooks(dispatcher *webhook.Dispatcher) {
+	w.webhooks = dispatcher
+}
+
+// SetHistory - grava cada job finalizado no histórico durável
+func (w *Worker) SetHistory(repo history.Repository) {
+	w.history = repo
+}
+
+// SetBrowserPool - executa os jobs nos Chromes do pool (compartilhado com o servidor, se embutido)
+func (w *Worker) SetBrowserPool(pool *browserpool.Pool) {
+	w.pool = pool
+}
+
+// SetArtifacts - guarda as evidências (screenshot, HTML, erros do console) dos jobs que falharem
+func (w *Worker) SetArtifacts(store *artifacts.Store) {
+	w.artifacts = store
+}
+
+// Run - processa jobs até o ctx ser cancelado
+// Também roda o reaper, o promotor de retries e o assinante de cancelamentos
+func (w *Worker) Run(ctx context.Context) {
+	// Reaper: devolve para a fila jobs de workers que morreram
+	go w.runReaper(ctx)
+
+	// Promotor: devolve para a fila jobs cujo backoff terminou
+	go w.runPromoter(ctx)
+
+	// Cancelamentos: interrompe o job em execução quando pedido via API
+	go func() {
+		for jobID := range w.queue.SubscribeCancellations(ctx) {
+			if w.current.cancel(jobID) {
+				logger.Info(fmt.Sprintf("[%s] 🛑 Cancelamento recebido para o job %s", w.id, jobID))
+			}
+		}
+	}()
+
+	// Loop principal do worker
+	for ctx.Err() == nil {
+		logger.Info(fmt.Sprintf("[%s] 🔍 Buscando próximo job...", w.id))
+
+		// Pega próximo job da fila
+		job, err := w.queue.GetNextJob(w.id)
+		if w.claimFailed(err) {
+			continue
+		}
+		if err != nil {
+			logger.Error(fmt.Sprintf("[%s] ❌ Erro ao buscar job: %v", w.id, err))
+			sleep(ctx, 5*time.Second)
+			continue
+		}
+
+		if job == nil {
+			// Fila vazia, aguarda
+			sleep(ctx, 2*time.Second)
+			continue
+		}
+
+		if job.BatchID != "" {
+			w.processBatch(ctx, job)
+			continue
+		}
+
+		w.process(job, nil)
+	}
+
+	logger.Info(fmt.Sprintf("[%s] 🛑 Worker parando...", w.id))
+}
+
+// processBatch - processa o job e, no mesmo Chrome logado, os próximos pendentes do lote
+// Paga o login uma vez por lote em vez de uma vez por CPF
+func (w *Worker) processBatch(ctx context.Context, job *queue.Job) {
+	session := &batchSession{}
+	defer session.close()
+
+	for processed := 1; ; processed++ {
+		w.process(job, session)
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		next, err := w.queue.ClaimBatchJob(w.id, job.BatchID)
+		for w.claimFailed(err) {
+			next, err = w.queue.ClaimBatchJob(w.id, job.BatchID)
+		}
+		if err != nil {
+			logger.Error(fmt.Sprintf("[%s] ❌ Erro ao buscar próximo job do lote %s: %v", w.id, job.BatchID, err))
+			return
+		}
+		if next == nil {
+			logger.Info(fmt.Sprintf("[%s] 📦 Lote %s: %d job(s) processados neste navegador", w.id, job.BatchID, processed))
+			return
+		}
+		job = next
+	}
+}
+
+// claimFailed - o job pego da fila já saiu falho (ClaimFailedError): só avisa do fim
+func (w *Worker) claimFailed(err error) bool {
+	var claimErr *queue.ClaimFailedError
+	if !errors.As(err, &claimErr) {
+		return false
+	}
+
+	logger.Error(fmt.Sprintf("[%s] 💀 %v", w.id, claimErr))
+	w.finished(claimErr.JobID)
+	return true
+}
+
+// process - executa um job e grava o resultado (completo, retry ou cancelado)
+// Com session != nil o job reaproveita (ou abre) o Chrome logado do lote
+func (w *Worker) process(job *queue.Job, session *batchSession) {
+	logger.Info(fmt.Sprintf("[%s] 📋 Processando job %s (%s, tentativa %d)", w.id, job.ID, queryOf(job), job.Attempts))
+
+	// Usuário bloqueado depois que o job entrou na fila: nem abre o Chrome
+	if w.failIfLoginBlocked(job) {
+		return
+	}
+
+	// Contexto cancelável pelo DELETE /api/jobs/{id}
+	jobCtx := w.current.start(job.ID)
+	if w.queue.IsCancelRequested(job.ID) {
+		w.current.cancel(job.ID)
+	}
+
+	// Mantém o lease vivo enquanto o bot roda (perdeu o lease: interrompe o Chrome)
+	lease := job.Lease()
+	leaseLost, stopHeartbeat := w.startHeartbeat(job.ID, lease)
+
+	// Executa automação
+	response, err := w.execute(jobCtx, job, session)
+	stopHeartbeat()
+	cancelled := jobCtx.Err() != nil
+	w.current.finish()
+	w.recordLoginResult(job.Username, err)
+
+	// O reaper devolveu o job para a fila: a nova tentativa é quem grava o resultado
+	if leaseLost() {
+		logger.Error(fmt.Sprintf("[%s] ⚠️ Job %s interrompido: lease perdido, resultado descartado", w.id, job.ID))
+		return
+	}
+
+	if cancelled && w.queue.IsCancelRequested(job.ID) {
+		if err := w.queue.MarkCancelled(job.ID, lease); errors.Is(err, queue.ErrLeaseLost) {
+			logger.Error(fmt.Sprintf("[%s] ⚠️ Lease do job %s perdido, cancelamento descartado", w.id, job.ID))
+			return
+		} else if err != nil {
+			logger.Error(fmt.Sprintf("[%s] ❌ Erro ao cancelar job %s: %v", w.id, job.ID, err))
+		}
+		logger.Info(fmt.Sprintf("[%s] 🛑 Job %s cancelado", w.id, job.ID))
+		w.finished(job.ID)
+		return
+	}
+
+	if err != nil {
+		logger.Error(fmt.Sprintf("[%s] ❌ Erro no job %s: %v", w.id, job.ID, err))
+
+		// Senha errada, expirada ou usuário bloqueado não são re-tentados (RetryPolicy.PermanentCodes)
+
+		retried, retryErr := w.queue.RetryJob(job.ID, lease, err)
+		if errors.Is(retryErr, queue.ErrLeaseLost) {
+			logger.Error(fmt.Sprintf("[%s] ⚠️ Lease do job %s perdido, falha descartada", w.id, job.ID))
+		} else if retryErr != nil {
+			logger.Error(fmt.Sprintf("[%s] ❌ Erro ao reagendar job %s: %v", w.id, job.ID, retryErr))
+		} else if retried {
+			logger.Info(fmt.Sprintf("[%s] 🔁 Job %s reagendado (tentativa %d falhou)", w.id, job.ID, job.Attempts))
+		} else {
+			logger.Error(fmt.Sprintf("[%s] 💀 Job %s falhou definitivamente", w.id, job.ID))
+			w.finished(job.ID)
+		}
+		return
+	}
+
+	// Serializa resultado (todas as propostas com proposal_selection "all")
+	var resultJSON []byte
+	if job.ProposalSelection == models.ProposalAll {
+		resultJSON, _ = json.Marshal(response.Results)
+	} else {
+		resultJSON, _ = json.Marshal(response.Data)
+	}
+
+	// Marca como completo
+	if err := w.queue.CompleteJob(job.ID, lease, string(resultJSON)); errors.Is(err, queue.ErrLeaseLost) {
+		logger.Error(fmt.Sprintf("[%s] ⚠️ Lease do job %s perdido, resultado descartado", w.id, job.ID))
+		return
+	} else if err != nil {
+		logger.Error(fmt.Sprintf("[%s] ❌ Erro ao completar job %s: %v", w.id, job.ID, err))
+		return
+	}
+
+	logger.Info(fmt.Sprintf("[%s] ✅ Job %s completado!", w.id, job.ID))
+	w.discardArtifacts(job.ID)
+	w.finished(job.ID)
+}
+
+// failIfLoginBlocked - falha o job sem tentar login se o usuário estiver bloqueado
+func (w *Worker) failIfLoginBlocked(job *queue.Job) bool {
+	block, err := w.queue.GetLoginBlock(job.Username)
+	if err != nil {
+		logger.Error(fmt.Sprintf("[%s] ⚠️ Erro ao consultar bloqueio de %s: %v", w.id, job.Username, err))
+		return false
+	}
+	if block == nil {
+		return false
+	}
+
+	logger.Error(fmt.Sprintf("[%s] 🚫 Job %s não executado: usuário %s bloqueado", w.id, job.ID, job.Username))
+	if _, err := w.queue.RetryJob(job.ID, job.Lease(), &queue.LoginBlockedError{Block: block}); err != nil {
+		logger.Error(fmt.Sprintf("[%s] ❌ Erro ao falhar job %s: %v", w.id, job.ID, err))
+	}
+	w.finished(job.ID)
+	return true
+}
+
+// recordLoginResult - conta falhas de credencial; ao bloquear, avisa dos jobs da fila que falharam junto
+func (w *Worker) recordLoginResult(username string, err error) {
+	block, guardErr := automation.RecordLoginResult(w.queue, username, err)
+	if guardErr != nil {
+		logger.Error(fmt.Sprintf("[%s] ⚠️ Erro ao registrar resultado do login: %v", w.id, guardErr))
+	}
+	if block == nil {
+		return
+	}
+
+	logger.Error(fmt.Sprintf("[%s] 🚫 Usuário %s bloqueado após %d falha(s) de credencial (%d job(s) da fila falhados)", w.id, username, block.Failures, len(block.FailedJobs)))
+	for _, jobID := range block.FailedJobs {
+		w.finished(jobID)
+	}
+}
+
+// execute - roda a automação do job (Chrome próprio ou sessão do lote)
+func (w *Worker) execute(ctx context.Context, job *queue.Job, session *batchSession) (*models.SearchResponse, error) {
+	bot := automation.NewCaixaBot(w.headless)
+	bot.SetBrowserPool(w.pool)
+	bot.SetProgressReporter(w.progressReporter(job))
+	bot.SetFailureReporter(w.failureReporter(job))
+
+	if session == nil {
+		return bot.LoginAndSearch(ctx, job.Username, job.Password, queryOf(job), selectionOf(job))
+	}
+
+	if err := session.ensure(ctx, job, bot); err != nil {
+		return nil, err
+	}
+	session.current.SetProgressReporter(w.progressReporter(job))
+	session.current.SetFailureReporter(w.failureReporter(job))
+
+	return session.search(ctx, job)
+}
+
+// progressReporter - publica as etapas do job (SSE /api/jobs/{id}/events)
+func (w *Worker) progressReporter(job *queue.Job) automation.ProgressReporter {
+	return func(stage automation.Stage, percent int, message string) {
+		if err := w.queue.PublishProgress(job.ID, string(stage), percent, message); err != nil {
+			logger.Error(fmt.Sprintf("[%s] ⚠️ Erro ao publicar progresso do job %s: %v", w.id, job.ID, err))
+		}
+	}
+}
+
+// failureReporter - grava as evidências da falha na pasta do job (nil sem store: nada é capturado)
+func (w *Worker) failureReporter(job *queue.Job) automation.FailureReporter {
+	if w.artifacts == nil {
+		return nil
+	}
+	return func(bundle *artifacts.Bundle) {
+		bundle.JobID = job.ID
+		bundle.Attempt = job.Attempts
+		if err := w.artifacts.Save(bundle); err != nil {
+			logger.Error(fmt.Sprintf("[%s] ⚠️ Erro ao gravar evidências do job %s: %v", w.id, job.ID, err))
+			return
+		}
+		logger.Info(fmt.Sprintf("[%s] 🧾 Evidências do job %s em GET /api/jobs/%s/artifacts", w.id, job.ID, job.ID))
+	}
+}
+
+// discardArtifacts - apaga as evidências de tentativas anteriores do job que acabou de dar certo
+func (w *Worker) discardArtifacts(jobID string) {
+	if w.artifacts == nil {
+		return
+	}
+	if err := w.artifacts.Delete(jobID); err != nil {
+		logger.Error(fmt.Sprintf("[%s] ⚠️ Erro ao apagar evidências do job %s: %v", w.id, jobID, err))
+	}
+}
+
+// queryOf - busca do job (jobs antigos não têm o tipo: CPF)
+func queryOf(job *queue.Job) automation.SearchQuery {
+	if job.SearchType == "" {
+		return automation.CPFQuery(job.SearchedValue())
+	}
+	return automation.SearchQuery{Type: job.SearchType, Value: job.SearchedValue()}
+}
+
+// selectionOf - propostas a extrair (jobs antigos não têm o campo: primeira)
+func selectionOf(job *queue.Job) models.ProposalSelection {
+	if job.ProposalSelection == "" {
+		return models.ProposalFirst
+	}
+	return job.ProposalSelection
+}
+
+// finished - grava o histórico e dispara o callback do job finalizado
+func (w *Worker) finished(jobID string) {
+	job, err := w.queue.GetJobStatus(jobID)
+	if err != nil {
+		logger.Error(fmt.Sprintf("[%s] ⚠️ Erro ao buscar job finalizado %s: %v", w.id, jobID, err))
+		return
+	}
+
+	w.recordHistory(job)
+	w.notifyFinished(job)
+}
+
+// recordHistory - grava o job no histórico durável
+func (w *Worker) recordHistory(job *queue.Job) {
+	if w.history == nil {
+		return
+	}
+
+	record, err := history.FromJob(job)
+	if err == nil {
+		err = w.history.Save(record)
+	}
+	if err != nil {
+		logger.Error(fmt.Sprintf("[%s] ⚠️ Erro ao gravar histórico do job %s: %v", w.id, job.ID, err))
+	}
+}
+
+// notifyFinished - dispara o callback do job (se houver callback_url)
+func (w *Worker) notifyFinished(job *queue.Job) {
+	// Cancelados não têm callback
+	if job.CallbackURL == "" || (job.Status != queue.StatusCompleted && job.Status != queue.StatusFailed) {
+		return
+	}
+	if w.webhooks == nil {
+		logger.Error(fmt.Sprintf("[%s] ⚠️ Job %s tem callback_url mas os webhooks estão desabilitados (%s)", w.id, job.ID, webhook.SecretEnv))
+		return
+	}
+
+	if _, err := w.webhooks.NotifyJobFinished(job); err != nil {
+		logger.Error(fmt.Sprintf("[%s] ⚠️ Erro ao registrar callback do job %s: %v", w.id, job.ID, err))
+	}
+}
+
+// startHeartbeat - renova o lease periodicamente até stop ser chamado
+// Se o lease for perdido (reaper devolveu o job), cancela o job em execução:
+// outro worker vai rodar a próxima tentativa e este não pode gravar o resultado
+func (w *Worker) startHeartbeat(jobID string, lease queue.Lease) (lost func() bool, stop func()) {
+	done := make(chan struct{})
+	ticker := time.NewTicker(w.queue.GetConfig().HeartbeatInterval)
+	var leaseLost atomic.Bool
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				err := w.queue.Heartbeat(jobID, lease)
+				if errors.Is(err, queue.ErrLeaseLost) {
+					logger.Error(fmt.Sprintf("[%s] ⚠️ Lease do job %s perdido (reaper devolveu o job), interrompendo", w.id, jobID))
+					leaseLost.Store(true)
+					w.current.cancel(jobID)
+					return
+				}
+				if err != nil {
+					logger.Error(fmt.Sprintf("[%s] ⚠️ Erro no heartbeat do job %s: %v", w.id, jobID, err))
+				}
+			}
+		}
+	}()
+
+	return leaseLost.Load, func() { close(done) }
+}
+
+// runReaper - varre periodicamente leases expirados
+func (w *Worker) runReaper(ctx context.Context) {
+	ticker := time.NewTicker(w.queue.GetConfig().ReapInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		reaped, err := w.queue.ReapExpiredJobs()
+		if err != nil {
+			logger.Error(fmt.Sprintf("[%s] ❌ Erro no reaper: %v", w.id, err))
+			continue
+		}
+		if reaped > 0 {
+			logger.Info(fmt.Sprintf("[%s] ♻️ Reaper recolheu %d job(s) com lease expirado", w.id, reaped))
+		}
+	}
+}
+
+// runPromoter - promove periodicamente jobs agendados para nova tentativa
+func (w *Worker) runPromoter(ctx context.Context) {
+	ticker := time.NewTicker(w.queue.GetConfig().PromoteInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		promoted, err := w.queue.PromoteDelayedJobs()
+		if err != nil {
+			logger.Error(fmt.Sprintf("[%s] ❌ Erro ao promover jobs agendados: %v", w.id, err))
+			continue
+		}
+		if promoted > 0 {
+			logger.Info(fmt.Sprintf("[%s] ⏰ %d job(s) voltaram para a fila após backoff", w.id, promoted))
+		}
+	}
+}
+
+// runningJob - job em execução neste worker (para cancelamento)
+type runningJob struct {
+	mu         sync.Mutex
+	jobID      string
+	cancelFunc context.CancelFunc
+}
+
+// start - registra o job e retorna o contexto que será cancelado
+func (r *runningJob) start(jobID string) context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.jobID = jobID
+	r.cancelFunc = cancel
+
+	return ctx
+}
+
+// cancel - cancela o contexto se o job informado estiver em execução
+func (r *runningJob) cancel(jobID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.jobID != jobID || r.cancelFunc == nil {
+		return false
+	}
+	r.cancelFunc()
+	return true
+}
+
+// finish - libera o contexto do job atual
+func (r *runningJob) finish() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.cancelFunc != nil {
+		r.cancelFunc()
+	}
+	r.jobID = ""
+	r.cancelFunc = nil
+}
+
+// batchSession - Chrome logado reaproveitado entre os jobs de um lote
+type batchSession struct {
+	current  *automation.Session
+	password string
+
+	// Login recusado por credencial: os próximos jobs do lote falham sem tentar de novo
+	loginErr      error
+	loginUsername string
+	loginPassword string
+}
+
+// ensure - garante um Chrome logado com as credenciais do job
+// Abre um novo (com o bot do job) se ainda não há, se o Chrome caiu ou se as credenciais mudaram
+func (b *batchSession) ensure(ctx context.Context, job *queue.Job, bot *automation.CaixaBot) error {
+	if b.current != nil && (!b.current.Alive() || b.current.Username != job.Username || b.password != job.Password) {
+		b.close()
+	}
+
+	if b.current == nil {
+		if b.loginErr != nil && b.loginUsername == job.Username && b.loginPassword == job.Password {
+			return b.loginErr
+		}
+
+		session, err := bot.OpenSession(ctx, job.Username, job.Password)
+		if err != nil {
+			if automation.IsCredentialError(err) {
+				b.loginErr, b.loginUsername, b.loginPassword = err, job.Username, job.Password
+			}
+			return err
+		}
+		b.current = session
+		b.password = job.Password
+	}
+	return nil
+}
+
+// search - busca o CPF do job no Chrome do lote (SearchMany da sessão logada)
+// Cada job do lote é um item: lease, cancelamento e progresso são por job, então o
+// próximo CPF só é pego da fila (ClaimBatchJob) quando este termina
+func (b *batchSession) search(ctx context.Context, job *queue.Job) (*models.SearchResponse, error) {
+	var response *models.SearchResponse
+	var err error
+	for result := range b.current.SearchMany(ctx, job.Password, []string{job.SearchedValue()}, selectionOf(job)) {
+		response, err = result.Response, result.Err
+	}
+	return response, err
+}
+
+// close - encerra o Chrome do lote
+func (b *batchSession) close() {
+	if b.current != nil {
+		b.current.Close()
+		b.current = nil
+		b.password = ""
+	}
+}
+
+// sleep - espera d ou até o ctx ser cancelado
+func sleep(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
@@ -0,0 +1,138 @@
+package documents
+
+import (
+	"fmt"
+	"strings"
+)
+
+// OnlyDigits - remove tudo que não for dígito (pontos, traços, espaços)
+func OnlyDigits(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// NormalizeCPF - limpa a formatação e valida os dígitos verificadores
+func NormalizeCPF(value string) (string, error) {
+	cpf := OnlyDigits(value)
+
+	// Planilhas costumam perder zeros à esquerda (até dois, de CPFs começando com 0 ou 00)
+	// Menos de 9 dígitos não é CPF sem zeros, é outro valor: não completa
+	if len(cpf) == 9 || len(cpf) == 10 {
+		cpf = strings.Repeat("0", 11-len(cpf)) + cpf
+	}
+
+	if !ValidCPF(cpf) {
+		return "", fmt.Errorf("CPF inválido: %q", value)
+	}
+	return cpf, nil
+}
+
+// ValidCPF - valida CPF (11 dígitos, sem formatação)
+func ValidCPF(cpf string) bool {
+	if len(cpf) != 11 || allSameDigit(cpf) {
+		return false
+	}
+
+	return checkDigit(cpf[:9], 10) == cpf[9] && checkDigit(cpf[:10], 11) == cpf[10]
+}
+
+// checkDigit - calcula dígito verificador do CPF (módulo 11)
+func checkDigit(digits string, weight int) byte {
+	sum := 0
+	for _, r := range digits {
+		sum += int(r-'0') * weight
+		weight--
+	}
+
+	rest := sum % 11
+	if rest < 2 {
+		return '0'
+	}
+	return byte('0' + 11 - rest)
+}
+
+// allSameDigit - "111.111.111-11" passa no cálculo mas não é válido
+func allSameDigit(value string) bool {
+	return strings.Count(value, value[:1]) == len(value)
+}
+
+// NormalizeCNPJ - limpa a formatação e valida os dígitos verificadores
+func NormalizeCNPJ(value string) (string, error) {
+	cnpj := OnlyDigits(value)
+
+	// Planilhas costumam perder zeros à esquerda
+	if len(cnpj) > 0 && len(cnpj) < 14 {
+		cnpj = strings.Repeat("0", 14-len(cnpj)) + cnpj
+	}
+
+	if !ValidCNPJ(cnpj) {
+		return "", fmt.Errorf("CNPJ inválido: %q", value)
+	}
+	return cnpj, nil
+}
+
+// ValidCNPJ - valida CNPJ (14 dígitos, sem formatação)
+func ValidCNPJ(cnpj string) bool {
+	if len(cnpj) != 14 || allSameDigit(cnpj) {
+		return false
+	}
+
+	return cnpjCheckDigit(cnpj[:12]) == cnpj[12] && cnpjCheckDigit(cnpj[:13]) == cnpj[13]
+}
+
+// cnpjCheckDigit - calcula dígito verificador do CNPJ (pesos 2 a 9, da direita para a esquerda)
+func cnpjCheckDigit(digits string) byte {
+	sum := 0
+	weight := 2
+	for i := len(digits) - 1; i >= 0; i-- {
+		sum += int(digits[i]-'0') * weight
+		weight++
+		if weight > 9 {
+			weight = 2
+		}
+	}
+
+	rest := sum % 11
+	if rest < 2 {
+		return '0'
+	}
+	return byte('0' + 11 - rest)
+}
+
+// Faixa de tamanho aceita para números de proposta/contrato (só dígitos)
+const (
+	minOperationNumberDigits = 6
+	maxOperationNumberDigits = 20
+)
+
+// NormalizeProposalNumber - número da proposta só com dígitos
+func NormalizeProposalNumber(value string) (string, error) {
+	return normalizeOperationNumber(value, "número da proposta")
+}
+
+// NormalizeContractNumber - número do contrato só com dígitos ("8.4444.0123456-7" → "8444401234567")
+func NormalizeContractNumber(value string) (string, error) {
+	return normalizeOperationNumber(value, "número do contrato")
+}
+
+// normalizeOperationNumber - remove a formatação e confere o tamanho
+// Letras não são aceitas: o portal só tem números nesses campos
+func normalizeOperationNumber(value, label string) (string, error) {
+	trimmed := strings.TrimSpace(value)
+	if strings.IndexFunc(trimmed, func(r rune) bool {
+		return !(r >= '0' && r <= '9') && !strings.ContainsRune(".-/ ", r)
+	}) >= 0 {
+		return "", fmt.Errorf("%s inválido: %q", label, value)
+	}
+
+	number := OnlyDigits(trimmed)
+	if len(number) < minOperationNumberDigits || len(number) > maxOperationNumberDigits {
+		return "", fmt.Errorf("%s inválido: %q (esperado de %d a %d dígitos)", label, value, minOperationNumberDigits, maxOperationNumberDigits)
+	}
+	return number, nil
+}
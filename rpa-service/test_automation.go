@@ -1,7 +1,9 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"os"
 
 	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/internal/automation"
 	"github.com/lukasglimalkl/caixa-habitacao-automation/rpa-service/pkg/logger"
@@ -13,13 +15,23 @@ func main() {
 	
 	fmt.Println("🧪 Testando automação diretamente...")
 	
+	// Credenciais vêm do ambiente (nunca versionar senhas)
+	username := os.Getenv("CAIXA_USERNAME")
+	password := os.Getenv("CAIXA_PASSWORD")
+	cpf := os.Getenv("CAIXA_CPF")
+	selection, err := automation.ParseProposalSelection(os.Getenv("CAIXA_PROPOSAL_SELECTION"))
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	if username == "" || password == "" || cpf == "" {
+		fmt.Println("❌ Defina CAIXA_USERNAME, CAIXA_PASSWORD e CAIXA_CPF")
+		return
+	}
+	
 	bot := automation.NewCaixaBot(false) // headless = false
 	
-	response, err := bot.LoginAndSearch(
-		"marcella@iprimeconsultoria.com.br",
-		"Bolinho2020",
-		"53304153810",
-	)
+	response, err := bot.LoginAndSearch(context.Background(), username, password, automation.CPFQuery(cpf), selection)
 	
 	if err != nil {
 		fmt.Printf("❌ Erro: %v\n", err)
@@ -29,4 +41,9 @@ func main() {
 	fmt.Printf("✅ Sucesso!\n")
 	fmt.Printf("📋 Nome: %s\n", response.Data.Nome)
 	fmt.Printf("📋 CPF: %s\n", response.Data.CPF)
+	for _, result := range response.Results {
+		if result.Proposta != nil {
+			fmt.Printf("📄 Proposta %s (%s): %s\n", result.Proposta.Numero, result.Proposta.Situacao, result.NumeroContrato)
+		}
+	}
 }
\ No newline at end of file